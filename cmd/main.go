@@ -2,38 +2,79 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
 	"flag"
+	"io"
 	http "net/http"
 	"os"
+	"os/signal"
 	"runtime"
-	"strconv"
-	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"log/slog"
 
 	"github.com/grafana/pyroscope-go"
+	"github.com/grafana/quickpizza"
+	"github.com/grafana/quickpizza/pkg/config"
 	"github.com/grafana/quickpizza/pkg/database"
+	qpgraphql "github.com/grafana/quickpizza/pkg/graphql"
 	qpgrpc "github.com/grafana/quickpizza/pkg/grpc"
 	qphttp "github.com/grafana/quickpizza/pkg/http"
+	"github.com/grafana/quickpizza/pkg/jwt"
 	"github.com/grafana/quickpizza/pkg/logging"
+	"github.com/grafana/quickpizza/pkg/openapi"
+	"github.com/grafana/quickpizza/pkg/password"
+	"github.com/grafana/quickpizza/pkg/ratelimit"
+	"github.com/grafana/quickpizza/pkg/util"
 	"github.com/hashicorp/go-retryablehttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/propagation"
 )
 
-var devMode = flag.Bool("dev", false, "Run in development mode with Vite dev server")
+var (
+	devMode     = flag.Bool("dev", false, "Run in development mode with Vite dev server")
+	configFile  = flag.String("config", os.Getenv("QUICKPIZZA_CONFIG_FILE"), "YAML configuration file, whose settings are overridden by env vars")
+	printConfig = flag.Bool("print-config", false, "Print the effective configuration in YAML, and exit")
+)
 
 func main() {
 	flag.Parse()
 	// write logs as JSON
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-		Level: logging.GetLogLevel(),
+		Level: logging.Level,
 	})))
 
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			slog.Error("invalid configuration", "err", err)
+		}
+		os.Exit(1)
+	}
+	logging.Level.Set(cfg.LogLevel)
+
+	if *printConfig {
+		data, err := cfg.YAML()
+		if err != nil {
+			slog.Error("printing configuration", "err", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	slog.Debug("effective configuration", "config", cfg)
+
 	// Profiling in pull mode is enabled by default.
 	// If QUICKPIZZA_PYROSCOPE_ENDPOINT is set, profiling in push mode will be enabled.
-	profilingConfig, profilingEnabled := envPyroscopeConfig()
+	profilingConfig, profilingEnabled := pyroscopeConfig(cfg)
 	if profilingEnabled {
 		slog.Info("enabling Pyroscope profiling in Push mode")
 
@@ -47,13 +88,18 @@ func main() {
 	otelInstaller := &qphttp.OTelInstaller{}
 
 	// TODO: use standard OTEL_EXPORTER_OTLP_ENDPOINT env var
-	otlpEndpoint, _ := os.LookupEnv("QUICKPIZZA_OTLP_ENDPOINT")
-	if otlpEndpoint != "" {
+	if cfg.OTel.Endpoint != "" {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
 		var err error
-		otelInstaller, err = qphttp.NewOTelInstaller(ctx, otlpEndpoint)
+		otelInstaller, err = qphttp.NewOTelInstaller(ctx, qphttp.OTelConfig{
+			Endpoint:          cfg.OTel.Endpoint,
+			Protocol:          cfg.OTel.Protocol,
+			ServiceName:       cfg.OTel.ServiceName,
+			ServiceNamespace:  cfg.OTel.ServiceNamespace,
+			ServiceInstanceID: cfg.OTel.ServiceInstanceID,
+		})
 		if err != nil {
 			slog.Error("creating OpenTelemetryinstaller", "err", err)
 			os.Exit(1)
@@ -61,94 +107,268 @@ func main() {
 
 		slog.Debug("enabling OpenTelemetry tracing and metrics")
 
-		if envBool("QUICKPIZZA_TRUST_CLIENT_TRACEID") {
+		if cfg.OTel.TrustClientTraceID {
 			otelInstaller.Insecure()
 		}
 	}
 
-	// Create an HTTP client configured from env vars.
-	// If no specific env vars are set, this will return a http client that does not perform any retries.
-	httpCli := clientFromEnv()
+	// Create an HTTP client with the configured timeout and retries.
+	// If retries are not configured, this will return a http client that does not perform any retries.
+	httpCli := newHTTPClient(cfg.Client)
+
+	// Requests between services are authenticated with QUICKPIZZA_INTERNAL_TOKEN, which must be set when they run in
+	// separate instances. Otherwise, they share the process, and thus a random token.
+	internalToken := cfg.Server.InternalToken
+	if internalToken == "" {
+		internalToken = rand.Text()
+	}
+
+	var serverOpts []qphttp.ServerOption
+	serverOpts = append(serverOpts, qphttp.WithInternalToken(internalToken))
+
+	// Clients are identified by X-Forwarded-For only in requests forwarded by QUICKPIZZA_TRUSTED_PROXIES. The list was
+	// already checked when loading the configuration.
+	trustedProxies, _ := qphttp.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	serverOpts = append(serverOpts, qphttp.WithTrustedProxies(trustedProxies))
+
+	// Deadline propagation is opt-in, so that both cascading timeouts (the default, where every hop applies its own
+	// fixed QUICKPIZZA_TIMEOUT) and deadline-aware behavior can be demonstrated.
+	if cfg.Server.DeadlinePropagation {
+		serverOpts = append(serverOpts, qphttp.WithDeadlinePropagation(cfg.Server.RequestTimeout))
+	}
+
+	// Rate limiting is disabled unless QUICKPIZZA_RATE_LIMIT is set.
+	if rateLimit, ok := rateLimitConfig(cfg.RateLimit); ok {
+		serverOpts = append(serverOpts, qphttp.WithRateLimit(rateLimit))
+	}
+
+	// JWT authentication is disabled unless QUICKPIZZA_JWT_ALGORITHM is set.
+	jwtSigner, jwtVerifier, jwtEnabled := setupJWT(cfg, httpCli)
+	if jwtEnabled {
+		serverOpts = append(serverOpts, qphttp.WithJWT(jwtSigner, jwtVerifier))
+	}
+
+	serverOpts = append(serverOpts, qphttp.WithPasswordPolicy(passwordPolicy(cfg.Auth)))
+
+	// On shutdown, /ready fails for QUICKPIZZA_SHUTDOWN_DELAY before the listener is closed, so that load balancers stop
+	// sending requests first.
+	serverOpts = append(serverOpts, qphttp.WithShutdownDelay(cfg.Server.ShutdownDelay))
+
+	// /ready and /started check the dependencies of the services enabled below, except for the checks disabled with
+	// QUICKPIZZA_PROBE_DISABLED_CHECKS.
+	serverOpts = append(serverOpts, qphttp.WithProbeConfig(qphttp.ProbeConfig{
+		Timeout:  cfg.Server.ProbeTimeout,
+		Disabled: cfg.Server.ProbeDisabledChecks,
+	}))
+
+	// Artificial delays and failures, e.g. QUICKPIZZA_DELAY_COPY, are disabled unless configured.
+	serverOpts = append(serverOpts, qphttp.WithFaults(cfg.Faults.HTTPFaults()))
+
+	// Conditional updates of ratings are optional, so that lost updates can be demonstrated unless
+	// QUICKPIZZA_REQUIRE_IF_MATCH is set.
+	if cfg.Server.RequireIfMatch {
+		serverOpts = append(serverOpts, qphttp.WithIfMatchRequired())
+	}
+
+	// Requests are validated against the OpenAPI document, unless QUICKPIZZA_DISABLE_OPENAPI_VALIDATION is set.
+	// Responses are validated too in development mode, or if QUICKPIZZA_OPENAPI_VALIDATE_RESPONSES is set, which also
+	// makes the server refuse to start if its routes do not match the document.
+	spec, err := openapi.Load(quickpizza.OpenAPISpec)
+	if err != nil {
+		slog.Error("loading OpenAPI document", "err", err)
+		os.Exit(1)
+	}
+	if !cfg.Server.DisableOpenAPIValidation {
+		serverOpts = append(serverOpts, qphttp.WithOpenAPIValidation(spec))
+	}
+	validateResponses := *devMode || cfg.Server.OpenAPIValidateResponses
+	if validateResponses {
+		serverOpts = append(serverOpts, qphttp.WithOpenAPIResponseValidation(spec))
+	}
 
 	// Create the QuickPizza server.
-	server := qphttp.NewServer(profilingEnabled, otelInstaller)
+	server := qphttp.NewServer(profilingEnabled, otelInstaller, serverOpts...)
+
+	// Database connections are closed on shutdown, once nothing uses them anymore.
+	var dbs []io.Closer
 
 	server.AddLivenessProbes()
 
 	// Always add Prometheus handler endpoint.
 	server.AddPrometheusHandler()
 
+	// Serve the OpenAPI document, listing the operations of the services enabled below, and the API explorer.
+	server.AddOpenAPI(quickpizza.OpenAPISpec)
+
 	// Enable services in this instance. Services are enabled with the following logic:
 	// If QUICKPIZZA_ENABLE_ALL_SERVICES is either _not set_ or set to a truthy value, all services are enabled. This is the
 	// default behavior.
 	// If QUICKPIZZA_ENABLE_ALL_SERVICES is set to a falsy values, services are opted-in by setting the environment variables
 	// below to a truty value.
+	svcs := cfg.Services
 
-	if envServe("QUICKPIZZA_ENABLE_HTTP_TESTING_SERVICE") {
+	if svcs.Serve(svcs.HTTPTesting) {
 		server.AddHTTPTesting()
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_TEST_K6_IO_SERVICE") {
+	if svcs.Serve(svcs.TestK6IO) {
 		server.AddTestK6IO()
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_CONFIG_SERVICE") {
-		// Prefix for env vars is QUICKPIZZA_CONF_ instead of QUICKPIZZA_CONFIG_ to avoid picking up variables
-		// generated by K8s from the pod name.
-		server.AddConfigHandler(envConfig("QUICKPIZZA_CONF_"))
+	if svcs.Serve(svcs.Config) {
+		server.AddConfigHandler(cfg.Conf)
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_PUBLIC_API_SERVICE") {
+	if svcs.Serve(svcs.PublicAPI) {
 		// Serve frontend static assets
 		server.AddFrontend(*devMode)
 
 		// If running as a microservice (not all services in one instance),
 		// also act as a gateway to proxy public-facing endpoints
-		if !envServeAll() {
+		if !svcs.All {
 			server.AddGateway(
-				envEndpoint("QUICKPIZZA_ENABLE_CATALOG_SERVICE", "QUICKPIZZA_CATALOG_ENDPOINT"),
-				envEndpoint("QUICKPIZZA_ENABLE_COPY_SERVICE", "QUICKPIZZA_COPY_ENDPOINT"),
-				envEndpoint("QUICKPIZZA_ENABLE_WS_SERVICE", "QUICKPIZZA_WS_ENDPOINT"),
-				envEndpoint("QUICKPIZZA_ENABLE_RECOMMENDATIONS_SERVICE", "QUICKPIZZA_RECOMMENDATIONS_ENDPOINT"),
-				envEndpoint("QUICKPIZZA_ENABLE_CONFIG_SERVICE", "QUICKPIZZA_CONFIG_ENDPOINT"),
+				cfg.Endpoint(svcs.Catalog, cfg.Endpoints.Catalog),
+				cfg.Endpoint(svcs.Copy, cfg.Endpoints.Copy),
+				cfg.Endpoint(svcs.WS, cfg.Endpoints.WS),
+				cfg.Endpoint(svcs.Recommendations, cfg.Endpoints.Recommendations),
+				cfg.Endpoint(svcs.Config, cfg.Endpoints.Config),
 			)
+			addEndpointCheck(cfg, server, "gateway:catalog", svcs.Catalog, cfg.Endpoints.Catalog)
+			addEndpointCheck(cfg, server, "gateway:copy", svcs.Copy, cfg.Endpoints.Copy)
+			addEndpointCheck(cfg, server, "gateway:ws", svcs.WS, cfg.Endpoints.WS)
+			addEndpointCheck(cfg, server, "gateway:recommendations", svcs.Recommendations, cfg.Endpoints.Recommendations)
+			addEndpointCheck(cfg, server, "gateway:config", svcs.Config, cfg.Endpoints.Config)
 		}
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_WS_SERVICE") {
+	if svcs.Serve(svcs.WS) {
 		server.AddWebSocket()
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_CATALOG_SERVICE") {
-		db, err := database.NewCatalog(envDBConnString())
+	if svcs.Serve(svcs.Catalog) {
+		db, err := database.NewCatalog(cfg.Database.Options(), cfg.CatalogConfig())
 		if err != nil {
 			slog.Error("setting up database connection", "err", err)
 			os.Exit(1)
 		}
-		server.AddCatalogHandler(db)
+		dbs = append(dbs, db)
+		server.AddReadinessCheck("catalog:database", db.Ping)
+		server.AddStartupCheck("catalog:migrations", db.CheckMigrations)
+
+		// Reviews are moderated with the banned words of the Copy service.
+		copyClient := qphttp.NewCopyClient(cfg.Endpoint(svcs.Copy, cfg.Endpoints.Copy)).WithClient(httpCli).WithInternalToken(internalToken)
+		server.AddCatalogHandler(db, copyClient)
+
+		// The OpenID Connect provider is backed by the users of the Catalog service, so it runs along with it.
+		if svcs.Serve(svcs.OIDC) {
+			if err := server.AddOIDCProvider(db, oidcConfig(cfg, jwtSigner)); err != nil {
+				slog.Error("setting up OpenID Connect provider", "err", err)
+				os.Exit(1)
+			}
+		}
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_COPY_SERVICE") {
-		db, err := database.NewCopy(envDBConnString())
+	if svcs.Serve(svcs.Copy) {
+		db, err := database.NewCopy(cfg.Database.Options())
 		if err != nil {
 			slog.Error("setting up database connection", "err", err)
 			os.Exit(1)
 		}
+		dbs = append(dbs, db)
+		server.AddReadinessCheck("copy:database", db.Ping)
+		server.AddStartupCheck("copy:migrations", db.CheckMigrations)
+
 		server.AddCopyHandler(db)
 	}
 
 	// Recommendations service needs to know the URL where the Catalog and Copy services are located.
 	// This URL is automatically set to `localhost` if Recommendations is enabled at the same time as either of those.
 	// If they are not, URLs are sourced from QUICKPIZZA_CATALOG_ENDPOINT and QUICKPIZZA_COPY_ENDPOINT.
-	if envServe("QUICKPIZZA_ENABLE_RECOMMENDATIONS_SERVICE") {
-		catalogClient := qphttp.NewCatalogClient(envEndpoint("QUICKPIZZA_ENABLE_CATALOG_SERVICE", "QUICKPIZZA_CATALOG_ENDPOINT")).WithClient(httpCli)
-		copyClient := qphttp.NewCopyClient(envEndpoint("QUICKPIZZA_ENABLE_COPY_SERVICE", "QUICKPIZZA_COPY_ENDPOINT")).WithClient(httpCli)
+	if svcs.Serve(svcs.Recommendations) {
+		catalogClient := qphttp.NewCatalogClient(cfg.Endpoint(svcs.Catalog, cfg.Endpoints.Catalog)).WithClient(httpCli).WithInternalToken(internalToken)
+		copyClient := qphttp.NewCopyClient(cfg.Endpoint(svcs.Copy, cfg.Endpoints.Copy)).WithClient(httpCli).WithInternalToken(internalToken)
 
 		server.AddRecommendations(catalogClient, copyClient)
+		addEndpointCheck(cfg, server, "recommendations:catalog", svcs.Catalog, cfg.Endpoints.Catalog)
+		addEndpointCheck(cfg, server, "recommendations:copy", svcs.Copy, cfg.Endpoints.Copy)
 	}
 
-	if envServe("QUICKPIZZA_ENABLE_GRPC_SERVICE") {
-		grpcServer := qpgrpc.NewServer(":3334", ":3335")
+	if svcs.Serve(svcs.GraphQL) {
+		// Like the gRPC service, the GraphQL one runs the business logic of the other services itself.
+		catalog, err := database.NewCatalog(cfg.Database.Options(), cfg.CatalogConfig())
+		if err != nil {
+			slog.Error("setting up database connection", "err", err)
+			os.Exit(1)
+		}
+		cp, err := database.NewCopy(cfg.Database.Options())
+		if err != nil {
+			slog.Error("setting up database connection", "err", err)
+			os.Exit(1)
+		}
+		dbs = append(dbs, catalog, cp)
+		server.AddReadinessCheck("graphql:catalog-database", catalog.Ping)
+		server.AddReadinessCheck("graphql:copy-database", cp.Ping)
+
+		tp, err := otelInstaller.TracerProvider("graphql")
+		if err != nil {
+			slog.Error("setting up GraphQL tracing", "err", err)
+			os.Exit(1)
+		}
+
+		graphqlHandler := qpgraphql.NewHandler(catalog, cp,
+			qpgraphql.WithTracing(tp),
+			qpgraphql.WithDataLoaders(!cfg.GraphQL.DisableDataLoaders),
+			qpgraphql.WithLimits(cfg.GraphQL.MaxDepth, cfg.GraphQL.MaxComplexity),
+		)
+		server.AddGraphQL(graphqlHandler)
+		// Subscriptions run over WebSockets, which the HTTP server does not wait for on shutdown.
+		server.RegisterOnShutdown(graphqlHandler.Close)
+	}
+
+	var grpcServer *qpgrpc.Server
+	if svcs.Serve(svcs.GRPC) {
+		// The gRPC service runs the business logic of the Catalog, Copy and Recommendations services itself, on the same
+		// database.
+		catalog, err := database.NewCatalog(cfg.Database.Options(), cfg.CatalogConfig())
+		if err != nil {
+			slog.Error("setting up database connection", "err", err)
+			os.Exit(1)
+		}
+		cp, err := database.NewCopy(cfg.Database.Options())
+		if err != nil {
+			slog.Error("setting up database connection", "err", err)
+			os.Exit(1)
+		}
+		dbs = append(dbs, catalog, cp)
+		server.AddReadinessCheck("grpc:catalog-database", catalog.Ping)
+		server.AddReadinessCheck("grpc:copy-database", cp.Ping)
+
+		tp, err := otelInstaller.TracerProvider("grpc")
+		if err != nil {
+			slog.Error("setting up gRPC tracing", "err", err)
+			os.Exit(1)
+		}
+
+		grpcOpts := []qpgrpc.ServerOption{qpgrpc.WithTracing(tp, otelInstaller.TrustsClientTraceID())}
+
+		// The gRPC server is plaintext unless TLS is configured, with certificate files or a self-signed certificate.
+		if tlsConfig, ok := grpcTLSConfig(cfg.GRPC); ok {
+			config, err := tlsConfig.Load()
+			if err != nil {
+				slog.Error("setting up gRPC TLS", "err", err)
+				os.Exit(1)
+			}
+			grpcOpts = append(grpcOpts, qpgrpc.WithTLS(config))
+		}
+
+		grpcServer = qpgrpc.NewServer(
+			cfg.GRPC.ListenAddress,
+			cfg.GRPC.HealthListenAddress,
+			catalog, cp, grpcOpts...,
+		)
+		// Browsers and HTTP clients call the same service over gRPC-Web and Connect on the main HTTP listener.
+		server.AddGRPCWeb(qpgrpc.WebPathPrefix, grpcServer.WebHandler())
+
 		go func() {
 			err := grpcServer.ListenAndServe()
 			if err != nil {
@@ -158,17 +378,87 @@ func main() {
 		}()
 	}
 
+	if validateResponses {
+		// Operations can only be missing if all services run in this instance.
+		if err := server.CheckOpenAPIRoutes(svcs.All); err != nil {
+			slog.Error("routes do not match the OpenAPI document", "err", err)
+			os.Exit(1)
+		}
+	}
+
 	listen := ":3333"
-	slog.Info("Starting QuickPizza", "listenAddress", listen)
-	err := http.ListenAndServe(listen, server)
-	if err != nil {
-		slog.Error("Running HTTP server", "err", err)
-		os.Exit(1)
+	go func() {
+		slog.Info("Starting QuickPizza", "listenAddress", listen)
+		err := server.ListenAndServe(listen)
+		if err != nil {
+			slog.Error("Running HTTP server", "err", err)
+			os.Exit(1)
+		}
+	}()
+
+	// On SIGTERM or SIGINT, the servers are shut down gracefully. A second signal exits right away.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	<-ctx.Done()
+	stop()
+
+	shutdown(cfg, server, grpcServer, dbs, otelInstaller)
+}
+
+// shutdown drains the HTTP and gRPC servers concurrently, for up to QUICKPIZZA_SHUTDOWN_TIMEOUT and
+// QUICKPIZZA_GRPC_SHUTDOWN_TIMEOUT respectively. Then, it closes the database connections, and flushes the spans and
+// metrics that were not exported yet.
+func shutdown(cfg *config.Config, server *qphttp.Server, grpcServer *qpgrpc.Server, dbs []io.Closer, otelInstaller *qphttp.OTelInstaller) {
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		timeout := cfg.Server.ShutdownTimeout
+		slog.Info("Shutting down HTTP server", "timeout", timeout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP requests were cancelled on shutdown", "err", err)
+		}
+	}()
+
+	if grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			timeout := cfg.GRPC.ShutdownTimeout
+			slog.Info("Shutting down gRPC server", "timeout", timeout)
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				slog.Warn("gRPC calls were cancelled on shutdown", "err", err)
+			}
+		}()
 	}
+
+	wg.Wait()
+
+	for _, db := range dbs {
+		if err := db.Close(); err != nil {
+			slog.Warn("Closing database connection", "err", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := otelInstaller.Shutdown(ctx); err != nil {
+		slog.Warn("Flushing telemetry", "err", err)
+	}
+
+	slog.Info("QuickPizza stopped")
 }
 
-// clientFromEnv returns an *http.Client implementation according to the retries and backoff specified in env vars.
-func clientFromEnv() *http.Client {
+// newHTTPClient returns an *http.Client implementation with the timeout, retries and backoff of config.
+func newHTTPClient(config config.Client) *http.Client {
 	// Configure an underlying client with otel transport.
 	// Otel transport takes care of generating spans for outcoming requests, as well as propagating trace IDs on those
 	// requests.
@@ -181,70 +471,46 @@ func clientFromEnv() *http.Client {
 				propagation.Baggage{},
 			)),
 		),
+		Timeout: config.Timeout,
 	}
 
-	timeout := envDuration("QUICKPIZZA_TIMEOUT")
-	if timeout == 0 {
-		timeout = time.Second
-	}
-
-	httpClient.Timeout = timeout
-
 	retriableClient := retryablehttp.NewClient()
 	retriableClient.Logger = nil
 	// Configure retryablehttp to use the instrumented client.
 	// Retries occur at the retriableClient layer, so instrumentation will see failures from httpClient.
 	retriableClient.HTTPClient = httpClient
 
-	retriableClient.RetryMax = envInt("QUICKPIZZA_RETRIES")
+	retriableClient.RetryMax = config.Retries
 
-	if retryMin := envDuration("QUICKPIZZA_BACKOFF_MIN"); retryMin != 0 {
-		retriableClient.RetryWaitMin = retryMin
+	if config.BackoffMin != 0 {
+		retriableClient.RetryWaitMin = config.BackoffMin
 	}
 
-	if retryMax := envDuration("QUICKPIZZA_BACKOFF_MAX"); retryMax != 0 {
-		retriableClient.RetryWaitMax = retryMax
+	if config.BackoffMax != 0 {
+		retriableClient.RetryWaitMax = config.BackoffMax
 	}
 
 	// Return a stdlib client that uses retryablehttp as transport.
 	return retriableClient.StandardClient()
 }
 
-func envPyroscopeConfig() (pyroscope.Config, bool) {
-	pyroscopeAddr, ok := os.LookupEnv("QUICKPIZZA_PYROSCOPE_ENDPOINT")
-	if !ok {
+// pyroscopeConfig returns the configuration of profiling in push mode, and whether it is enabled.
+func pyroscopeConfig(cfg *config.Config) (pyroscope.Config, bool) {
+	if cfg.Pyroscope.Endpoint == "" {
 		return pyroscope.Config{}, false
 	}
 
-	svcName, ok := os.LookupEnv("QUICKPIZZA_PYROSCOPE_NAME")
-	if !ok {
-		svcName, ok = os.LookupEnv("QUICKPIZZA_OTEL_SERVICE_NAME")
-		if !ok {
-			svcName = "quickpizza"
-		}
-	}
-
-	svcNamespace, ok := os.LookupEnv("QUICKPIZZA_PYROSCOPE_NAMESPACE")
-	if !ok {
-		svcNamespace = "quickpizza"
-	}
-
-	svcNamespaceLabel, ok := os.LookupEnv("QUICKPIZZA_PYROSCOPE_NAMESPACE_LABEL_NAME")
-	if !ok {
-		svcNamespaceLabel = "namespace"
-	}
-
-	svcGitRef, ok := os.LookupEnv("QUICKPIZZA_PYROSCOPE_SERVICE_GIT_REF")
-	if !ok {
-		svcGitRef = "refs/heads/main"
+	svcName := cfg.Pyroscope.Name
+	if svcName == "" {
+		svcName = cfg.OTel.ServiceName
 	}
 
 	return pyroscope.Config{
 		ApplicationName: svcName,
-		ServerAddress:   pyroscopeAddr,
+		ServerAddress:   cfg.Pyroscope.Endpoint,
 
-		BasicAuthUser:     os.Getenv("QUICKPIZZA_GRAFANA_CLOUD_USER"),
-		BasicAuthPassword: os.Getenv("QUICKPIZZA_GRAFANA_CLOUD_PASSWORD"),
+		BasicAuthUser:     cfg.Pyroscope.User,
+		BasicAuthPassword: cfg.Pyroscope.Password,
 
 		// make configurable?
 		ProfileTypes: []pyroscope.ProfileType{
@@ -264,119 +530,200 @@ func envPyroscopeConfig() (pyroscope.Config, bool) {
 		},
 
 		Tags: map[string]string{
-			svcNamespaceLabel:    svcNamespace,
-			"service_git_ref":    svcGitRef,
-			"service_repository": "https://github.com/grafana/quickpizza",
+			cfg.Pyroscope.NamespaceLabelName: cfg.Pyroscope.Namespace,
+			"service_git_ref":                cfg.Pyroscope.ServiceGitRef,
+			"service_repository":             "https://github.com/grafana/quickpizza",
 		},
 	}, true
 }
 
-func envServeAll() bool {
-	allSvcs, present := os.LookupEnv("QUICKPIZZA_ENABLE_ALL_SERVICES")
-	allSvcsB, _ := strconv.ParseBool(allSvcs)
-
-	// If QUICKPIZZA_ENABLE_ALL_SERVICES is not defined (default), serve everything.
-	if !present {
-		return true
+// passwordPolicy returns the policy for new passwords.
+func passwordPolicy(config config.Auth) password.Policy {
+	policy := password.Policy{MinLength: config.PasswordMinLength}
+	if err := policy.ParseRequirements(config.PasswordRequire); err != nil {
+		slog.Error("parsing QUICKPIZZA_PASSWORD_REQUIRE", "err", err)
+		os.Exit(1)
 	}
 
-	// Otherwise, serve all if QUICKPIZZA_ENABLE_ALL_SERVICES is truthy.
-	return allSvcsB
-}
-
-// envServe returns whether a service should be enabled.
-func envServe(name string) bool {
-	return envServeAll() || envBool(name)
+	slog.Debug("password policy", "policy", policy)
+	return policy
 }
 
-// envEndpoint returns the endpoint URL for a service.
-// If the service is enabled (envServe(svcEnv) == true), it returns "http://localhost:3333".
-// Otherwise, it returns the value of the endpointEnv environment variable.
-func envEndpoint(svcEnv, endpointEnv string) string {
-	if envServe(svcEnv) {
-		return "http://localhost:3333"
+// rateLimitConfig returns the rate limiter configuration, and whether rate limiting is enabled.
+func rateLimitConfig(config config.RateLimit) (qphttp.RateLimitConfig, bool) {
+	if config.Rate == "" {
+		return qphttp.RateLimitConfig{}, false
 	}
 
-	endpoint, _ := os.LookupEnv(endpointEnv)
-	return endpoint
-}
-
-// envBool returns true if an env var is set and has a truthy value.
-func envBool(name string) bool {
-	v, found := os.LookupEnv(name)
-	if !found {
-		return false
+	rate, err := ratelimit.ParseRate(config.Rate)
+	if err != nil {
+		slog.Error("parsing QUICKPIZZA_RATE_LIMIT", "err", err)
+		os.Exit(1)
 	}
 
-	b, err := strconv.ParseBool(v)
+	routes, err := qphttp.ParseRateLimitRoutes(config.Routes)
 	if err != nil {
-		return false
+		slog.Error("parsing QUICKPIZZA_RATE_LIMIT_ROUTES", "err", err)
+		os.Exit(1)
+	}
+
+	rateLimit := qphttp.RateLimitConfig{
+		Rate:   rate,
+		Key:    config.Key,
+		Routes: routes,
+	}
+	if err := rateLimit.Validate(); err != nil {
+		slog.Error("invalid rate limit configuration", "err", err)
+		os.Exit(1)
 	}
 
-	return b
+	slog.Info("enabling rate limiting", "rate", rate, "key", config.Key, "routes", len(routes))
+	return rateLimit, true
 }
 
-// envInt returns the 32-bit integer value for the specified env var, or 0 if it is not set or cannot be parsed.
-func envInt(name string) int {
-	v, found := os.LookupEnv(name)
-	if !found {
-		return 0
+// setupJWT returns the JWT signer and verifier, and whether JWT authentication is enabled.
+// Only instances running the Catalog service sign tokens. With RS256, other instances fetch the public key of the
+// Catalog service from its JWKS endpoint; with HS256, all of them need the same QUICKPIZZA_JWT_SECRET.
+func setupJWT(cfg *config.Config, httpCli *http.Client) (*jwt.Signer, *jwt.Verifier, bool) {
+	alg := cfg.JWT.Algorithm
+	if alg == "" {
+		return nil, nil, false
 	}
 
-	b, err := strconv.ParseInt(v, 10, 32)
-	if err != nil {
-		return 0
-	}
+	issuer := cfg.JWT.Issuer
+	signing := cfg.Services.Serve(cfg.Services.Catalog)
 
-	return int(b)
-}
+	var signer *jwt.Signer
+	var keys jwt.KeySource
+	var err error
 
-// envInt returns the time.Duration value for the specified env var, or 0 if it is not set or cannot be parsed.
-func envDuration(name string) time.Duration {
-	v, found := os.LookupEnv(name)
-	if !found {
-		return 0
+	switch alg {
+	case jwt.HS256:
+		secret := cfg.JWT.Secret
+		if secret == "" {
+			if !cfg.Services.All {
+				slog.Error("QUICKPIZZA_JWT_SECRET must be set for HS256 when running as separate services")
+				os.Exit(1)
+			}
+			slog.Warn("QUICKPIZZA_JWT_SECRET is not set, using a random secret")
+			secret = util.GenerateAlphaNumToken(48)
+		}
+
+		if signing {
+			signer, err = jwt.NewHS256Signer([]byte(secret), issuer)
+		}
+		keys = jwt.StaticKey{Key: []byte(secret)}
+
+	case jwt.RS256:
+		if signing {
+			var key *rsa.PrivateKey
+			if path := cfg.JWT.PrivateKey; path != "" {
+				var data []byte
+				data, err = os.ReadFile(path)
+				if err == nil {
+					key, err = jwt.ParseRSAPrivateKey(data)
+				}
+			} else {
+				slog.Warn("QUICKPIZZA_JWT_PRIVATE_KEY is not set, using a random key")
+				key, err = jwt.GenerateRSAKey()
+			}
+			if err == nil {
+				signer, err = jwt.NewRS256Signer(key, issuer)
+				keys = jwt.StaticKey{Key: &key.PublicKey}
+			}
+		} else {
+			jwksURL := cfg.JWT.JWKSURL
+			if jwksURL == "" {
+				jwksURL = cfg.Endpoint(cfg.Services.Catalog, cfg.Endpoints.Catalog) + "/.well-known/jwks.json"
+			}
+			keys = jwt.NewJWKSCache(jwksURL, httpCli, 10*time.Minute)
+		}
+
+	default:
+		slog.Error("unsupported QUICKPIZZA_JWT_ALGORITHM", "algorithm", alg)
+		os.Exit(1)
 	}
 
-	d, err := time.ParseDuration(v)
 	if err != nil {
-		return 0
+		slog.Error("setting up JWT signer", "err", err)
+		os.Exit(1)
 	}
 
-	return d
+	verifier, err := jwt.NewVerifier(alg, issuer, keys)
+	if err != nil {
+		slog.Error("setting up JWT verifier", "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("enabling JWT authentication", "algorithm", alg, "issuer", issuer, "signing", signing)
+	return signer, verifier, true
 }
 
-// envConfig reads environment variables matching prefix, and returns them as a map with the prefix stripped.
-// TODO: Convert variable names to camelCase in the returned map.
-func envConfig(prefix string) map[string]string {
-	config := make(map[string]string)
+// oidcConfig returns the configuration of the OpenID Connect provider. ID tokens are signed with the
+// RS256 key used for JWT authentication, if there is one, or with a key of their own otherwise.
+func oidcConfig(cfg *config.Config, jwtSigner *jwt.Signer) qphttp.OIDCConfig {
+	config := qphttp.OIDCConfig{
+		Issuer:  cfg.OIDC.Issuer,
+		Signer:  jwtSigner,
+		Clients: qphttp.DefaultOIDCClients(),
+	}
 
-	for _, envVar := range os.Environ() {
-		// os.Environ returns vars in key=value form.
-		nameValue := strings.Split(envVar, "=")
-		if len(nameValue) < 2 {
-			continue // This should be impossible as per the contract of os.Environ.
+	if clients := cfg.OIDC.Clients; clients != "" {
+		config.Clients = nil
+		if err := json.Unmarshal([]byte(clients), &config.Clients); err != nil {
+			slog.Error("parsing QUICKPIZZA_OIDC_CLIENTS", "err", err)
+			os.Exit(1)
 		}
-
-		name := nameValue[0]
-		value := nameValue[1]
-
-		if !strings.HasPrefix(name, prefix) {
-			continue
+	} else {
+		// The secret of the default confidential client is generated on start, so it is only known from this log.
+		for _, client := range config.Clients {
+			if client.Secret != "" {
+				slog.Info("generated OpenID Connect client secret", "clientID", client.ID, "secret", client.Secret)
+			}
 		}
+	}
 
-		name = strings.ToLower(strings.TrimPrefix(name, prefix))
-		config[strings.ToLower(name)] = value
+	if jwtSigner == nil || jwtSigner.Algorithm() != jwt.RS256 {
+		var key *rsa.PrivateKey
+		var err error
+		if path := cfg.JWT.PrivateKey; path != "" {
+			var data []byte
+			data, err = os.ReadFile(path)
+			if err == nil {
+				key, err = jwt.ParseRSAPrivateKey(data)
+			}
+		} else {
+			key, err = jwt.GenerateRSAKey()
+		}
+		if err == nil {
+			config.Signer, err = jwt.NewRS256Signer(key, "")
+		}
+		if err != nil {
+			slog.Error("setting up OpenID Connect signer", "err", err)
+			os.Exit(1)
+		}
 	}
 
+	slog.Info("enabling OpenID Connect provider", "issuer", config.Issuer, "clients", len(config.Clients))
 	return config
 }
 
-// envDBConnString returns the specified db connection string from QUICKPIZZA_DB. It defaults to an in-memory sqlite instance
-func envDBConnString() string {
-	v, found := os.LookupEnv("QUICKPIZZA_DB")
-	if !found || v == "" {
-		return "file::memory:?cache=shared"
+// addEndpointCheck makes /ready check that a service can be reached at endpoint, unless it runs in this instance.
+func addEndpointCheck(cfg *config.Config, server *qphttp.Server, name string, enabled bool, endpoint string) {
+	if cfg.Services.Serve(enabled) {
+		return
 	}
-	return v
+	server.AddReadinessCheck(name, qphttp.EndpointCheck(endpoint))
+}
+
+// grpcTLSConfig returns the TLS configuration of the gRPC server, and whether TLS is enabled at all.
+func grpcTLSConfig(cfg config.GRPC) (qpgrpc.TLSConfig, bool) {
+	config := qpgrpc.TLSConfig{
+		CertFile:     cfg.TLSCertFile,
+		KeyFile:      cfg.TLSKeyFile,
+		SelfSigned:   cfg.TLSSelfSigned,
+		ClientCAFile: cfg.TLSClientCAFile,
+	}
+
+	return config, config.CertFile != "" || config.KeyFile != "" || config.SelfSigned || config.ClientCAFile != ""
 }
@@ -37,6 +37,10 @@ locals {
       name  = "QUICKPIZZA_ENABLE_ALL_SERVICES"
       value = 0
     },
+    {
+      name  = "QUICKPIZZA_INTERNAL_TOKEN"
+      value = var.quickpizza_internal_token
+    },
     {
       name  = "QUICKPIZZA_OTLP_ENDPOINT"
       value = "http://alloy:4318"
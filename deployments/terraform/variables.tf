@@ -74,6 +74,14 @@ variable "quickpizza_image_pull_policy" {
 }
 
 
+variable "quickpizza_internal_token" {
+  default     = "quickpizza-internal-token"
+  description = "The token that authenticates the requests the QuickPizza services make to each other. Change it in any deployment other than a demo."
+  nullable    = false
+  sensitive   = true
+  type        = string
+}
+
 variable "quickpizza_log_level" {
   default     = "info"
   description = "The Log Level to use for the QuickPizza Demo Application, for example \"info\" or \"debug\"."
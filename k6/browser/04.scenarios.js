@@ -2,6 +2,8 @@ import { browser } from "k6/browser";
 import { check } from 'https://jslib.k6.io/k6-utils/1.5.0/index.js';
 
 const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+// The password of the admin user is logged when QuickPizza starts, or is "admin" with QUICKPIZZA_DEV_STAFF_PASSWORDS=1.
+const ADMIN_PASSWORD = __ENV.ADMIN_PASSWORD || "admin";
 
 export const options = {
   scenarios: {
@@ -36,6 +38,8 @@ export async function admin() {
 
   try {
     await page.goto(`${BASE_URL}/admin`, { waitUntil: "networkidle" });
+    await page.locator("#username").fill("admin");
+    await page.locator("#password").fill(ADMIN_PASSWORD);
     await page.getByRole("button", { name: "Sign in" }).click();
     checkData = await page.getByRole("button", { name: "Logout" }).textContent();
     check(checkData, {
@@ -9,6 +9,8 @@ import { RecommendationsPage } from "./pages/recommendations-page.js";
 import { PageUtils } from "./pages/page-utils.js";
 
 const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+// The password of the admin user is logged when QuickPizza starts, or is "admin" with QUICKPIZZA_DEV_STAFF_PASSWORDS=1.
+const ADMIN_PASSWORD = __ENV.ADMIN_PASSWORD || "admin";
 
 export const options = {
   scenarios: {
@@ -86,7 +88,7 @@ export async function admin() {
   try {
     const loginPage = new LoginPage(page);
     await loginPage.goto(BASE_URL);
-    await loginPage.login();
+    await loginPage.login("admin", ADMIN_PASSWORD);
 
     check(loginPage, {
       "logout button text": await loginPage.getLogoutButtonText() == "Logout",
@@ -0,0 +1,83 @@
+import { browser } from "k6/browser";
+import crypto from "k6/crypto";
+import encoding from "k6/encoding";
+import http from "k6/http";
+import { check } from 'https://jslib.k6.io/k6-utils/1.5.0/index.js';
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+const CLIENT_ID = "quickpizza-demo";
+const REDIRECT_URI = `${BASE_URL}/oauth2/callback`;
+
+export const options = {
+  scenarios: {
+    ui: {
+      executor: "shared-iterations",
+      options: {
+        browser: {
+          type: "chromium",
+        },
+      },
+    },
+  },
+};
+
+export default async function () {
+  // PKCE: the code verifier stays in the script, only its hash is sent to the authorization endpoint.
+  const codeVerifier = encoding.b64encode(crypto.randomBytes(32), "rawurl");
+  const codeChallenge = crypto.sha256(codeVerifier, "base64rawurl");
+  const state = encoding.b64encode(crypto.randomBytes(8), "rawurl");
+
+  const params = [
+    "response_type=code",
+    `client_id=${CLIENT_ID}`,
+    `redirect_uri=${encodeURIComponent(REDIRECT_URI)}`,
+    "scope=openid%20profile",
+    `state=${state}`,
+    `code_challenge=${codeChallenge}`,
+    "code_challenge_method=S256",
+  ].join("&");
+
+  const page = await browser.newPage();
+
+  try {
+    await page.goto(`${BASE_URL}/oauth2/authorize?${params}`);
+
+    await page.locator("#username").type("default");
+    await page.locator("#password").type("12345678");
+    await Promise.all([
+      page.waitForNavigation(),
+      page.locator("#sign-in").click(),
+    ]);
+
+    await check(page.locator("#result"), {
+      "authorization code received": async (lo) => (await lo.textContent()) === "Authorization code received",
+    });
+    await check(page.locator("#state"), {
+      "state is preserved": async (lo) => (await lo.textContent()) === state,
+    });
+
+    const code = await page.locator("#code").textContent();
+
+    // Exchange the code for tokens, as the backend of a client would.
+    const res = http.post(`${BASE_URL}/oauth2/token`, {
+      grant_type: "authorization_code",
+      client_id: CLIENT_ID,
+      redirect_uri: REDIRECT_URI,
+      code: code,
+      code_verifier: codeVerifier,
+    });
+    check(res, {
+      "token exchange succeeded": (r) => r.status === 200,
+      "id token received": (r) => r.json("id_token") !== undefined,
+    });
+
+    const ratings = http.get(`${BASE_URL}/api/ratings`, {
+      headers: { Authorization: `Bearer ${res.json("access_token")}` },
+    });
+    check(ratings, {
+      "access token accepted": (r) => r.status === 200,
+    });
+  } finally {
+    await page.close();
+  }
+}
@@ -1,6 +1,8 @@
 export class LoginPage {
   constructor(page) {
     this.page = page
+    this.usernameInput = page.locator('#username');
+    this.passwordInput = page.locator('#password');
     this.submitButton = page.getByRole('button', { name: "Sign in" });
     this.logoutButton = page.getByRole('button', { name: "Logout" });
   }
@@ -9,7 +11,9 @@ export class LoginPage {
     await this.page.goto(`${baseURL}/admin`, { waitUntil: "networkidle" });
   }
 
-  async login() {
+  async login(username, password) {
+    await this.usernameInput.fill(username);
+    await this.passwordInput.fill(password);
     await this.submitButton.click();
   }
 
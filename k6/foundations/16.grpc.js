@@ -20,6 +20,21 @@ export default () => {
 
   console.log(JSON.stringify(response.message));
 
+  // The gRPC API mirrors the REST API: recommend a pizza, then rate it as the default user.
+  const recommendation = client.invoke('quickpizza.GRPC/RecommendPizza', { maxNumberOfToppings: 5, minNumberOfToppings: 2 });
+  check(recommendation, {
+    'recommendation status is OK': (r) => r && r.status === StatusOK,
+  });
+
+  if (recommendation.status === StatusOK) {
+    const rating = client.invoke('quickpizza.GRPC/CreateRating', { pizzaId: recommendation.message.pizza.id, stars: 5 }, {
+      metadata: { authorization: 'Token abcdef0123456789' },
+    });
+    check(rating, {
+      'rating status is OK': (r) => r && r.status === StatusOK,
+    });
+  }
+
   client.close();
   sleep(1);
 };
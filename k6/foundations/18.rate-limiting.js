@@ -0,0 +1,56 @@
+// This example shows how to handle server-side throttling. Start QuickPizza with rate limiting enabled, e.g.:
+//   QUICKPIZZA_RATE_LIMIT=5/s:10 QUICKPIZZA_RATE_LIMIT_ROUTES="POST /api/pizza=1/s:3"
+// Without rate limiting, all requests are expected to succeed.
+import http from "k6/http";
+import { check, sleep } from "k6";
+import { Counter } from "k6/metrics";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+
+const throttled = new Counter("quickpizza_throttled_requests");
+
+export const options = {
+  vus: 3,
+  duration: "5s",
+  thresholds: {
+    // Being throttled is fine, as long as it does not happen too often.
+    quickpizza_throttled_requests: ["count<50"],
+    // 429 responses are expected, so they are not counted as failures.
+    http_req_failed: ["rate<0.01"],
+  },
+};
+
+// Consider 429 responses as expected, so that they do not count towards http_req_failed.
+http.setResponseCallback(http.expectedStatuses(200, 429));
+
+export default function () {
+  const res = http.post(
+    `${BASE_URL}/api/pizza`,
+    JSON.stringify({ maxCaloriesPerSlice: 1000 }),
+    {
+      headers: {
+        "Content-Type": "application/json",
+        Authorization: "token abcdef0123456789",
+      },
+    }
+  );
+
+  check(res, {
+    "status is 200 or 429": (r) => r.status === 200 || r.status === 429,
+    "429 responses have Retry-After": (r) =>
+      r.status !== 429 || r.headers["Retry-After"] !== undefined,
+  });
+
+  if (res.status === 429) {
+    throttled.add(1);
+    // Back off for as long as the server asks us to, but never longer than the test itself.
+    const retryAfter = parseInt(res.headers["Retry-After"] || "1", 10);
+    sleep(Math.min(retryAfter, 5));
+    return;
+  }
+
+  console.log(
+    `${res.json().pizza.name} (${res.headers["Ratelimit-Remaining"] ?? "unlimited"} requests left)`
+  );
+  sleep(0.2);
+}
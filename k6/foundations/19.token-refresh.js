@@ -0,0 +1,59 @@
+// This example shows how to keep a session alive with refresh tokens. Start QuickPizza with short-lived tokens to
+// see them being refreshed, e.g.: QUICKPIZZA_TOKEN_TTL=3s
+import http from "k6/http";
+import { check, sleep } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+
+export const options = {
+  vus: 5,
+  duration: "10s",
+};
+
+// Every VU has its own session, kept across iterations.
+let session;
+
+function login() {
+  const res = http.post(
+    `${BASE_URL}/api/users/token/login`,
+    JSON.stringify({ username: "default", password: "12345678" }),
+    { headers: { "Content-Type": "application/json" } }
+  );
+  check(res, { "login status is 200": (r) => r.status === 200 });
+  return toSession(res);
+}
+
+function refresh() {
+  const res = http.post(
+    `${BASE_URL}/api/users/token/refresh`,
+    JSON.stringify({ refresh_token: session.refreshToken }),
+    { headers: { "Content-Type": "application/json" } }
+  );
+  check(res, { "refresh status is 200": (r) => r.status === 200 });
+  // If the refresh token expired too, start over.
+  return res.status === 200 ? toSession(res) : login();
+}
+
+function toSession(res) {
+  const body = res.json();
+  return {
+    token: body.token,
+    refreshToken: body.refresh_token,
+    // Refresh a bit before the token actually expires.
+    refreshAt: Date.now() + (body.expires_in - 1) * 1000,
+  };
+}
+
+export default function () {
+  if (!session) {
+    session = login();
+  } else if (Date.now() >= session.refreshAt) {
+    session = refresh();
+  }
+
+  const res = http.get(`${BASE_URL}/api/ratings`, {
+    headers: { Authorization: `token ${session.token}` },
+  });
+  check(res, { "ratings status is 200": (r) => r.status === 200 });
+  sleep(0.5);
+}
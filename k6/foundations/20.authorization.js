@@ -0,0 +1,62 @@
+// This example exercises role-based access control: the latest recommendations can only be seen by staff, so
+// requests without a valid token fail with 401, and requests from regular users fail with 403.
+import http from "k6/http";
+import { check } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+// The passwords of the staff users are logged when QuickPizza starts, or are their usernames with
+// QUICKPIZZA_DEV_STAFF_PASSWORDS=1.
+const ADMIN_PASSWORD = __ENV.ADMIN_PASSWORD || "admin";
+const KITCHEN_PASSWORD = __ENV.KITCHEN_PASSWORD || "kitchen";
+
+export const options = {
+  vus: 1,
+  iterations: 5,
+};
+
+// Authorization failures are expected in this test.
+http.setResponseCallback(http.expectedStatuses(200, 401, 403));
+
+function adminLogin(user, password) {
+  return http.post(`${BASE_URL}/api/admin/login?user=${user}&password=${password}`);
+}
+
+function recommendations(token) {
+  const jar = http.cookieJar();
+  jar.clear(BASE_URL);
+  return http.get(`${BASE_URL}/api/internal/recommendations`, {
+    headers: token ? { Authorization: `token ${token}` } : {},
+  });
+}
+
+export default function () {
+  check(adminLogin("admin", ADMIN_PASSWORD), {
+    "admin can log in": (r) => r.status === 200,
+  });
+  check(adminLogin("admin", "wrong-password"), {
+    "wrong password is rejected with 401": (r) => r.status === 401,
+  });
+  check(adminLogin("default", "12345678"), {
+    "regular user is rejected with 403": (r) => r.status === 403,
+  });
+
+  // Kitchen staff can see the latest recommendations with their own token.
+  const login = http.post(
+    `${BASE_URL}/api/users/token/login`,
+    JSON.stringify({ username: "kitchen", password: KITCHEN_PASSWORD }),
+    { headers: { "Content-Type": "application/json" } }
+  );
+  check(recommendations(login.json("token")), {
+    "kitchen staff is allowed": (r) => r.status === 200,
+  });
+
+  check(recommendations(null), {
+    "missing token is rejected with 401": (r) => r.status === 401,
+  });
+  check(recommendations("abcdef0123456789"), {
+    "regular user token is rejected with 403": (r) => r.status === 403,
+  });
+  check(recommendations("0000000000000000"), {
+    "unknown token is rejected with 401": (r) => r.status === 401,
+  });
+}
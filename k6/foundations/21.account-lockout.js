@@ -0,0 +1,45 @@
+// This example simulates a brute-force attack against a single account: after QUICKPIZZA_LOGIN_MAX_FAILURES (5 by
+// default) wrong passwords, logins for the account are locked out with 429, even with the right password. Run it
+// with more VUs and random usernames to see per-IP lockouts (QUICKPIZZA_LOGIN_MAX_IP_FAILURES) kick in.
+import http from "k6/http";
+import { check } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+const MAX_FAILURES = parseInt(__ENV.MAX_FAILURES || "5");
+
+export const options = {
+  vus: 1,
+  iterations: 1,
+};
+
+// Failed and rejected logins are expected in this test.
+http.setResponseCallback(http.expectedStatuses(200, 201, 401, 429));
+
+function login(username, password) {
+  return http.post(
+    `${BASE_URL}/api/users/token/login`,
+    JSON.stringify({ username, password }),
+    { headers: { "Content-Type": "application/json" } }
+  );
+}
+
+export default function () {
+  const username = `victim-${Date.now()}`;
+  const password = "correct-horse-battery";
+
+  const res = http.post(`${BASE_URL}/api/users`, JSON.stringify({ username, password }), {
+    headers: { "Content-Type": "application/json" },
+  });
+  check(res, { "user created": (r) => r.status === 201 });
+
+  for (let i = 0; i < MAX_FAILURES; i++) {
+    check(login(username, `guess-${i}`), {
+      "wrong password is rejected with 401": (r) => r.status === 401,
+    });
+  }
+
+  check(login(username, password), {
+    "account is locked out with 429": (r) => r.status === 429,
+    "Retry-After is set": (r) => parseInt(r.headers["Retry-After"]) > 0,
+  });
+}
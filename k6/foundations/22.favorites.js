@@ -0,0 +1,57 @@
+// This example is a write-heavy personal data flow: every VU signs up, and then keeps getting recommendations,
+// saving some of them as favorites, paging through its favorites and removing the oldest ones. Increase the duration
+// to turn it into a soak test.
+import http from "k6/http";
+import { check, sleep } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+
+export const options = {
+  vus: 5,
+  duration: "10s",
+};
+
+let token;
+
+function params() {
+  return {
+    headers: {
+      "Content-Type": "application/json",
+      Authorization: `token ${token}`,
+    },
+  };
+}
+
+function signUp() {
+  const username = `fav-${__VU}-${Date.now()}`;
+  const password = "pizza-lover-1234";
+  http.post(`${BASE_URL}/api/users`, JSON.stringify({ username, password }), params());
+  const res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({ username, password }), params());
+  check(res, { "login status is 200": (r) => r.status === 200 });
+  return res.json("token");
+}
+
+export default function () {
+  if (!token) {
+    token = signUp();
+  }
+
+  const pizza = http.post(`${BASE_URL}/api/pizza`, JSON.stringify({}), params());
+  check(pizza, { "pizza status is 200": (r) => r.status === 200 });
+
+  const res = http.post(`${BASE_URL}/api/favorites/${pizza.json("pizza.id")}`, null, params());
+  check(res, { "favorite saved": (r) => r.status === 201 });
+
+  const page = http.get(`${BASE_URL}/api/favorites?per_page=10`, params());
+  check(page, { "favorites listed": (r) => r.status === 200 });
+
+  // Keep at most 10 favorites per user.
+  const total = page.json("total");
+  if (total > 10) {
+    const last = http.get(`${BASE_URL}/api/favorites?per_page=1&page=${total}`, params());
+    const del = http.del(`${BASE_URL}/api/favorites/${last.json("favorites.0.pizza_id")}`, null, params());
+    check(del, { "oldest favorite removed": (r) => r.status === 204 });
+  }
+
+  sleep(0.5);
+}
@@ -0,0 +1,72 @@
+// This example shows how to retry POST requests safely: every logical request gets its own Idempotency-Key, which is
+// sent again on every retry. If the first attempt went through, but its response was lost, QuickPizza replays the
+// original response instead of creating a duplicate. Start QuickPizza with QUICKPIZZA_FAIL_RATE_RECOMMENDATIONS_API_PIZZA_POST
+// to see retries of failed requests.
+import http from "k6/http";
+import { check, sleep } from "k6";
+import { uuidv4 } from "https://jslib.k6.io/k6-utils/1.5.0/index.js";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+const MAX_ATTEMPTS = 3;
+
+export const options = {
+  vus: 1,
+  iterations: 5,
+};
+
+// Failed attempts are retried, and reusing a key is part of the example, so these are expected.
+http.setResponseCallback(http.expectedStatuses({ min: 200, max: 399 }, 409, 422, 503));
+
+function postWithRetries(url, body) {
+  const params = {
+    headers: {
+      "Content-Type": "application/json",
+      Authorization: "token abcdef0123456789",
+      "Idempotency-Key": uuidv4(),
+    },
+  };
+
+  let res;
+  for (let attempt = 1; attempt <= MAX_ATTEMPTS; attempt++) {
+    res = http.post(url, body, params);
+    // 409 means that a previous attempt is still being processed.
+    if (res.status < 500 && res.status !== 409) {
+      break;
+    }
+    sleep(attempt);
+  }
+  return res;
+}
+
+export default function () {
+  const body = JSON.stringify({ maxCaloriesPerSlice: 1000 });
+
+  const pizza = postWithRetries(`${BASE_URL}/api/pizza`, body);
+  check(pizza, { "pizza status is 200": (r) => r.status === 200 });
+  if (pizza.status !== 200) {
+    return;
+  }
+
+  // Simulate a lost response by sending the same request, with the same key, twice.
+  const key = uuidv4();
+  const params = {
+    headers: {
+      "Content-Type": "application/json",
+      Authorization: "token abcdef0123456789",
+      "Idempotency-Key": key,
+    },
+  };
+  const rating = JSON.stringify({ pizza_id: pizza.json("pizza.id"), stars: 5 });
+  const first = http.post(`${BASE_URL}/api/ratings`, rating, params);
+  const retry = http.post(`${BASE_URL}/api/ratings`, rating, params);
+  check(retry, {
+    "retry is replayed": (r) => r.headers["Idempotent-Replayed"] === "true",
+    "no duplicate rating": (r) => r.json("id") === first.json("id"),
+  });
+
+  // Reusing a key for a different request is an error.
+  const other = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({ pizza_id: 1, stars: 1 }), params);
+  check(other, { "key reuse is rejected": (r) => r.status === 422 });
+
+  sleep(1);
+}
@@ -0,0 +1,78 @@
+// This example exercises the streaming RPCs of the QuickPizza gRPC API: it watches a few recommendations as the server
+// streams them, rates those pizzas in a single client stream, and chats with the kitchen over a bidirectional stream.
+import { Client, Stream } from "k6/net/grpc";
+import { check, sleep } from "k6";
+
+const BASE_URL = __ENV.BASE_GRPC_URL || "localhost:3334";
+const TOKEN = "abcdef0123456789";
+
+const client = new Client();
+client.load(["definitions"], "../../../proto/quickpizza.proto");
+
+export const options = {
+  vus: 1,
+  iterations: 3,
+};
+
+export default () => {
+  client.connect(BASE_URL, { plaintext: true });
+
+  const pizzaIDs = [];
+  const watch = new Stream(client, "quickpizza.GRPC/WatchRecommendations", { timeout: "10s" });
+  watch.on("data", (recommendation) => {
+    pizzaIDs.push(recommendation.pizza.id);
+  });
+  watch.on("error", (err) => {
+    console.error(`WatchRecommendations failed: ${err.message}`);
+  });
+  watch.on("end", () => {
+    check(pizzaIDs, { "received 3 recommendations": (ids) => ids.length === 3 });
+    ratePizzas(pizzaIDs);
+  });
+  watch.write({ restrictions: { maxNumberOfToppings: 5 }, count: 3, intervalMs: 200 });
+  watch.end();
+};
+
+function ratePizzas(pizzaIDs) {
+  const bulk = new Stream(client, "quickpizza.GRPC/BulkRatePizzas", {
+    metadata: { authorization: `Token ${TOKEN}` },
+    timeout: "10s",
+  });
+  bulk.on("data", (summary) => {
+    check(summary, { "all ratings created": (s) => s.created === pizzaIDs.length });
+  });
+  bulk.on("error", (err) => {
+    console.error(`BulkRatePizzas failed: ${err.message}`);
+  });
+  bulk.on("end", chat);
+  for (const id of pizzaIDs) {
+    bulk.write({ pizzaId: id, stars: Math.floor(Math.random() * 5) + 1 });
+  }
+  bulk.end();
+}
+
+function chat() {
+  const messages = ["Hi there!", "How long until my pizza is ready?", "Thanks!"];
+  let replies = 0;
+
+  const stream = new Stream(client, "quickpizza.GRPC/KitchenChat", { timeout: "10s" });
+  stream.on("data", (message) => {
+    replies++;
+    console.log(`${message.sender}: ${message.text}`);
+    // Wait for each reply before sending the next message, as in a real conversation.
+    if (replies < messages.length) {
+      stream.write({ text: messages[replies] });
+    } else {
+      stream.end();
+    }
+  });
+  stream.on("error", (err) => {
+    console.error(`KitchenChat failed: ${err.message}`);
+  });
+  stream.on("end", () => {
+    check(replies, { "kitchen replied to every message": (n) => n === messages.length });
+    client.close();
+    sleep(1);
+  });
+  stream.write({ text: messages[0] });
+}
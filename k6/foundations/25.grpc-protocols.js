@@ -0,0 +1,83 @@
+// This example calls the same RPC of the QuickPizza gRPC API over three wire protocols: native gRPC on the gRPC port,
+// and the Connect protocol and gRPC-Web on the HTTP port, with JSON messages. The durations of the calls are recorded
+// in the rpc_duration metric, tagged with the protocol, to compare their overhead in the end-of-test summary.
+import http from "k6/http";
+import { Client, StatusOK } from "k6/net/grpc";
+import { Trend } from "k6/metrics";
+import { check, sleep } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+const BASE_GRPC_URL = __ENV.BASE_GRPC_URL || "localhost:3334";
+const RPC = "quickpizza.GRPC/RecommendPizza";
+const REQUEST = { maxNumberOfToppings: 5, minNumberOfToppings: 2 };
+
+const client = new Client();
+client.load(["definitions"], "../../../proto/quickpizza.proto");
+
+const rpcDuration = new Trend("rpc_duration", true);
+
+export const options = {
+  vus: 1,
+  iterations: 10,
+  thresholds: {
+    // Sub-metrics per protocol, so that the summary lists them side by side.
+    "rpc_duration{protocol:grpc}": [],
+    "rpc_duration{protocol:connect}": [],
+    "rpc_duration{protocol:grpc-web}": [],
+  },
+};
+
+export default () => {
+  client.connect(BASE_GRPC_URL, { plaintext: true });
+
+  const start = Date.now();
+  const grpcRes = client.invoke(RPC, REQUEST);
+  rpcDuration.add(Date.now() - start, { protocol: "grpc" });
+  check(grpcRes, { "gRPC status is OK": (r) => r && r.status === StatusOK });
+  client.close();
+
+  // Connect unary calls are plain POST requests, with the message as body and the RPC as path.
+  const connectRes = http.post(`${BASE_URL}/${RPC}`, JSON.stringify(REQUEST), {
+    headers: { "Content-Type": "application/json", "Connect-Protocol-Version": "1" },
+  });
+  rpcDuration.add(connectRes.timings.duration, { protocol: "connect" });
+  check(connectRes, { "Connect status is 200": (r) => r.status === 200 });
+
+  // gRPC-Web messages are prefixed with a flags byte and their length, and the status comes after them, in a trailers
+  // message.
+  const webRes = http.post(`${BASE_URL}/${RPC}`, frame(JSON.stringify(REQUEST)), {
+    headers: { "Content-Type": "application/grpc-web+json", "X-Grpc-Web": "1" },
+    responseType: "binary",
+  });
+  rpcDuration.add(webRes.timings.duration, { protocol: "grpc-web" });
+  check(webRes, { "gRPC-Web status is OK": (r) => r.status === 200 && grpcWebStatus(r.body) === "0" });
+
+  sleep(1);
+};
+
+// frame prefixes a message for gRPC-Web.
+function frame(message) {
+  const bytes = new Uint8Array(5 + message.length);
+  new DataView(bytes.buffer).setUint32(1, message.length);
+  for (let i = 0; i < message.length; i++) {
+    bytes[5 + i] = message.charCodeAt(i);
+  }
+  return bytes.buffer;
+}
+
+// grpcWebStatus returns the grpc-status of the trailers message of a gRPC-Web response.
+function grpcWebStatus(body) {
+  const bytes = new Uint8Array(body);
+  const view = new DataView(body);
+  let offset = 0;
+  while (offset + 5 <= bytes.length) {
+    const length = view.getUint32(offset + 1);
+    if (bytes[offset] & 0x80) {
+      const trailers = String.fromCharCode(...bytes.subarray(offset + 5, offset + 5 + length));
+      const match = trailers.match(/grpc-status: (\d+)/);
+      return match && match[1];
+    }
+    offset += 5 + length;
+  }
+  return null;
+}
@@ -0,0 +1,126 @@
+// This example uses the QuickPizza GraphQL API: the "client" scenario recommends pizzas, rates them and fetches the
+// ratings of the user, with the pizza of every rating, in a single query. Meanwhile, the "subscriber" scenario
+// subscribes to new recommendations over WebSocket, with the graphql-transport-ws protocol, until it received one per
+// client iteration.
+//
+// Run it with QUICKPIZZA_GRAPHQL_DISABLE_DATALOADERS=true set on the server to see the N+1 queries of the ratings
+// query in graphql_ratings_duration.
+import http from "k6/http";
+import { WebSocket } from "k6/websockets";
+import { Trend } from "k6/metrics";
+import { check, sleep } from "k6";
+
+const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+const WS_URL = BASE_URL.replace("https://", "wss://").replace("http://", "ws://");
+const CLIENT_ITERATIONS = 10;
+const TOKEN = __ENV.TOKEN || "abcdef0123456789";
+
+const ratingsDuration = new Trend("graphql_ratings_duration", true);
+
+export const options = {
+  scenarios: {
+    subscriber: {
+      exec: "subscriber",
+      executor: "shared-iterations",
+      iterations: 1,
+      maxDuration: "30s",
+    },
+    client: {
+      exec: "client",
+      executor: "shared-iterations",
+      iterations: CLIENT_ITERATIONS,
+      vus: 2,
+      // Delay the start to get the subscriber ready.
+      startTime: "2s",
+    },
+  },
+};
+
+// graphql sends a query or mutation, and returns the response. GraphQL responses have status 200 even if
+// the query failed, so errors have to be checked in the body.
+function graphql(query, variables, token) {
+  const res = http.post(`${BASE_URL}/graphql`, JSON.stringify({ query, variables }), {
+    headers: {
+      "Content-Type": "application/json",
+      Authorization: `Token ${token}`,
+    },
+  });
+  check(res, {
+    "status is 200": (r) => r.status === 200,
+    "no errors": (r) => !r.json("errors"),
+  });
+  return res;
+}
+
+export function client() {
+  const recommend = graphql(
+    `mutation Recommend($restrictions: RestrictionsInput) {
+      recommend(restrictions: $restrictions) { id name }
+    }`,
+    { restrictions: { maxNumberOfToppings: 5, minNumberOfToppings: 2 } },
+    TOKEN,
+  );
+  const pizza = recommend.json("data.recommend");
+  if (!pizza) {
+    return;
+  }
+  console.log(`VU ${__VU} got ${pizza.name}`);
+
+  graphql(
+    `mutation Rate($input: RatingInput!) {
+      rate(input: $input) { id stars }
+    }`,
+    { input: { pizzaId: pizza.id, stars: 5 } },
+    TOKEN,
+  );
+
+  // The pizza of every rating is loaded in a single database query, thanks to the DataLoader.
+  const ratings = graphql(
+    `query {
+      ratings {
+        stars
+        pizza { name dough { name } ingredients { name } }
+      }
+    }`,
+    {},
+    TOKEN,
+  );
+  ratingsDuration.add(ratings.timings.duration);
+
+  sleep(1);
+}
+
+export function subscriber() {
+  let received = 0;
+
+  const ws = new WebSocket(`${WS_URL}/graphql`, ["graphql-transport-ws"]);
+  ws.addEventListener("open", () => {
+    ws.send(JSON.stringify({ type: "connection_init", payload: { Authorization: `Token ${TOKEN}` } }));
+  });
+
+  ws.addEventListener("message", (e) => {
+    const msg = JSON.parse(e.data);
+    switch (msg.type) {
+      case "connection_ack":
+        ws.send(JSON.stringify({
+          id: "1",
+          type: "subscribe",
+          payload: { query: "subscription { newRecommendation { id name } }" },
+        }));
+        break;
+      case "next":
+        console.log(`Subscriber received: ${msg.payload.data.newRecommendation.name}`);
+        received++;
+        // Close the connection to end the iteration once every recommendation of the client was received.
+        if (received === CLIENT_ITERATIONS) {
+          ws.send(JSON.stringify({ id: "1", type: "complete" }));
+          ws.close();
+        }
+        break;
+      case "error":
+        console.error(`Subscription failed: ${JSON.stringify(msg.payload)}`);
+        ws.close();
+        break;
+    }
+  });
+}
@@ -6,6 +6,8 @@ import chai, {
 chai.config.exitOnError = true;
 
 const BASE_URL = __ENV.BASE_URL || "http://localhost:3333";
+// The password of the admin user is logged when QuickPizza starts, or is "admin" with QUICKPIZZA_DEV_STAFF_PASSWORDS=1.
+const ADMIN_PASSWORD = __ENV.ADMIN_PASSWORD || "admin";
 
 export const options = {
   vus: 1,
@@ -102,6 +104,169 @@ function testCreateUserLogin() {
   });
 }
 
+function testUserProfile() {
+  const username = randomString(32);
+  const password = randomString(32);
+  const params = (token) => ({
+    headers: {
+      'Content-Type': 'application/json',
+      'Authorization': `token ${token}`,
+    },
+  });
+
+  describe("Manage the profile of a user", () => {
+    var res = http.post(`${BASE_URL}/api/users`, JSON.stringify({username: username, password: password}), params(""));
+    expect(res.status, "response status").to.equal(201);
+
+    res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({username: username, password: password}), params(""));
+    expect(res.status, "response status").to.equal(200);
+    let token = res.json().token;
+
+    res = http.get(`${BASE_URL}/api/users/me`, params(token));
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().username, "username").to.equal(username);
+
+    const newUsername = randomString(32);
+    res = http.patch(`${BASE_URL}/api/users/me`, JSON.stringify({username: newUsername}), params(token));
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().username, "username").to.equal(newUsername);
+
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 4, pizza_id: 1}), params(token));
+    expect(res.status, "response status").to.equal(201);
+
+    // Changing the password revokes the current session and starts a new one.
+    const newPassword = randomString(32);
+    res = http.post(`${BASE_URL}/api/users/me/password`, JSON.stringify({
+      current_password: password,
+      new_password: newPassword,
+    }), params(token));
+    expect(res.status, "response status").to.equal(200);
+    const oldToken = token;
+    token = res.json().token;
+
+    res = http.get(`${BASE_URL}/api/users/me`, params(oldToken));
+    expect(res.status, "response status").to.equal(401);
+
+    res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({username: newUsername, password: newPassword}), params(""));
+    expect(res.status, "response status").to.equal(200);
+
+    res = http.del(`${BASE_URL}/api/users/me`, null, params(token));
+    expect(res.status, "response status").to.equal(204);
+
+    res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({username: newUsername, password: newPassword}), params(""));
+    expect(res.status, "response status").to.equal(401);
+  });
+
+  describe("Fail to delete the default user", () => {
+    var res = http.del(`${BASE_URL}/api/users/me`, null, params("abcdef0123456789"));
+    expect(res.status, "response status").to.equal(403);
+  });
+}
+
+function testRatingReviews() {
+  const username = randomString(32);
+  const password = randomString(32);
+  const params = (token) => ({
+    headers: {
+      'Content-Type': 'application/json',
+      'Authorization': `token ${token}`,
+    },
+  });
+
+  describe("Rate pizzas with reviews", () => {
+    var res = http.post(`${BASE_URL}/api/users`, JSON.stringify({username: username, password: password}), params(""));
+    expect(res.status, "response status").to.equal(201);
+
+    res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({username: username, password: password}), params(""));
+    expect(res.status, "response status").to.equal(200);
+    const token = res.json().token;
+
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 5, pizza_id: 1, review: "Perfectly crispy."}), params(token));
+    expect(res.status, "response status").to.equal(201);
+    expect(res.json().status, "rating status").to.equal("approved");
+    const ratingId = res.json().id;
+
+    // Users can rate every pizza only once.
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 1, pizza_id: 1}), params(token));
+    expect(res.status, "response status").to.equal(409);
+    expect(res.headers["Location"], "location").to.equal(`/api/ratings/${ratingId}`);
+    expect(res.json().code, "problem code").to.equal("rating_exists");
+
+    // Errors are problem details, listing every invalid field.
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 0, pizza_id: 2, review: "x".repeat(2001)}), params(token));
+    expect(res.status, "response status").to.equal(400);
+    expect(res.headers["Content-Type"], "content type").to.equal("application/problem+json");
+    expect(res.json().code, "problem code").to.equal("validation_failed");
+    expect(res.json().errors.map((e) => e.field), "invalid fields").to.deep.equal(["stars", "review"]);
+
+    // Requests are validated against the OpenAPI document, which rejects unknown fields and parameters out of range.
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 5, pizza_id: 2, comment: "Great."}), params(token));
+    expect(res.status, "response status").to.equal(400);
+    expect(res.json().errors.map((e) => e.field), "invalid fields").to.deep.equal(["comment"]);
+
+    res = http.get(`${BASE_URL}/api/favorites?per_page=100`, params(token));
+    expect(res.status, "response status").to.equal(400);
+    expect(res.json().code, "problem code").to.equal("validation_failed");
+    expect(res.json().errors.map((e) => e.field), "invalid fields").to.deep.equal(["per_page"]);
+
+    // Reviews with banned words wait for an admin.
+    res = http.post(`${BASE_URL}/api/ratings`, JSON.stringify({stars: 1, pizza_id: 2, review: "Tasted like garbage."}), params(token));
+    expect(res.status, "response status").to.equal(201);
+    expect(res.json().status, "rating status").to.equal("pending");
+    const pendingId = res.json().id;
+
+    res = http.post(`${BASE_URL}/api/users/token/login`, JSON.stringify({username: "admin", password: ADMIN_PASSWORD}), params(""));
+    expect(res.status, "response status").to.equal(200);
+    const adminToken = res.json().token;
+
+    res = http.post(`${BASE_URL}/api/admin/ratings/${pendingId}/approve`, null, params(adminToken));
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().status, "rating status").to.equal("approved");
+
+    // Changes based on an outdated version of a rating are rejected.
+    res = http.get(`${BASE_URL}/api/ratings/${ratingId}`, params(token));
+    expect(res.status, "response status").to.equal(200);
+    const etag = res.headers["Etag"];
+    expect(etag, "etag").to.equal('"v1"');
+
+    res = http.get(`${BASE_URL}/api/ratings/${ratingId}`, {headers: {...params(token).headers, 'If-None-Match': etag}});
+    expect(res.status, "response status").to.equal(304);
+
+    res = http.put(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify({stars: 4}), {headers: {...params(token).headers, 'If-Match': etag}});
+    expect(res.status, "response status").to.equal(200);
+    expect(res.headers["Etag"], "etag").to.equal('"v2"');
+
+    res = http.put(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify({stars: 3}), {headers: {...params(token).headers, 'If-Match': etag}});
+    expect(res.status, "response status").to.equal(412);
+    expect(res.json().code, "problem code").to.equal("version_mismatch");
+
+    // PATCH only changes what the patch sets.
+    res = http.patch(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify({review: "Crispy."}), params(token));
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().stars, "stars").to.equal(4);
+
+    const jsonPatch = {headers: {...params(token).headers, 'Content-Type': 'application/json-patch+json'}};
+    res = http.patch(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify([
+      {op: "test", path: "/stars", value: 4},
+      {op: "replace", path: "/stars", value: 2},
+    ]), jsonPatch);
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().stars, "stars").to.equal(2);
+    expect(res.json().review, "review").to.equal("Crispy.");
+
+    res = http.patch(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify([{op: "test", path: "/stars", value: 4}]), jsonPatch);
+    expect(res.status, "response status").to.equal(409);
+    expect(res.json().code, "problem code").to.equal("patch_conflict");
+
+    res = http.patch(`${BASE_URL}/api/ratings/${ratingId}`, JSON.stringify({pizza_id: 2}), params(token));
+    expect(res.status, "response status").to.equal(422);
+    expect(res.json().code, "problem code").to.equal("read_only_field");
+
+    res = http.del(`${BASE_URL}/api/users/me`, null, params(token));
+    expect(res.status, "response status").to.equal(204);
+  });
+}
+
 function testTokenValidation() {
   describe("Validate a token", () => {
     var res = http.post(`${BASE_URL}/api/users/token/authenticate`, {
@@ -157,6 +322,22 @@ function testPizzaRecommendation() {
   expect(res.json().pizza.name, "pizza name").to.equal("a".repeat(64));
 }
 
+function testOpenAPI() {
+  describe("Serve the OpenAPI document and the API explorer", () => {
+    let res = http.get(`${BASE_URL}/api/openapi.json`);
+    expect(res.status, "response status").to.equal(200);
+    expect(res.json().paths, "paths").to.have.property("/api/pizza");
+
+    res = http.get(`${BASE_URL}/api/openapi.yaml`);
+    expect(res.status, "response status").to.equal(200);
+    expect(res.headers["Content-Type"], "content type").to.equal("application/yaml");
+
+    res = http.get(`${BASE_URL}/docs`);
+    expect(res.status, "response status").to.equal(200);
+    expect(res.body).to.include("/api/openapi.json");
+  });
+}
+
 function testLegacyTestK6IOEndpoint() {
   var res = http.get(`${BASE_URL}/flip_coin.php`);
   expect(res.status, "response status").to.equal(200);
@@ -165,10 +346,13 @@ function testLegacyTestK6IOEndpoint() {
 
 export default function() {
   testCreateUserLogin();
+  testUserProfile();
+  testRatingReviews();
   testDatabaseCreatedUserLogin();
   testTokenValidation();
   testPizzaRecommendation();
   testMetrics();
+  testOpenAPI();
   testLegacyTestK6IOEndpoint();
 }
 
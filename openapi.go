@@ -0,0 +1,9 @@
+// Package quickpizza holds the files of the repository root that are embedded in the QuickPizza binary.
+package quickpizza
+
+import _ "embed"
+
+// OpenAPISpec is the OpenAPI document describing the QuickPizza API.
+//
+//go:embed quickpizza-openapi.yaml
+var OpenAPISpec []byte
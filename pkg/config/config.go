@@ -0,0 +1,417 @@
+// Package config holds the configuration of QuickPizza. It is read from an optional YAML file, and from environment
+// variables, which take precedence. Every setting has a variable, named in the env tag of its field.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	qpgraphql "github.com/grafana/quickpizza/pkg/graphql"
+	qphttp "github.com/grafana/quickpizza/pkg/http"
+	"github.com/grafana/quickpizza/pkg/password"
+)
+
+// LocalEndpoint is the endpoint of the services that run in the same instance.
+const LocalEndpoint = "http://localhost:3333"
+
+// Config is the configuration of a QuickPizza instance. Fields tagged with redact are not printed in full.
+type Config struct {
+	LogLevel slog.Level `yaml:"log_level" env:"QUICKPIZZA_LOG_LEVEL"`
+
+	Services  Services  `yaml:"services"`
+	Endpoints Endpoints `yaml:"endpoints"`
+	Database  Database  `yaml:"database"`
+	Client    Client    `yaml:"client"`
+	Server    Server    `yaml:"server"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Auth      Auth      `yaml:"auth"`
+	JWT       JWT       `yaml:"jwt"`
+	OIDC      OIDC      `yaml:"oidc"`
+	GraphQL   GraphQL   `yaml:"graphql"`
+	GRPC      GRPC      `yaml:"grpc"`
+	OTel      OTel      `yaml:"otel"`
+	Pyroscope Pyroscope `yaml:"pyroscope"`
+	Faults    Faults    `yaml:"faults"`
+
+	// Conf is served by the Config service. Prefix for env vars is QUICKPIZZA_CONF_ instead of QUICKPIZZA_CONFIG_ to
+	// avoid picking up variables generated by K8s from the pod name.
+	Conf map[string]string `yaml:"conf" envprefix:"QUICKPIZZA_CONF_"`
+}
+
+// Services enables the services of the instance. All of them are enabled unless All is false, in which case they are
+// opted-in one by one.
+type Services struct {
+	All             bool `yaml:"all" env:"QUICKPIZZA_ENABLE_ALL_SERVICES"`
+	PublicAPI       bool `yaml:"public_api" env:"QUICKPIZZA_ENABLE_PUBLIC_API_SERVICE"`
+	Catalog         bool `yaml:"catalog" env:"QUICKPIZZA_ENABLE_CATALOG_SERVICE"`
+	Copy            bool `yaml:"copy" env:"QUICKPIZZA_ENABLE_COPY_SERVICE"`
+	WS              bool `yaml:"ws" env:"QUICKPIZZA_ENABLE_WS_SERVICE"`
+	Recommendations bool `yaml:"recommendations" env:"QUICKPIZZA_ENABLE_RECOMMENDATIONS_SERVICE"`
+	Config          bool `yaml:"config" env:"QUICKPIZZA_ENABLE_CONFIG_SERVICE"`
+	OIDC            bool `yaml:"oidc" env:"QUICKPIZZA_ENABLE_OIDC_SERVICE"`
+	GraphQL         bool `yaml:"graphql" env:"QUICKPIZZA_ENABLE_GRAPHQL_SERVICE"`
+	GRPC            bool `yaml:"grpc" env:"QUICKPIZZA_ENABLE_GRPC_SERVICE"`
+	HTTPTesting     bool `yaml:"http_testing" env:"QUICKPIZZA_ENABLE_HTTP_TESTING_SERVICE"`
+	TestK6IO        bool `yaml:"test_k6_io" env:"QUICKPIZZA_ENABLE_TEST_K6_IO_SERVICE"`
+}
+
+// Serve returns whether a service, enabled or not on its own, runs in the instance.
+func (s Services) Serve(enabled bool) bool {
+	return s.All || enabled
+}
+
+// Endpoints are the URLs of the services that do not run in the instance, e.g. http://catalog:3333.
+type Endpoints struct {
+	Catalog         string `yaml:"catalog" env:"QUICKPIZZA_CATALOG_ENDPOINT"`
+	Copy            string `yaml:"copy" env:"QUICKPIZZA_COPY_ENDPOINT"`
+	WS              string `yaml:"ws" env:"QUICKPIZZA_WS_ENDPOINT"`
+	Recommendations string `yaml:"recommendations" env:"QUICKPIZZA_RECOMMENDATIONS_ENDPOINT"`
+	Config          string `yaml:"config" env:"QUICKPIZZA_CONFIG_ENDPOINT"`
+}
+
+// Endpoint returns the URL of a service: LocalEndpoint if it runs in the instance, and endpoint otherwise.
+func (c *Config) Endpoint(enabled bool, endpoint string) string {
+	if c.Services.Serve(enabled) {
+		return LocalEndpoint
+	}
+	return endpoint
+}
+
+// Database configures the database of the Catalog and Copy services, and the limits that keep it from growing forever.
+type Database struct {
+	ConnString   string        `yaml:"conn_string" env:"QUICKPIZZA_DB" redact:"url"`
+	QueryTimeout time.Duration `yaml:"query_timeout" env:"QUICKPIZZA_DB_QUERY_TIMEOUT"`
+	OTelDBName   string        `yaml:"otel_db_name" env:"QUICKPIZZA_OTEL_DB_NAME"`
+
+	FixedPizzas        int `yaml:"fixed_pizzas" env:"QUICKPIZZA_DB_FIXED_PIZZAS"`
+	FixedUsers         int `yaml:"fixed_users" env:"QUICKPIZZA_DB_FIXED_USERS"`
+	FixedRatings       int `yaml:"fixed_ratings" env:"QUICKPIZZA_DB_FIXED_RATINGS"`
+	MaxPizzas          int `yaml:"max_pizzas" env:"QUICKPIZZA_DB_MAX_PIZZAS"`
+	MaxUsers           int `yaml:"max_users" env:"QUICKPIZZA_DB_MAX_USERS"`
+	MaxRatings         int `yaml:"max_ratings" env:"QUICKPIZZA_DB_MAX_RATINGS"`
+	MaxSessions        int `yaml:"max_sessions" env:"QUICKPIZZA_DB_MAX_SESSIONS"`
+	MaxFavorites       int `yaml:"max_favorites" env:"QUICKPIZZA_DB_MAX_FAVORITES"`
+	MaxIdempotencyKeys int `yaml:"max_idempotency_keys" env:"QUICKPIZZA_DB_MAX_IDEMPOTENCY_KEYS"`
+	MaxLoginAttempts   int `yaml:"max_login_attempts" env:"QUICKPIZZA_DB_MAX_LOGIN_ATTEMPTS"`
+}
+
+// Options returns the options of the connections to the database.
+func (d Database) Options() database.Options {
+	return database.Options{
+		ConnString:   d.ConnString,
+		QueryTimeout: d.QueryTimeout,
+		OTelDBName:   d.OTelDBName,
+	}
+}
+
+// Client configures the requests made to other services.
+type Client struct {
+	Timeout time.Duration `yaml:"timeout" env:"QUICKPIZZA_TIMEOUT"`
+	Retries int           `yaml:"retries" env:"QUICKPIZZA_RETRIES"`
+	// BackoffMin and BackoffMax bound the wait between retries. Zero uses the defaults of go-retryablehttp.
+	BackoffMin time.Duration `yaml:"backoff_min" env:"QUICKPIZZA_BACKOFF_MIN"`
+	BackoffMax time.Duration `yaml:"backoff_max" env:"QUICKPIZZA_BACKOFF_MAX"`
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	DeadlinePropagation bool          `yaml:"deadline_propagation" env:"QUICKPIZZA_DEADLINE_PROPAGATION"`
+	RequestTimeout      time.Duration `yaml:"request_timeout" env:"QUICKPIZZA_REQUEST_TIMEOUT"`
+	RequireIfMatch      bool          `yaml:"require_if_match" env:"QUICKPIZZA_REQUIRE_IF_MATCH"`
+
+	DisableOpenAPIValidation bool `yaml:"disable_openapi_validation" env:"QUICKPIZZA_DISABLE_OPENAPI_VALIDATION"`
+	OpenAPIValidateResponses bool `yaml:"openapi_validate_responses" env:"QUICKPIZZA_OPENAPI_VALIDATE_RESPONSES"`
+
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay" env:"QUICKPIZZA_SHUTDOWN_DELAY"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"QUICKPIZZA_SHUTDOWN_TIMEOUT"`
+
+	ProbeTimeout        time.Duration `yaml:"probe_timeout" env:"QUICKPIZZA_PROBE_TIMEOUT"`
+	ProbeDisabledChecks []string      `yaml:"probe_disabled_checks" env:"QUICKPIZZA_PROBE_DISABLED_CHECKS"`
+
+	// InternalToken authenticates the requests services make to each other. It must be the same in every instance,
+	// and is random if not set, which only works if all services run in the instance.
+	InternalToken string `yaml:"internal_token" env:"QUICKPIZZA_INTERNAL_TOKEN" redact:"true"`
+	// TrustedProxies lists the IP addresses and CIDR ranges of the proxies, e.g. the public API, whose
+	// X-Forwarded-For header is trusted to identify clients.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"QUICKPIZZA_TRUSTED_PROXIES"`
+}
+
+// RateLimit configures rate limiting, which is disabled unless Rate is set, e.g. to "10/s".
+type RateLimit struct {
+	Rate   string `yaml:"rate" env:"QUICKPIZZA_RATE_LIMIT"`
+	Key    string `yaml:"key" env:"QUICKPIZZA_RATE_LIMIT_KEY"`
+	Routes string `yaml:"routes" env:"QUICKPIZZA_RATE_LIMIT_ROUTES"`
+}
+
+// Auth configures tokens, logins and passwords.
+type Auth struct {
+	TokenTTL          time.Duration `yaml:"token_ttl" env:"QUICKPIZZA_TOKEN_TTL"`
+	RefreshTokenTTL   time.Duration `yaml:"refresh_token_ttl" env:"QUICKPIZZA_REFRESH_TOKEN_TTL"`
+	IdempotencyKeyTTL time.Duration `yaml:"idempotency_key_ttl" env:"QUICKPIZZA_IDEMPOTENCY_KEY_TTL"`
+
+	LoginMaxFailures   int           `yaml:"login_max_failures" env:"QUICKPIZZA_LOGIN_MAX_FAILURES"`
+	LoginMaxIPFailures int           `yaml:"login_max_ip_failures" env:"QUICKPIZZA_LOGIN_MAX_IP_FAILURES"`
+	LoginFailureWindow time.Duration `yaml:"login_failure_window" env:"QUICKPIZZA_LOGIN_FAILURE_WINDOW"`
+	LoginLockout       time.Duration `yaml:"login_lockout" env:"QUICKPIZZA_LOGIN_LOCKOUT"`
+
+	PasswordMinLength int `yaml:"password_min_length" env:"QUICKPIZZA_PASSWORD_MIN_LENGTH"`
+	// PasswordRequire lists the character classes required in passwords, e.g. "upper,digit".
+	PasswordRequire string `yaml:"password_require" env:"QUICKPIZZA_PASSWORD_REQUIRE"`
+
+	// DevStaffPasswords gives the admin and kitchen users their username as password, instead of a random one that is
+	// logged once. Only meant for local development and tests.
+	DevStaffPasswords bool `yaml:"dev_staff_passwords" env:"QUICKPIZZA_DEV_STAFF_PASSWORDS"`
+}
+
+// CatalogConfig returns the configuration of the Catalog.
+func (c *Config) CatalogConfig() database.CatalogConfig {
+	return database.CatalogConfig{
+		FixedPizzas:        c.Database.FixedPizzas,
+		FixedUsers:         c.Database.FixedUsers,
+		FixedRatings:       c.Database.FixedRatings,
+		MaxPizzas:          c.Database.MaxPizzas,
+		MaxUsers:           c.Database.MaxUsers,
+		MaxRatings:         c.Database.MaxRatings,
+		MaxSessions:        c.Database.MaxSessions,
+		MaxFavorites:       c.Database.MaxFavorites,
+		MaxIdempotencyKeys: c.Database.MaxIdempotencyKeys,
+		MaxLoginAttempts:   c.Database.MaxLoginAttempts,
+		IdempotencyKeyTTL:  c.Auth.IdempotencyKeyTTL,
+		LoginMaxFailures:   c.Auth.LoginMaxFailures,
+		LoginMaxIPFailures: c.Auth.LoginMaxIPFailures,
+		LoginFailureWindow: c.Auth.LoginFailureWindow,
+		LoginLockout:       c.Auth.LoginLockout,
+		TokenTTL:           c.Auth.TokenTTL,
+		RefreshTokenTTL:    c.Auth.RefreshTokenTTL,
+		DevStaffPasswords:  c.Auth.DevStaffPasswords,
+	}
+}
+
+// JWT configures JWT authentication, which is disabled unless Algorithm is set to HS256 or RS256.
+type JWT struct {
+	Algorithm  string `yaml:"algorithm" env:"QUICKPIZZA_JWT_ALGORITHM"`
+	Issuer     string `yaml:"issuer" env:"QUICKPIZZA_JWT_ISSUER"`
+	Secret     string `yaml:"secret" env:"QUICKPIZZA_JWT_SECRET" redact:"true"`
+	PrivateKey string `yaml:"private_key" env:"QUICKPIZZA_JWT_PRIVATE_KEY"`
+	JWKSURL    string `yaml:"jwks_url" env:"QUICKPIZZA_JWT_JWKS_URL"`
+}
+
+// OIDC configures the OpenID Connect provider.
+type OIDC struct {
+	Issuer string `yaml:"issuer" env:"QUICKPIZZA_OIDC_ISSUER"`
+	// Clients is a JSON array of clients, replacing the default ones if set.
+	Clients string `yaml:"clients" env:"QUICKPIZZA_OIDC_CLIENTS" redact:"true"`
+}
+
+// GraphQL configures the GraphQL API. Zero limits disable them.
+type GraphQL struct {
+	MaxDepth           int  `yaml:"max_depth" env:"QUICKPIZZA_GRAPHQL_MAX_DEPTH"`
+	MaxComplexity      int  `yaml:"max_complexity" env:"QUICKPIZZA_GRAPHQL_MAX_COMPLEXITY"`
+	DisableDataLoaders bool `yaml:"disable_dataloaders" env:"QUICKPIZZA_GRAPHQL_DISABLE_DATALOADERS"`
+}
+
+// GRPC configures the gRPC server, which is plaintext unless one of the TLS settings is set.
+type GRPC struct {
+	ListenAddress       string        `yaml:"listen_address" env:"QUICKPIZZA_GRPC_LISTEN_ADDRESS"`
+	HealthListenAddress string        `yaml:"health_listen_address" env:"QUICKPIZZA_GRPC_HEALTH_LISTEN_ADDRESS"`
+	ShutdownTimeout     time.Duration `yaml:"shutdown_timeout" env:"QUICKPIZZA_GRPC_SHUTDOWN_TIMEOUT"`
+
+	TLSCertFile     string `yaml:"tls_cert_file" env:"QUICKPIZZA_GRPC_TLS_CERT_FILE"`
+	TLSKeyFile      string `yaml:"tls_key_file" env:"QUICKPIZZA_GRPC_TLS_KEY_FILE"`
+	TLSSelfSigned   bool   `yaml:"tls_self_signed" env:"QUICKPIZZA_GRPC_TLS_SELF_SIGNED"`
+	TLSClientCAFile string `yaml:"tls_client_ca_file" env:"QUICKPIZZA_GRPC_TLS_CLIENT_CA_FILE"`
+}
+
+// OTel configures OpenTelemetry, which is disabled unless Endpoint is set.
+type OTel struct {
+	Endpoint           string `yaml:"endpoint" env:"QUICKPIZZA_OTLP_ENDPOINT"`
+	Protocol           string `yaml:"protocol" env:"OTEL_EXPORTER_OTLP_PROTOCOL"`
+	ServiceName        string `yaml:"service_name" env:"QUICKPIZZA_OTEL_SERVICE_NAME"`
+	ServiceNamespace   string `yaml:"service_namespace" env:"QUICKPIZZA_OTEL_SERVICE_NAMESPACE"`
+	ServiceInstanceID  string `yaml:"service_instance_id" env:"QUICKPIZZA_OTEL_SERVICE_INSTANCE_ID"`
+	TrustClientTraceID bool   `yaml:"trust_client_traceid" env:"QUICKPIZZA_TRUST_CLIENT_TRACEID"`
+}
+
+// Pyroscope configures profiling in push mode, which is disabled unless Endpoint is set.
+type Pyroscope struct {
+	Endpoint string `yaml:"endpoint" env:"QUICKPIZZA_PYROSCOPE_ENDPOINT"`
+	// Name is the application name of profiles. It defaults to the OpenTelemetry service name.
+	Name               string `yaml:"name" env:"QUICKPIZZA_PYROSCOPE_NAME"`
+	Namespace          string `yaml:"namespace" env:"QUICKPIZZA_PYROSCOPE_NAMESPACE"`
+	NamespaceLabelName string `yaml:"namespace_label_name" env:"QUICKPIZZA_PYROSCOPE_NAMESPACE_LABEL_NAME"`
+	ServiceGitRef      string `yaml:"service_git_ref" env:"QUICKPIZZA_PYROSCOPE_SERVICE_GIT_REF"`
+	User               string `yaml:"user" env:"QUICKPIZZA_GRAFANA_CLOUD_USER"`
+	Password           string `yaml:"password" env:"QUICKPIZZA_GRAFANA_CLOUD_PASSWORD" redact:"true"`
+}
+
+// Faults are artificial delays and failures, see qphttp.Faults. Delays are set in milliseconds in env vars, e.g.
+// QUICKPIZZA_DELAY_COPY=500, and as durations in files, e.g. 500ms.
+type Faults struct {
+	CopyDelay                   time.Duration `yaml:"copy_delay" env:"QUICKPIZZA_DELAY_COPY" envunit:"ms"`
+	CopyQuotesDelay             time.Duration `yaml:"copy_quotes_delay" env:"QUICKPIZZA_DELAY_COPY_API_QUOTES" envunit:"ms"`
+	CopyNamesDelay              time.Duration `yaml:"copy_names_delay" env:"QUICKPIZZA_DELAY_COPY_API_NAMES" envunit:"ms"`
+	CopyAdjectivesDelay         time.Duration `yaml:"copy_adjectives_delay" env:"QUICKPIZZA_DELAY_COPY_API_ADJECTIVES" envunit:"ms"`
+	RecommendationsDelay        time.Duration `yaml:"recommendations_delay" env:"QUICKPIZZA_DELAY_RECOMMENDATIONS" envunit:"ms"`
+	RecommendationsGetDelay     time.Duration `yaml:"recommendations_get_delay" env:"QUICKPIZZA_DELAY_RECOMMENDATIONS_API_PIZZA_GET" envunit:"ms"`
+	RecommendationsPostDelay    time.Duration `yaml:"recommendations_post_delay" env:"QUICKPIZZA_DELAY_RECOMMENDATIONS_API_PIZZA_POST" envunit:"ms"`
+	RecommendationsPostFailRate int           `yaml:"recommendations_post_fail_rate" env:"QUICKPIZZA_FAIL_RATE_RECOMMENDATIONS_API_PIZZA_POST"`
+	FrontendCSSDelay            time.Duration `yaml:"frontend_css_delay" env:"QUICKPIZZA_DELAY_FRONTEND_CSS_ASSETS" envunit:"ms"`
+	FrontendPNGDelay            time.Duration `yaml:"frontend_png_delay" env:"QUICKPIZZA_DELAY_FRONTEND_PNG_ASSETS" envunit:"ms"`
+}
+
+// HTTPFaults returns the faults injected by the HTTP server.
+func (f Faults) HTTPFaults() qphttp.Faults {
+	return qphttp.Faults{
+		CopyDelay:                   f.CopyDelay,
+		CopyQuotesDelay:             f.CopyQuotesDelay,
+		CopyNamesDelay:              f.CopyNamesDelay,
+		CopyAdjectivesDelay:         f.CopyAdjectivesDelay,
+		RecommendationsDelay:        f.RecommendationsDelay,
+		RecommendationsGetDelay:     f.RecommendationsGetDelay,
+		RecommendationsPostDelay:    f.RecommendationsPostDelay,
+		RecommendationsPostFailRate: f.RecommendationsPostFailRate,
+		FrontendCSSDelay:            f.FrontendCSSDelay,
+		FrontendPNGDelay:            f.FrontendPNGDelay,
+	}
+}
+
+// Default returns the configuration used when neither a file nor env vars set anything.
+func Default() *Config {
+	catalog := database.DefaultCatalogConfig()
+
+	return &Config{
+		LogLevel: slog.LevelInfo,
+		Services: Services{All: true},
+		Database: Database{
+			ConnString:         "file::memory:?cache=shared",
+			OTelDBName:         "quickpizza-database",
+			FixedPizzas:        catalog.FixedPizzas,
+			FixedUsers:         catalog.FixedUsers,
+			FixedRatings:       catalog.FixedRatings,
+			MaxPizzas:          catalog.MaxPizzas,
+			MaxUsers:           catalog.MaxUsers,
+			MaxRatings:         catalog.MaxRatings,
+			MaxSessions:        catalog.MaxSessions,
+			MaxFavorites:       catalog.MaxFavorites,
+			MaxIdempotencyKeys: catalog.MaxIdempotencyKeys,
+			MaxLoginAttempts:   catalog.MaxLoginAttempts,
+		},
+		Client: Client{Timeout: time.Second},
+		Server: Server{
+			ShutdownTimeout: 10 * time.Second,
+			ProbeTimeout:    qphttp.DefaultCheckTimeout,
+		},
+		RateLimit: RateLimit{Key: qphttp.RateLimitByToken},
+		Auth: Auth{
+			TokenTTL:           catalog.TokenTTL,
+			RefreshTokenTTL:    catalog.RefreshTokenTTL,
+			IdempotencyKeyTTL:  catalog.IdempotencyKeyTTL,
+			LoginMaxFailures:   catalog.LoginMaxFailures,
+			LoginMaxIPFailures: catalog.LoginMaxIPFailures,
+			LoginFailureWindow: catalog.LoginFailureWindow,
+			LoginLockout:       catalog.LoginLockout,
+			PasswordMinLength:  password.DefaultPolicy().MinLength,
+		},
+		JWT: JWT{Issuer: "quickpizza"},
+		GraphQL: GraphQL{
+			MaxDepth:      qpgraphql.DefaultMaxDepth,
+			MaxComplexity: qpgraphql.DefaultMaxComplexity,
+		},
+		GRPC: GRPC{
+			ListenAddress:       ":3334",
+			HealthListenAddress: ":3335",
+			ShutdownTimeout:     10 * time.Second,
+		},
+		OTel: OTel{
+			Protocol:          "http/protobuf",
+			ServiceName:       "quickpizza",
+			ServiceNamespace:  "quickpizza",
+			ServiceInstanceID: "local",
+		},
+		Pyroscope: Pyroscope{
+			Namespace:          "quickpizza",
+			NamespaceLabelName: "namespace",
+			ServiceGitRef:      "refs/heads/main",
+		},
+		Conf: map[string]string{},
+	}
+}
+
+// Validate returns the problems of the configuration that can be found without using it, e.g. negative timeouts, or
+// missing endpoints of services that do not run in the instance. Settings parsed by the components they configure,
+// e.g. the rate limit, are checked when the components are set up.
+func (c *Config) Validate() error {
+	return errors.Join(c.validate()...)
+}
+
+func (c *Config) validate() []error {
+	errs := checkNonNegative(c)
+
+	if c.Faults.RecommendationsPostFailRate > 100 {
+		errs = append(errs, fieldError(&c.Faults, "RecommendationsPostFailRate", "must be a percentage, between 0 and 100"))
+	}
+
+	for _, field := range []string{"Catalog", "Copy", "WS", "Recommendations", "Config"} {
+		if err := checkURL(&c.Endpoints, field); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkURL(&c.OTel, "Endpoint"); err != nil {
+		errs = append(errs, err)
+	}
+	if c.OTel.Protocol != "http/protobuf" && c.OTel.Protocol != "grpc" {
+		errs = append(errs, fieldError(&c.OTel, "Protocol", fmt.Sprintf("unsupported protocol %q, must be http/protobuf or grpc", c.OTel.Protocol)))
+	}
+
+	if _, err := qphttp.ParseTrustedProxies(c.Server.TrustedProxies); err != nil {
+		errs = append(errs, fieldError(&c.Server, "TrustedProxies", err.Error()))
+	}
+
+	// Services that do not run in the instance are reached through their endpoint, which must be set.
+	s := c.Services
+	required := map[string]bool{}
+	if s.Serve(s.Recommendations) {
+		required["Catalog"] = !s.Serve(s.Catalog)
+		required["Copy"] = !s.Serve(s.Copy)
+	}
+	if s.PublicAPI && !s.All {
+		required["Catalog"] = !s.Catalog
+		required["Copy"] = !s.Copy
+		required["WS"] = !s.WS
+		required["Recommendations"] = !s.Recommendations
+		required["Config"] = !s.Config
+	}
+	if c.JWT.Algorithm == "RS256" && c.JWT.JWKSURL == "" && !s.Serve(s.Catalog) {
+		required["Catalog"] = true
+	}
+	for _, field := range []string{"Catalog", "Copy", "WS", "Recommendations", "Config"} {
+		if required[field] && fieldValue(&c.Endpoints, field).String() == "" {
+			errs = append(errs, fieldError(&c.Endpoints, field, "must be set, as the service does not run in this instance"))
+		}
+	}
+	if !s.All && c.Server.InternalToken == "" {
+		errs = append(errs, fieldError(&c.Server, "InternalToken", "must be set to the same value in every instance, when running as separate services"))
+	}
+
+	return errs
+}
+
+// checkURL returns an error if the URL in the field of section is set, but is not an absolute http(s) URL.
+func checkURL(section any, field string) error {
+	v := fieldValue(section, field).String()
+	if v == "" {
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return fieldError(section, field, err.Error())
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fieldError(section, field, fmt.Sprintf("%q is not an http:// or https:// URL", v))
+	}
+	return nil
+}
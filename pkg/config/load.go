@@ -0,0 +1,261 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Load returns the default configuration, overridden by the YAML file at path, if path is not empty, and then by env
+// vars. Unknown settings in the file, values that cannot be parsed and invalid configurations are errors. Empty env
+// vars are ignored, like unset ones.
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	// Every problem is reported at once, rather than one per attempt to start.
+	errs := append(c.loadEnv(os.Environ()), c.validate()...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// loadEnv overrides the settings of c with the env vars set in environ, in key=value form. It returns an error for every
+// value that cannot be parsed.
+func (c *Config) loadEnv(environ []string) []error {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if value != "" {
+			env[name] = value
+		}
+	}
+
+	var errs []error
+	walkFields(reflect.ValueOf(c).Elem(), func(f reflect.StructField, v reflect.Value) {
+		if prefix := f.Tag.Get("envprefix"); prefix != "" {
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(f.Type))
+			}
+			for name, value := range env {
+				if key, ok := strings.CutPrefix(name, prefix); ok {
+					v.SetMapIndex(reflect.ValueOf(strings.ToLower(key)), reflect.ValueOf(value))
+				}
+			}
+			return
+		}
+
+		name := f.Tag.Get("env")
+		value, ok := env[name]
+		if name == "" || !ok {
+			return
+		}
+		if err := setValue(v, value, f.Tag.Get("envunit")); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q: %w", name, value, err))
+		}
+	})
+	return errs
+}
+
+// setValue sets v from the value of an env var. Durations are parsed as milliseconds if unit is "ms" and s is an
+// integer, and lists are comma-separated.
+func setValue(v reflect.Value, s string, unit string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		if ms, err := strconv.Atoi(s); err == nil && unit == "ms" {
+			v.SetInt(int64(time.Duration(ms) * time.Millisecond))
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("must be a duration, e.g. 500ms or 10s")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be a boolean, e.g. true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		v.SetInt(int64(i))
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// walkFields calls fn with the settings of the struct v, and of its sections.
+func walkFields(v reflect.Value, fn func(f reflect.StructField, v reflect.Value)) {
+	for i := range v.NumField() {
+		f, fv := v.Type().Field(i), v.Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Tag.Get("env") == "" {
+			walkFields(fv, fn)
+			continue
+		}
+		fn(f, fv)
+	}
+}
+
+// checkNonNegative returns an error for every integer or duration setting of c that is negative.
+func checkNonNegative(c *Config) []error {
+	var errs []error
+	cv := reflect.ValueOf(c).Elem()
+	for i := range cv.NumField() {
+		section := cv.Field(i)
+		if section.Kind() != reflect.Struct {
+			continue
+		}
+		for j := range section.NumField() {
+			f, v := section.Type().Field(j), section.Field(j)
+			if (f.Type == durationType || f.Type.Kind() == reflect.Int) && v.Int() < 0 {
+				errs = append(errs, fieldError(section.Addr().Interface(), f.Name, "must not be negative"))
+			}
+		}
+	}
+	return errs
+}
+
+// fieldValue returns the value of the field of section, a pointer to one of the sections of Config.
+func fieldValue(section any, field string) reflect.Value {
+	return reflect.ValueOf(section).Elem().FieldByName(field)
+}
+
+// fieldError returns an error about the field of section, a pointer to one of the sections of Config, naming the
+// setting in files and its env var.
+func fieldError(section any, field, msg string) error {
+	t := reflect.TypeOf(section).Elem()
+	f, _ := t.FieldByName(field)
+
+	name := yamlName(f)
+	for i := range reflect.TypeFor[Config]().NumField() {
+		if s := reflect.TypeFor[Config]().Field(i); s.Type == t {
+			name = yamlName(s) + "." + name
+		}
+	}
+	return fmt.Errorf("%s (%s): %s", name, f.Tag.Get("env"), msg)
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return name
+}
+
+// YAML returns the configuration in YAML, as accepted by Load, with the env var of every setting in a comment.
+// Secrets are redacted.
+func (c *Config) YAML() ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(c.redacted()); err != nil {
+		return nil, err
+	}
+	commentEnv(&node, reflect.TypeFor[Config]())
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LogValue logs the configuration with the names of YAML, and secrets redacted.
+func (c *Config) LogValue() slog.Value {
+	var m map[string]any
+	data, err := yaml.Marshal(c.redacted())
+	if err == nil {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return slog.StringValue(err.Error())
+	}
+	return slog.AnyValue(m)
+}
+
+// redacted returns a copy of c without the secrets in the fields tagged with redact. Those tagged with redact:"url"
+// only have their password redacted.
+func (c *Config) redacted() *Config {
+	r := *c
+	walkFields(reflect.ValueOf(&r).Elem(), func(f reflect.StructField, v reflect.Value) {
+		if v.Kind() != reflect.String || v.String() == "" {
+			return
+		}
+		switch f.Tag.Get("redact") {
+		case "true":
+			v.SetString("xxxxx")
+		case "url":
+			if u, err := url.Parse(v.String()); err == nil {
+				v.SetString(u.Redacted())
+			}
+		}
+	})
+	return &r
+}
+
+// commentEnv adds the env var of every setting of t to node, the YAML mapping of a value of t.
+func commentEnv(node *yaml.Node, t reflect.Type) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		for j := range t.NumField() {
+			f := t.Field(j)
+			if yamlName(f) != key.Value {
+				continue
+			}
+			switch {
+			case f.Tag.Get("env") != "":
+				value.LineComment = f.Tag.Get("env")
+			case f.Tag.Get("envprefix") != "":
+				value.LineComment = f.Tag.Get("envprefix") + "<NAME>"
+			case f.Type.Kind() == reflect.Struct:
+				commentEnv(value, f.Type)
+			}
+		}
+	}
+}
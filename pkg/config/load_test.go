@@ -0,0 +1,296 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		environ []string
+		check   func(c *Config) any
+		want    any
+	}{
+		{
+			name:    "string",
+			environ: []string{"QUICKPIZZA_DB=postgres://db"},
+			check:   func(c *Config) any { return c.Database.ConnString },
+			want:    "postgres://db",
+		},
+		{
+			name:    "value containing equal signs",
+			environ: []string{"QUICKPIZZA_DB=file:test.db?mode=memory&cache=shared"},
+			check:   func(c *Config) any { return c.Database.ConnString },
+			want:    "file:test.db?mode=memory&cache=shared",
+		},
+		{
+			name:    "integer",
+			environ: []string{"QUICKPIZZA_RETRIES=3"},
+			check:   func(c *Config) any { return c.Client.Retries },
+			want:    3,
+		},
+		{
+			name:    "boolean",
+			environ: []string{"QUICKPIZZA_ENABLE_ALL_SERVICES=0"},
+			check:   func(c *Config) any { return c.Services.All },
+			want:    false,
+		},
+		{
+			name:    "duration",
+			environ: []string{"QUICKPIZZA_TIMEOUT=1m30s"},
+			check:   func(c *Config) any { return c.Client.Timeout },
+			want:    90 * time.Second,
+		},
+		{
+			name:    "duration in milliseconds",
+			environ: []string{"QUICKPIZZA_DELAY_COPY=500"},
+			check:   func(c *Config) any { return c.Faults.CopyDelay },
+			want:    500 * time.Millisecond,
+		},
+		{
+			name:    "duration with unit in milliseconds field",
+			environ: []string{"QUICKPIZZA_DELAY_COPY=2s"},
+			check:   func(c *Config) any { return c.Faults.CopyDelay },
+			want:    2 * time.Second,
+		},
+		{
+			name:    "list",
+			environ: []string{"QUICKPIZZA_TRUSTED_PROXIES=10.0.0.0/8, 192.168.1.10,,"},
+			check:   func(c *Config) any { return c.Server.TrustedProxies },
+			want:    []string{"10.0.0.0/8", "192.168.1.10"},
+		},
+		{
+			name:    "text unmarshaler",
+			environ: []string{"QUICKPIZZA_LOG_LEVEL=debug"},
+			check:   func(c *Config) any { return c.LogLevel },
+			want:    slog.LevelDebug,
+		},
+		{
+			name:    "empty values are ignored",
+			environ: []string{"QUICKPIZZA_TIMEOUT=", "QUICKPIZZA_RETRIES"},
+			check:   func(c *Config) any { return [2]any{c.Client.Timeout, c.Client.Retries} },
+			want:    [2]any{time.Second, 0},
+		},
+		{
+			name:    "prefixed map",
+			environ: []string{"QUICKPIZZA_CONF_FOO_BAR=baz", "QUICKPIZZA_CONFIG_FILE=ignored.yaml"},
+			check:   func(c *Config) any { return c.Conf },
+			want:    map[string]string{"foo_bar": "baz"},
+		},
+		{
+			name:    "unknown variables are ignored",
+			environ: []string{"QUICKPIZZA_UNKNOWN=1", "HOME=/root"},
+			check:   func(c *Config) any { return c.Client },
+			want:    Default().Client,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := Default()
+			if errs := c.loadEnv(tc.environ); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if got := tc.check(c); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		environ []string
+		want    []string
+	}{
+		{
+			name:    "integer",
+			environ: []string{"QUICKPIZZA_RETRIES=three"},
+			want:    []string{`QUICKPIZZA_RETRIES: invalid value "three": must be an integer`},
+		},
+		{
+			name:    "boolean",
+			environ: []string{"QUICKPIZZA_ENABLE_ALL_SERVICES=maybe"},
+			want:    []string{`QUICKPIZZA_ENABLE_ALL_SERVICES: invalid value "maybe": must be a boolean`},
+		},
+		{
+			name:    "duration without unit",
+			environ: []string{"QUICKPIZZA_TIMEOUT=5"},
+			want:    []string{`QUICKPIZZA_TIMEOUT: invalid value "5": must be a duration`},
+		},
+		{
+			name:    "every error is reported",
+			environ: []string{"QUICKPIZZA_RETRIES=x", "QUICKPIZZA_TIMEOUT=y", "QUICKPIZZA_LOG_LEVEL=loud"},
+			want:    []string{"QUICKPIZZA_LOG_LEVEL", "QUICKPIZZA_TIMEOUT", "QUICKPIZZA_RETRIES"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := Default().loadEnv(tc.environ)
+			if len(errs) != len(tc.want) {
+				t.Fatalf("expected %d errors, got %v", len(tc.want), errs)
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Fatalf("expected error %d to contain %q, got %q", i, want, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "default",
+			modify: func(c *Config) {},
+		},
+		{
+			name:   "negative values",
+			modify: func(c *Config) { c.Client.Retries = -1; c.Server.RequestTimeout = -time.Second },
+			want:   []string{"client.retries (QUICKPIZZA_RETRIES): must not be negative", "server.request_timeout (QUICKPIZZA_REQUEST_TIMEOUT): must not be negative"},
+		},
+		{
+			name:   "fail rate over 100",
+			modify: func(c *Config) { c.Faults.RecommendationsPostFailRate = 101 },
+			want:   []string{"faults.recommendations_post_fail_rate"},
+		},
+		{
+			name:   "endpoint is not a URL",
+			modify: func(c *Config) { c.Endpoints.Catalog = "catalog:3333" },
+			want:   []string{"endpoints.catalog (QUICKPIZZA_CATALOG_ENDPOINT)"},
+		},
+		{
+			name:   "unsupported OTLP protocol",
+			modify: func(c *Config) { c.OTel.Protocol = "http/json" },
+			want:   []string{"otel.protocol (OTEL_EXPORTER_OTLP_PROTOCOL)"},
+		},
+		{
+			name:   "invalid trusted proxy",
+			modify: func(c *Config) { c.Server.TrustedProxies = []string{"gateway"} },
+			want:   []string{"server.trusted_proxies (QUICKPIZZA_TRUSTED_PROXIES)"},
+		},
+		{
+			name: "separate services without endpoints",
+			modify: func(c *Config) {
+				c.Services = Services{Recommendations: true}
+				c.Server.InternalToken = "secret"
+			},
+			want: []string{"endpoints.catalog (QUICKPIZZA_CATALOG_ENDPOINT): must be set", "endpoints.copy (QUICKPIZZA_COPY_ENDPOINT): must be set"},
+		},
+		{
+			name: "separate services with endpoints",
+			modify: func(c *Config) {
+				c.Services = Services{Recommendations: true}
+				c.Server.InternalToken = "secret"
+				c.Endpoints.Catalog = "http://catalog:3333"
+				c.Endpoints.Copy = "http://copy:3333"
+			},
+		},
+		{
+			name: "separate services without internal token",
+			modify: func(c *Config) {
+				c.Services = Services{Config: true}
+			},
+			want: []string{"server.internal_token (QUICKPIZZA_INTERNAL_TOKEN)"},
+		},
+		{
+			name: "RS256 without catalog or JWKS URL",
+			modify: func(c *Config) {
+				c.Services = Services{Config: true}
+				c.Server.InternalToken = "secret"
+				c.JWT.Algorithm = "RS256"
+			},
+			want: []string{"endpoints.catalog (QUICKPIZZA_CATALOG_ENDPOINT): must be set"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := Default()
+			tc.modify(c)
+			errs := c.validate()
+			if len(errs) != len(tc.want) {
+				t.Fatalf("expected %d errors, got %v", len(tc.want), errs)
+			}
+			for i, want := range tc.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Fatalf("expected error %d to contain %q, got %q", i, want, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte("client:\n  timeout: 5s\n  retries: 2\nfaults:\n  copy_delay: 250ms\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Env vars take precedence over the file.
+	t.Setenv("QUICKPIZZA_RETRIES", "4")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Client.Timeout != 5*time.Second || c.Client.Retries != 4 || c.Faults.CopyDelay != 250*time.Millisecond {
+		t.Fatalf("unexpected configuration: %+v %+v", c.Client, c.Faults)
+	}
+}
+
+func TestLoadRejectsUnknownSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("client:\n  timout: 5s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "timout") {
+		t.Fatalf("expected an error about the unknown setting, got %v", err)
+	}
+}
+
+func TestYAMLRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	c := Default()
+	c.Database.ConnString = "postgres://user:hunter2@db:5432/quickpizza"
+	c.JWT.Secret = "hunter2"
+	c.Server.InternalToken = "hunter2"
+
+	data, err := c.YAML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatalf("secrets were not redacted:\n%s", data)
+	}
+	for _, want := range []string{"postgres://user:xxxxx@db:5432/quickpizza", "# QUICKPIZZA_DB", "# QUICKPIZZA_JWT_SECRET"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q in:\n%s", want, data)
+		}
+	}
+	if c.JWT.Secret != "hunter2" {
+		t.Fatal("redacting changed the configuration")
+	}
+}
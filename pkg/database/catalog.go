@@ -5,8 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"sync"
+	"time"
 
 	"log/slog"
 
@@ -29,15 +29,124 @@ type Catalog struct {
 	maxPizzas    int
 	maxUsers     int
 	maxRatings   int
+	maxSessions  int
+	maxFavorites int
+
+	maxIdempotencyKeys int
+	idempotencyKeyTTL  time.Duration
+
+	maxLoginAttempts   int
+	loginMaxFailures   int
+	loginMaxIPFailures int
+	loginFailureWindow time.Duration
+	loginLockout       time.Duration
+
+	tokenTTL        time.Duration
+	refreshTokenTTL time.Duration
+
+	queryTimeout time.Duration
 }
 
 const getRatingsMax = 50
 
 var ErrUsernameTaken = errors.New("username already taken")
 var ErrGlobalOperationNotPermitted = errors.New("operation not permitted for default user")
+var ErrTokenExpired = errors.New("token expired")
+var ErrTokenRevoked = errors.New("token revoked")
+var ErrTokenNotFound = errors.New("token not found")
+var ErrPizzaNotFound = errors.New("pizza not found")
+var ErrFavoriteNotFound = errors.New("pizza is not a favorite")
+var ErrRatingNotFound = errors.New("rating not found")
+var ErrRatingVersionMismatch = errors.New("rating was changed by another request")
+
+// RatingExistsError is returned by RecordRating when the user already rated the pizza.
+type RatingExistsError struct {
+	RatingID int64
+}
+
+func (e *RatingExistsError) Error() string {
+	return fmt.Sprintf("pizza already rated, see rating ID %d", e.RatingID)
+}
+
+// LockoutError is returned by LoginUser when there were too many failed login attempts for the account, or from
+// the client.
+type LockoutError struct {
+	// Scope is either "account" or "ip".
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed login attempts for this %s, try again in %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// dummyPasswordHash is checked against when logging in as a user that does not exist, so that it takes as long as
+// logging in with a wrong password, and usernames cannot be guessed from response times.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := password.HashPassword("quickpizza")
+	return hash
+})
 
-func NewCatalog(connString string) (*Catalog, error) {
-	db, err := initializeDB(connString)
+// CatalogConfig sets the size of the fixed data of the Catalog, the limits that keep the database from growing
+// forever, and the lifetime of tokens and login lockouts.
+type CatalogConfig struct {
+	// FixedPizzas, FixedUsers and FixedRatings are the number of rows of each kind, created first, that are never
+	// deleted when enforcing the limits below.
+	FixedPizzas  int
+	FixedUsers   int
+	FixedRatings int
+
+	// The maximum number of rows of each kind, or 0 for no limit. The oldest rows are deleted once it is exceeded.
+	MaxPizzas          int
+	MaxUsers           int
+	MaxRatings         int
+	MaxSessions        int
+	MaxFavorites       int
+	MaxIdempotencyKeys int
+	MaxLoginAttempts   int
+
+	IdempotencyKeyTTL time.Duration
+
+	// LoginMaxFailures and LoginMaxIPFailures are the number of failed logins within LoginFailureWindow after which
+	// an account, or a client IP, is locked out for LoginLockout.
+	LoginMaxFailures   int
+	LoginMaxIPFailures int
+	LoginFailureWindow time.Duration
+	LoginLockout       time.Duration
+
+	TokenTTL        time.Duration
+	RefreshTokenTTL time.Duration
+
+	// DevStaffPasswords gives the staff users the well-known passwords "admin" and "kitchen" instead of random ones.
+	// It is meant for local development and tests only.
+	DevStaffPasswords bool
+}
+
+// DefaultCatalogConfig returns the configuration of the Catalog used unless configured otherwise.
+func DefaultCatalogConfig() CatalogConfig {
+	return CatalogConfig{
+		FixedPizzas:        100,
+		FixedUsers:         10,
+		FixedRatings:       10,
+		MaxPizzas:          5000,
+		MaxUsers:           5000,
+		MaxRatings:         10000,
+		MaxSessions:        10000,
+		MaxFavorites:       10000,
+		MaxIdempotencyKeys: 10000,
+		MaxLoginAttempts:   10000,
+		IdempotencyKeyTTL:  24 * time.Hour,
+		LoginMaxFailures:   5,
+		LoginMaxIPFailures: 50,
+		LoginFailureWindow: 15 * time.Minute,
+		LoginLockout:       30 * time.Second,
+		TokenTTL:           time.Hour,
+		RefreshTokenTTL:    24 * time.Hour,
+	}
+}
+
+func NewCatalog(options Options, config CatalogConfig) (*Catalog, error) {
+	db, err := initializeDB(options)
 	if err != nil {
 		return nil, err
 	}
@@ -54,14 +163,33 @@ func NewCatalog(connString string) (*Catalog, error) {
 	}
 	db.RegisterModel((*model.PizzaToIngredients)(nil))
 
+	if err := setStaffPasswords(context.Background(), db, config.DevStaffPasswords); err != nil {
+		return nil, fmt.Errorf("setting passwords of staff users: %w", err)
+	}
+
 	c := &Catalog{
 		db:           db,
-		fixedPizzas:  envInt("QUICKPIZZA_DB_FIXED_PIZZAS", 100),
-		fixedUsers:   envInt("QUICKPIZZA_DB_FIXED_USERS", 10),
-		fixedRatings: envInt("QUICKPIZZA_DB_FIXED_RATINGS", 10),
-		maxPizzas:    envInt("QUICKPIZZA_DB_MAX_PIZZAS", 5000),
-		maxUsers:     envInt("QUICKPIZZA_DB_MAX_USERS", 5000),
-		maxRatings:   envInt("QUICKPIZZA_DB_MAX_RATINGS", 10000),
+		fixedPizzas:  config.FixedPizzas,
+		fixedUsers:   config.FixedUsers,
+		fixedRatings: config.FixedRatings,
+		maxPizzas:    config.MaxPizzas,
+		maxUsers:     config.MaxUsers,
+		maxRatings:   config.MaxRatings,
+		maxSessions:  config.MaxSessions,
+		maxFavorites: config.MaxFavorites,
+		queryTimeout: options.QueryTimeout,
+
+		maxIdempotencyKeys: config.MaxIdempotencyKeys,
+		idempotencyKeyTTL:  config.IdempotencyKeyTTL,
+
+		maxLoginAttempts:   config.MaxLoginAttempts,
+		loginMaxFailures:   config.LoginMaxFailures,
+		loginMaxIPFailures: config.LoginMaxIPFailures,
+		loginFailureWindow: config.LoginFailureWindow,
+		loginLockout:       config.LoginLockout,
+
+		tokenTTL:        config.TokenTTL,
+		refreshTokenTTL: config.RefreshTokenTTL,
 	}
 
 	log.Info(
@@ -72,12 +200,92 @@ func NewCatalog(connString string) (*Catalog, error) {
 		"maxPizzas", c.maxPizzas,
 		"maxUsers", c.maxUsers,
 		"maxRatings", c.maxRatings,
+		"maxSessions", c.maxSessions,
+		"maxFavorites", c.maxFavorites,
+		"maxIdempotencyKeys", c.maxIdempotencyKeys,
+		"idempotencyKeyTTL", c.idempotencyKeyTTL,
+		"maxLoginAttempts", c.maxLoginAttempts,
+		"loginMaxFailures", c.loginMaxFailures,
+		"loginMaxIPFailures", c.loginMaxIPFailures,
+		"loginFailureWindow", c.loginFailureWindow,
+		"loginLockout", c.loginLockout,
+		"queryTimeout", c.queryTimeout,
+		"tokenTTL", c.tokenTTL,
+		"refreshTokenTTL", c.refreshTokenTTL,
 	)
 
 	return c, nil
 }
 
+// staffUsernames are the users created with the admin and kitchen roles by the migrations.
+var staffUsernames = []string{"admin", "kitchen"}
+
+// staffPasswordLength is the length of the random passwords of staff users.
+const staffPasswordLength = 20
+
+// setStaffPasswords sets the passwords of the staff users that have none, or still have the well-known one, which is
+// their username, unless dev is set. Generated passwords are logged, which only happens once per database.
+func setStaffPasswords(ctx context.Context, db *bun.DB, dev bool) error {
+	var users []model.User
+	err := db.NewSelect().Model(&users).Where("username IN (?)", bun.In(staffUsernames)).Scan(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if user.PasswordHash != "" && (dev || !password.CheckPassword(user.Username, user.PasswordHash)) {
+			continue
+		}
+
+		newPassword := user.Username
+		if !dev {
+			newPassword = util.GenerateAlphaNumToken(staffPasswordLength)
+		}
+		hash, err := password.HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+
+		// Instances sharing the database may start at the same time, so only the first one to update the password
+		// logs it.
+		res, err := db.NewUpdate().
+			Model((*model.User)(nil)).
+			Set("password_hash = ?", hash).
+			Where("id = ? AND password_hash = ?", user.ID, user.PasswordHash).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 1 && !dev {
+			slog.Info("Generated password for staff user, it will not be shown again", "username", user.Username, "password", newPassword)
+		}
+	}
+
+	return nil
+}
+
+// Ping checks that the database can be reached.
+func (c *Catalog) Ping(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	return c.db.PingContext(ctx)
+}
+
+// CheckMigrations returns an error if some of the migrations of the Catalog are not applied to the database.
+func (c *Catalog) CheckMigrations(ctx context.Context) error {
+	return checkMigrations(ctx, c.db, migrations.Catalog)
+}
+
+// Close closes the connections to the database. It must only be called once nothing uses the Catalog anymore.
+func (c *Catalog) Close() error {
+	return c.db.Close()
+}
+
 func (c *Catalog) GetIngredients(ctx context.Context, t string) ([]model.Ingredient, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	// Inject an artificial error for testing purposes
 	err := errorinjector.InjectErrors(ctx, "get-ingredients")
 	if err != nil {
@@ -90,24 +298,67 @@ func (c *Catalog) GetIngredients(ctx context.Context, t string) ([]model.Ingredi
 }
 
 func (c *Catalog) GetDoughs(ctx context.Context) ([]model.Dough, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var doughs []model.Dough
 	err := c.db.NewSelect().Model(&doughs).Scan(ctx)
 	return doughs, err
 }
 
 func (c *Catalog) GetTools(ctx context.Context) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var tools []string
 	err := c.db.NewSelect().Model(&model.Tool{}).Column("name").Scan(ctx, &tools)
 	return tools, err
 }
 
 func (c *Catalog) GetHistory(ctx context.Context, limit int) ([]model.Pizza, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var history []model.Pizza
 	err := c.db.NewSelect().Model(&history).Relation("Dough").Relation("Ingredients").Order("created_at DESC").Limit(limit).Scan(ctx)
 	return history, err
 }
 
+// GetRecommendations returns the recommended pizzas with the given IDs, in no particular order. Pizzas that do not
+// exist are left out.
+func (c *Catalog) GetRecommendations(ctx context.Context, ids []int64) ([]model.Pizza, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var pizzas []model.Pizza
+	err := c.db.NewSelect().Model(&pizzas).Relation("Dough").Relation("Ingredients").Where("pizza.id IN (?)", bun.In(ids)).Scan(ctx)
+	return pizzas, err
+}
+
+// GetLastRecommendationID returns the ID of the last recommended pizza, or zero if there is none.
+func (c *Catalog) GetLastRecommendationID(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var id int64
+	err := c.db.NewSelect().Model((*model.Pizza)(nil)).ColumnExpr("COALESCE(MAX(id), 0)").Scan(ctx, &id)
+	return id, err
+}
+
+// GetRecommendationsAfter returns up to limit pizzas recommended after the one with the given ID, oldest first.
+func (c *Catalog) GetRecommendationsAfter(ctx context.Context, id int64, limit int) ([]model.Pizza, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var pizzas []model.Pizza
+	err := c.db.NewSelect().Model(&pizzas).Relation("Dough").Relation("Ingredients").Where("pizza.id > ?", id).Order("pizza.id ASC").Limit(limit).Scan(ctx)
+	return pizzas, err
+}
+
 func (c *Catalog) GetRecommendation(ctx context.Context, id int) (*model.Pizza, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var pizza model.Pizza
 	err := c.db.NewSelect().Model(&pizza).Relation("Dough").Relation("Ingredients").Where("pizza.id = ?", id).Limit(1).Scan(ctx)
 	if err == sql.ErrNoRows {
@@ -117,6 +368,9 @@ func (c *Catalog) GetRecommendation(ctx context.Context, id int) (*model.Pizza,
 }
 
 func (c *Catalog) GetRatings(ctx context.Context, user *model.User) ([]*model.Rating, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	ratings := make([]*model.Rating, 0)
 	err := c.db.NewSelect().Model((*model.Rating)(nil)).Relation("User").Relation("Pizza").Where("rating.user_id = ?", user.ID).Limit(getRatingsMax).Scan(ctx, &ratings)
 	if err == sql.ErrNoRows {
@@ -126,6 +380,9 @@ func (c *Catalog) GetRatings(ctx context.Context, user *model.User) ([]*model.Ra
 }
 
 func (c *Catalog) GetRating(ctx context.Context, user *model.User, ratingID int) (*model.Rating, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var rating model.Rating
 	err := c.db.NewSelect().Model(&rating).Relation("User").Relation("Pizza").Where("rating.id = ? AND rating.user_id = ?", ratingID, user.ID).Limit(1).Scan(ctx)
 	if err == sql.ErrNoRows {
@@ -135,6 +392,9 @@ func (c *Catalog) GetRating(ctx context.Context, user *model.User, ratingID int)
 }
 
 func (c *Catalog) DeleteRatings(ctx context.Context, user *model.User) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	if user.IsGlobal() {
 		return ErrGlobalOperationNotPermitted
 	}
@@ -143,7 +403,12 @@ func (c *Catalog) DeleteRatings(ctx context.Context, user *model.User) error {
 	return err
 }
 
-func (c *Catalog) DeleteRating(ctx context.Context, user *model.User, ratingID int) error {
+// DeleteRating deletes a rating of user. If ifVersion is not 0, the rating is only deleted if it still has that version,
+// and ErrRatingVersionMismatch is returned otherwise.
+func (c *Catalog) DeleteRating(ctx context.Context, user *model.User, ratingID int, ifVersion int64) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	if user.Username == model.GlobalUsername {
 		return ErrGlobalOperationNotPermitted
 	}
@@ -152,14 +417,33 @@ func (c *Catalog) DeleteRating(ctx context.Context, user *model.User, ratingID i
 	if err != nil {
 		return err
 	} else if rating == nil {
-		return fmt.Errorf("rating ID %v not found", ratingID)
+		return ErrRatingNotFound
 	}
 
-	_, err = c.db.NewDelete().Model(rating).WherePK().Exec(ctx)
-	return err
+	q := c.db.NewDelete().Model(rating).WherePK()
+	if ifVersion != 0 {
+		q = q.Where("version = ?", ifVersion)
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRatingVersionMismatch
+	}
+
+	return nil
 }
 
-func (c *Catalog) UpdateRating(ctx context.Context, user *model.User, rating *model.Rating) (*model.Rating, error) {
+// UpdateRating changes the stars, review and moderation state of a rating of user, and increments its version. If
+// ifVersion is not 0, the rating is only updated if it still has that version, and ErrRatingVersionMismatch is returned
+// otherwise.
+func (c *Catalog) UpdateRating(ctx context.Context, user *model.User, rating *model.Rating, ifVersion int64) (*model.Rating, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	if user.IsGlobal() {
 		return nil, ErrGlobalOperationNotPermitted
 	}
@@ -170,13 +454,31 @@ func (c *Catalog) UpdateRating(ctx context.Context, user *model.User, rating *mo
 	}
 
 	if existing == nil || existing.UserID != user.ID {
-		return nil, fmt.Errorf("rating ID %v not found", rating.ID)
+		return nil, ErrRatingNotFound
 	}
 
-	existing.Stars = rating.Stars
 	err = c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		_, err := tx.NewUpdate().Model(existing).Column("stars").WherePK().Exec(ctx)
-		return err
+		q := tx.NewUpdate().
+			Model(existing).
+			Set("stars = ?", rating.Stars).
+			Set("review = ?", rating.Review).
+			Set("status = ?", rating.Status).
+			Set("version = version + 1").
+			WherePK()
+		if ifVersion != 0 {
+			q = q.Where("version = ?", ifVersion)
+		}
+
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrRatingVersionMismatch
+		}
+
+		return tx.NewSelect().Model(existing).WherePK().Scan(ctx)
 	})
 
 	if err != nil {
@@ -186,7 +488,12 @@ func (c *Catalog) UpdateRating(ctx context.Context, user *model.User, rating *mo
 	return existing, nil
 }
 
-func (c *Catalog) RecordRating(ctx context.Context, rating *model.Rating) error {
+// RecordRating stores a new rating of user. Users can rate every pizza only once, except for the default user, which
+// is shared by all clients using an unknown token.
+func (c *Catalog) RecordRating(ctx context.Context, user *model.User, rating *model.Rating) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	pizza, err := c.GetRecommendation(ctx, int(rating.PizzaID))
 	if err != nil {
 		return err
@@ -197,8 +504,25 @@ func (c *Catalog) RecordRating(ctx context.Context, rating *model.Rating) error
 	}
 
 	rating.ID = 0
+	rating.UserID = user.ID
+	rating.Version = 1
 
 	return c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if !user.IsGlobal() {
+			var existingID int64
+			err := tx.NewSelect().
+				Model((*model.Rating)(nil)).
+				Column("id").
+				Where("user_id = ? AND pizza_id = ?", user.ID, rating.PizzaID).
+				Limit(1).
+				Scan(ctx, &existingID)
+			if err == nil {
+				return &RatingExistsError{RatingID: existingID}
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
 		_, err := tx.NewInsert().Model(rating).Exec(ctx)
 		if err != nil {
 			return err
@@ -208,7 +532,50 @@ func (c *Catalog) RecordRating(ctx context.Context, rating *model.Rating) error
 	})
 }
 
+// GetRatingsByStatus returns the oldest ratings in the given moderation state, e.g. to find the ones waiting for an
+// admin.
+func (c *Catalog) GetRatingsByStatus(ctx context.Context, status string, limit int) ([]*model.Rating, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	ratings := make([]*model.Rating, 0)
+	err := c.db.NewSelect().
+		Model(&ratings).
+		Where("status = ?", status).
+		Order("id ASC").
+		Limit(limit).
+		Scan(ctx)
+	return ratings, err
+}
+
+// SetRatingStatus moderates a rating, regardless of who it belongs to.
+func (c *Catalog) SetRatingStatus(ctx context.Context, ratingID int64, status string) (*model.Rating, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	res, err := c.db.NewUpdate().
+		Model((*model.Rating)(nil)).
+		Set("status = ?", status).
+		Set("version = version + 1").
+		Where("id = ?", ratingID).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrRatingNotFound
+	}
+
+	var rating model.Rating
+	err = c.db.NewSelect().Model(&rating).Where("id = ?", ratingID).Scan(ctx)
+	return &rating, err
+}
+
 func (c *Catalog) RecordUser(ctx context.Context, user *model.User) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	passwordHash, err := password.HashPassword(user.Password)
 	if err != nil {
 		return err
@@ -217,6 +584,7 @@ func (c *Catalog) RecordUser(ctx context.Context, user *model.User) error {
 	user.PasswordHash = passwordHash
 	user.Token = util.GenerateAlphaNumToken(model.UserTokenLength)
 	user.ID = 0
+	user.Role = model.RoleUser
 
 	var tmp model.User
 	err = c.db.NewSelect().Model(&tmp).Where("username = ?", user.Username).Limit(1).Scan(ctx)
@@ -237,47 +605,575 @@ func (c *Catalog) RecordUser(ctx context.Context, user *model.User) error {
 	})
 }
 
-func (c *Catalog) LoginUser(ctx context.Context, username, passwordText string) (*model.User, error) {
+// LoginUser returns the user with the given username and password, or nil if they do not match. Every attempt is
+// recorded in the login_attempts table along with the IP address of the client. After too many failures for the same
+// account or from the same IP address within the failure window, further attempts are rejected with a LockoutError,
+// for a period that doubles with every additional failure.
+func (c *Catalog) LoginUser(ctx context.Context, username, passwordText, ip string) (*model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	// Keep the audit log bounded, even if clients send huge usernames.
+	if len(username) > 2*model.MaxUserNameLength {
+		username = username[:2*model.MaxUserNameLength]
+	}
+
+	now := time.Now()
+	lockout, err := c.checkLockout(ctx, now, username, ip)
+	if err != nil {
+		return nil, err
+	}
+	if lockout != nil {
+		if err := c.recordLoginAttempt(ctx, now, username, ip, model.LoginLocked); err != nil {
+			return nil, err
+		}
+		return nil, lockout
+	}
+
 	var user model.User
-	err := c.db.NewSelect().Model(&user).Where("username = ?", username).Limit(1).Scan(ctx)
+	err = c.db.NewSelect().Model(&user).Where("username = ?", username).Limit(1).Scan(ctx)
 	if err == sql.ErrNoRows {
-		return nil, nil
+		password.CheckPassword(passwordText, dummyPasswordHash())
+		return nil, c.recordLoginAttempt(ctx, now, username, ip, model.LoginFailed)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Any password works for logging in as the default, global user.
+	if !user.IsGlobal() && !password.CheckPassword(passwordText, user.PasswordHash) {
+		return nil, c.recordLoginAttempt(ctx, now, username, ip, model.LoginFailed)
+	}
+
+	if err := c.recordLoginAttempt(ctx, now, username, ip, model.LoginSucceeded); err != nil {
+		return nil, err
 	}
+	return &user, nil
+}
 
-	// Some pre-created users have their password stored as plaintext.
-	if user.PasswordPlaintext != "" {
-		if passwordText == user.PasswordPlaintext {
-			return &user, nil
+// checkLockout returns a LockoutError if the account or the IP address are locked out at the time now.
+func (c *Catalog) checkLockout(ctx context.Context, now time.Time, username, ip string) (*LockoutError, error) {
+	windowStart := now.Add(-c.loginFailureWindow)
+
+	if c.loginMaxIPFailures > 0 {
+		failures, last, err := c.countLoginFailures(ctx, "ip = ?", ip, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		if retryAfter := c.lockoutLeft(now, failures, last, c.loginMaxIPFailures); retryAfter > 0 {
+			return &LockoutError{Scope: "ip", RetryAfter: retryAfter}, nil
 		}
-		return nil, nil
 	}
 
-	// Any password works for logging in as the default, global user.
-	if user.IsGlobal() || password.CheckPassword(passwordText, user.PasswordHash) {
-		return &user, nil
+	if c.loginMaxFailures > 0 {
+		// A successful login resets the count of failures of the account.
+		var lastSuccess time.Time
+		err := c.db.NewSelect().
+			Model((*model.LoginAttempt)(nil)).
+			ColumnExpr("created_at").
+			Where("username = ? AND result = ? AND created_at > ?", username, model.LoginSucceeded, windowStart).
+			Order("created_at DESC").
+			Limit(1).
+			Scan(ctx, &lastSuccess)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if lastSuccess.After(windowStart) {
+			windowStart = lastSuccess
+		}
+
+		failures, last, err := c.countLoginFailures(ctx, "username = ?", username, windowStart)
+		if err != nil {
+			return nil, err
+		}
+		if retryAfter := c.lockoutLeft(now, failures, last, c.loginMaxFailures); retryAfter > 0 {
+			return &LockoutError{Scope: "account", RetryAfter: retryAfter}, nil
+		}
 	}
+
 	return nil, nil
 }
 
+// countLoginFailures returns the number of failed login attempts matching where since the given time, along with the
+// time of the latest one.
+func (c *Catalog) countLoginFailures(ctx context.Context, where string, arg any, since time.Time) (int, time.Time, error) {
+	var attempts []model.LoginAttempt
+	err := c.db.NewSelect().
+		Model(&attempts).
+		Column("created_at").
+		Where(where, arg).
+		Where("result = ? AND created_at > ?", model.LoginFailed, since).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil || len(attempts) == 0 {
+		return 0, time.Time{}, err
+	}
+	return len(attempts), attempts[0].CreatedAt, nil
+}
+
+// lockoutLeft returns how long logins stay locked out after the given number of failures, the latest of which
+// happened at last. Once maxFailures is reached, the lockout period starts at loginLockout and doubles with every
+// further failure, up to the failure window.
+func (c *Catalog) lockoutLeft(now time.Time, failures int, last time.Time, maxFailures int) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+
+	lockout := c.loginLockout
+	for i := maxFailures; i < failures && lockout < c.loginFailureWindow; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, c.loginFailureWindow)
+
+	return last.Add(lockout).Sub(now)
+}
+
+func (c *Catalog) recordLoginAttempt(ctx context.Context, now time.Time, username, ip, result string) error {
+	attempt := &model.LoginAttempt{Username: username, IP: ip, Result: result, CreatedAt: now}
+
+	return c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(attempt).Exec(ctx); err != nil {
+			return err
+		}
+
+		return c.enforceTableSizeLimits(ctx, tx, (*model.LoginAttempt)(nil), 0, c.maxLoginAttempts)
+	})
+}
+
+// GetLoginAttempts returns the latest login attempts, optionally only those for username or from ip.
+func (c *Catalog) GetLoginAttempts(ctx context.Context, username, ip string, limit int) ([]model.LoginAttempt, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	attempts := []model.LoginAttempt{}
+	q := c.db.NewSelect().Model(&attempts).Order("id DESC").Limit(limit)
+	if username != "" {
+		q = q.Where("username = ?", username)
+	}
+	if ip != "" {
+		q = q.Where("ip = ?", ip)
+	}
+
+	err := q.Scan(ctx)
+	return attempts, err
+}
+
+// UpdateUsername changes the username of user.
+func (c *Catalog) UpdateUsername(ctx context.Context, user *model.User, username string) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	if user.IsGlobal() {
+		return ErrGlobalOperationNotPermitted
+	}
+
+	exists, err := c.db.NewSelect().Model((*model.User)(nil)).Where("username = ? AND id != ?", username, user.ID).Exists(ctx)
+	if err != nil {
+		return err
+	} else if exists {
+		return ErrUsernameTaken
+	}
+
+	_, err = c.db.NewUpdate().Model((*model.User)(nil)).Set("username = ?", username).Where("id = ?", user.ID).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	user.Username = username
+	return nil
+}
+
+// UpdatePassword changes the password of user. As the old password may have been compromised, the static token of the
+// user is replaced and all of its sessions are revoked.
+func (c *Catalog) UpdatePassword(ctx context.Context, user *model.User, passwordText string) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	if user.IsGlobal() {
+		return ErrGlobalOperationNotPermitted
+	}
+
+	passwordHash, err := password.HashPassword(passwordText)
+	if err != nil {
+		return err
+	}
+
+	return c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*model.User)(nil)).
+			Set("password_hash = ?", passwordHash).
+			Set("password_plaintext = ''").
+			Set("token = ?", util.GenerateAlphaNumToken(model.UserTokenLength)).
+			Where("id = ?", user.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*model.Session)(nil)).
+			Set("revoked_at = ?", time.Now()).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Exec(ctx)
+		return err
+	})
+}
+
+// DeleteUser deletes user, along with its ratings and sessions.
+func (c *Catalog) DeleteUser(ctx context.Context, user *model.User) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	if user.IsGlobal() {
+		return ErrGlobalOperationNotPermitted
+	}
+
+	// Ratings and sessions are deleted by the database, as their foreign keys cascade.
+	_, err := c.db.NewDelete().Model((*model.User)(nil)).Where("id = ?", user.ID).Exec(ctx)
+	return err
+}
+
+// GetUser returns the user with the given ID.
+func (c *Catalog) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var user model.User
+	err := c.db.NewSelect().Model(&user).Where("id = ?", id).Limit(1).Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // Authenticate finds the corresponding user for token.
+// Tokens of sessions created by CreateSession are only valid until they expire or are revoked, in which case
+// ErrTokenExpired or ErrTokenRevoked are returned. Pre-created users also have a static token that never expires.
 // If a user is not found, then a default user is returned, with ID 1. This is done
 // in order to simplify the testing/usage of QuickPizza in general. This function
 // will always return a user, unless it returns a non-nil error.
 func (c *Catalog) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	user, err := c.AuthenticateStrict(ctx, token)
+	if err != ErrTokenNotFound {
+		return user, err
+	}
+
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	// In order to support requests coming directly from the
+	// index.html (which contains a randomly-generated token not
+	// stored in the DB), return a global, default user if the
+	// token lookup failed.
+	user = &model.User{}
+	err = c.db.NewSelect().Model(user).Where("id = 1").Limit(1).Scan(ctx)
+	return user, err
+}
+
+// AuthenticateStrict is like Authenticate, but it returns ErrTokenNotFound instead of the default user for unknown
+// tokens. It is used where an unknown token must not grant any access, like admin sessions.
+func (c *Catalog) AuthenticateStrict(ctx context.Context, token string) (*model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var session model.Session
+	err := c.db.NewSelect().Model(&session).Relation("User").Where("session.token = ?", token).Limit(1).Scan(ctx)
+	if err == nil {
+		return sessionUser(&session)
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
 	var user model.User
-	err := c.db.NewSelect().Model(&user).Where("token = ?", token).Limit(1).Scan(ctx)
+	err = c.db.NewSelect().Model(&user).Where("token = ?", token).Limit(1).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AuthenticateSession returns the user of the session with the given ID, as long as the session has not expired nor
+// been revoked. It is used to authenticate tokens that reference a session, like JWTs.
+func (c *Catalog) AuthenticateSession(ctx context.Context, sessionID int64) (*model.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
 
+	var session model.Session
+	err := c.db.NewSelect().Model(&session).Relation("User").Where("session.id = ?", sessionID).Limit(1).Scan(ctx)
 	if err == sql.ErrNoRows {
-		// In order to support requests coming directly from the
-		// index.html (which contains a randomly-generated token not
-		// stored in the DB), return a global, default user if the
-		// token lookup failed.
-		err = c.db.NewSelect().Model(&user).Where("id = 1").Limit(1).Scan(ctx)
+		return nil, ErrTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sessionUser(&session)
+}
+
+// sessionUser returns the user of session, or an error if the session cannot be used anymore.
+func sessionUser(session *model.Session) (*model.User, error) {
+	switch {
+	case session.IsRevoked() || session.User == nil || session.User.ID == 0:
+		return nil, ErrTokenRevoked
+	case time.Now().After(session.ExpiresAt):
+		return nil, ErrTokenExpired
+	}
+	return session.User, nil
+}
+
+// CreateSession starts a new session for user, with a fresh token and refresh token.
+func (c *Catalog) CreateSession(ctx context.Context, user *model.User) (*model.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	session := c.newSession(user.ID)
+	err := c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(session).Exec(ctx); err != nil {
+			return err
+		}
+
+		return c.pruneSessions(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.User = user
+	return session, nil
+}
+
+// RefreshSession exchanges refreshToken for a new session of the same user. Refresh tokens can only be used once:
+// the session they belong to is revoked, so that both its token and its refresh token stop working.
+func (c *Catalog) RefreshSession(ctx context.Context, refreshToken string) (*model.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var session *model.Session
+	err := c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var old model.Session
+		err := tx.NewSelect().Model(&old).Relation("User").Where("session.refresh_token = ?", refreshToken).Limit(1).Scan(ctx)
+		switch {
+		case err == sql.ErrNoRows:
+			return ErrTokenNotFound
+		case err != nil:
+			return err
+		case old.IsRevoked() || old.User == nil || old.User.ID == 0:
+			return ErrTokenRevoked
+		case time.Now().After(old.RefreshExpiresAt):
+			return ErrTokenExpired
+		}
+
+		// Only the first of several concurrent refreshes with the same token succeeds.
+		res, err := tx.NewUpdate().
+			Model((*model.Session)(nil)).
+			Set("revoked_at = ?", time.Now()).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrTokenRevoked
+		}
+
+		session = c.newSession(old.UserID)
+		if _, err := tx.NewInsert().Model(session).Exec(ctx); err != nil {
+			return err
+		}
+		session.User = old.User
+
+		return c.pruneSessions(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RevokeSession revokes the session token belongs to or, if all is true, every session of the same user.
+// Tokens that do not belong to any session, such as static tokens, are ignored. As the default user is shared,
+// revoking all of its sessions is not permitted.
+func (c *Catalog) RevokeSession(ctx context.Context, token string, all bool) error {
+	return c.revokeSession(ctx, "session.token = ?", token, all)
+}
+
+// RevokeSessionByID is like RevokeSession, but finds the session by its ID.
+func (c *Catalog) RevokeSessionByID(ctx context.Context, sessionID int64, all bool) error {
+	return c.revokeSession(ctx, "session.id = ?", sessionID, all)
+}
+
+func (c *Catalog) revokeSession(ctx context.Context, where string, arg any, all bool) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var session model.Session
+	err := c.db.NewSelect().Model(&session).Relation("User").Where(where, arg).Limit(1).Scan(ctx)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if all && session.User != nil && session.User.IsGlobal() {
+		return ErrGlobalOperationNotPermitted
+	}
+
+	q := c.db.NewUpdate().
+		Model((*model.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("revoked_at IS NULL")
+	if all {
+		q = q.Where("user_id = ?", session.UserID)
+	} else {
+		q = q.Where("id = ?", session.ID)
+	}
+
+	_, err = q.Exec(ctx)
+	return err
+}
+
+// newSession returns a session for the given user, that expires according to the configured TTLs.
+func (c *Catalog) newSession(userID int64) *model.Session {
+	now := time.Now()
+	return &model.Session{
+		UserID:           userID,
+		Token:            util.GenerateAlphaNumToken(model.UserTokenLength),
+		RefreshToken:     util.GenerateAlphaNumToken(model.RefreshTokenLength),
+		ExpiresAt:        now.Add(c.tokenTTL),
+		RefreshExpiresAt: now.Add(c.refreshTokenTTL),
+	}
+}
+
+// pruneSessions deletes sessions that cannot be refreshed anymore, and keeps the table below its maximum size.
+func (c *Catalog) pruneSessions(ctx context.Context, tx bun.Tx) error {
+	_, err := tx.NewDelete().
+		Model((*model.Session)(nil)).
+		Where("refresh_expires_at < ?", time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return err
 	}
-	return &user, err
+
+	return c.enforceTableSizeLimits(ctx, tx, (*model.Session)(nil), 0, c.maxSessions)
+}
+
+// AddFavorite saves the pizza with the given ID as a favorite of user. It returns the favorite, and whether it was
+// added or it was a favorite already.
+func (c *Catalog) AddFavorite(ctx context.Context, user *model.User, pizzaID int64) (*model.Favorite, bool, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	if user.IsGlobal() {
+		return nil, false, ErrGlobalOperationNotPermitted
+	}
+
+	pizza, err := c.GetRecommendation(ctx, int(pizzaID))
+	if err != nil {
+		return nil, false, err
+	} else if pizza == nil {
+		return nil, false, ErrPizzaNotFound
+	}
+
+	favorite := &model.Favorite{UserID: user.ID, PizzaID: pizzaID, CreatedAt: time.Now()}
+	var added bool
+	err = c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().Model(favorite).On("CONFLICT (user_id, pizza_id) DO NOTHING").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return tx.NewSelect().Model(favorite).Where("user_id = ? AND pizza_id = ?", user.ID, pizzaID).Scan(ctx)
+		}
+
+		added = true
+		return c.enforceTableSizeLimits(ctx, tx, (*model.Favorite)(nil), 0, c.maxFavorites)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	favorite.Pizza = pizza
+	return favorite, added, nil
+}
+
+// RemoveFavorite removes the pizza with the given ID from the favorites of user.
+func (c *Catalog) RemoveFavorite(ctx context.Context, user *model.User, pizzaID int64) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	if user.IsGlobal() {
+		return ErrGlobalOperationNotPermitted
+	}
+
+	res, err := c.db.NewDelete().
+		Model((*model.Favorite)(nil)).
+		Where("user_id = ? AND pizza_id = ?", user.ID, pizzaID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrFavoriteNotFound
+	}
+	return nil
+}
+
+// GetFavorites returns a page of the favorites of user, newest first, along with their total number.
+func (c *Catalog) GetFavorites(ctx context.Context, user *model.User, offset, limit int) ([]*model.Favorite, int, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	favorites := make([]*model.Favorite, 0)
+	total, err := c.db.NewSelect().
+		Model(&favorites).
+		Where("user_id = ?", user.ID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(favorites) == 0 {
+		return favorites, total, nil
+	}
+
+	ids := make([]int64, 0, len(favorites))
+	for _, f := range favorites {
+		ids = append(ids, f.PizzaID)
+	}
+
+	var pizzas []model.Pizza
+	err = c.db.NewSelect().Model(&pizzas).Relation("Dough").Relation("Ingredients").Where("pizza.id IN (?)", bun.In(ids)).Scan(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[int64]*model.Pizza, len(pizzas))
+	for i := range pizzas {
+		byID[pizzas[i].ID] = &pizzas[i]
+	}
+	for _, f := range favorites {
+		f.Pizza = byID[f.PizzaID]
+	}
+
+	return favorites, total, nil
 }
 
 func (c *Catalog) RecordRecommendation(ctx context.Context, pizza *model.Pizza) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	// Inject an artificial error for testing purposes
 	err := errorinjector.InjectErrors(ctx, "record-recommendation")
 	if err != nil {
@@ -297,44 +1193,102 @@ func (c *Catalog) RecordRecommendation(ctx context.Context, pizza *model.Pizza)
 			}
 		}
 
-		return c.enforceTableSizeLimits(ctx, tx, (*model.Pizza)(nil), c.fixedPizzas, c.maxPizzas)
+		// Favorite pizzas are never pruned, so that users do not lose them.
+		favorites := tx.NewSelect().Model((*model.Favorite)(nil)).Column("pizza_id")
+		return c.enforceTableSizeLimits(ctx, tx, (*model.Pizza)(nil), c.fixedPizzas, c.maxPizzas, favorites)
+	})
+}
+
+// ReserveIdempotencyKey stores key, unless the user already used it, in which case the existing one is returned
+// instead. Reserved keys expire after the configured TTL, and are completed with CompleteIdempotencyKey once the
+// response is known.
+func (c *Catalog) ReserveIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	key.ID = 0
+	key.StatusCode = 0
+	key.CreatedAt = now
+	key.ExpiresAt = now.Add(c.idempotencyKeyTTL)
+
+	var existing *model.IdempotencyKey
+	err := c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		// Expired keys are deleted first, so that they can be used again.
+		_, err := tx.NewDelete().Model((*model.IdempotencyKey)(nil)).Where("expires_at < ?", now).Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewInsert().Model(key).On("CONFLICT (user_id, key) DO NOTHING").Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			existing = &model.IdempotencyKey{}
+			return tx.NewSelect().Model(existing).Where("user_id = ? AND key = ?", key.UserID, key.Key).Scan(ctx)
+		}
+
+		return c.enforceTableSizeLimits(ctx, tx, (*model.IdempotencyKey)(nil), 0, c.maxIdempotencyKeys)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request of a reserved key.
+func (c *Catalog) CompleteIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	_, err := c.db.NewUpdate().
+		Model(key).
+		Column("status_code", "content_type", "location", "etag", "body").
+		Where("user_id = ? AND key = ? AND fingerprint = ?", key.UserID, key.Key, key.Fingerprint).
+		Exec(ctx)
+	return err
+}
+
+// ReleaseIdempotencyKey deletes a reserved key that was not completed, e.g. because the request failed, so that it can
+// be retried.
+func (c *Catalog) ReleaseIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	_, err := c.db.NewDelete().
+		Model((*model.IdempotencyKey)(nil)).
+		Where("user_id = ? AND key = ? AND fingerprint = ? AND status_code = 0", key.UserID, key.Key, key.Fingerprint).
+		Exec(ctx)
+	return err
 }
 
 // enforceTableSizeLimits limits the size of a table, which must have an ID row.
 // All rows will be deleted except the N newest ones, where N == maximum.
 // If fixed > 0, then the first K rows (IDs 0, 1, 2...) will never be deleted,
 // where K == fixed (even if this would make the table exceed N rows).
+// Rows whose IDs are returned by any of the keep queries are not deleted either.
 // If maximum is 0 or negative, then do not enforce any limits.
 // Useful for keeping an in-memory SQLite database size below a certain number.
 // Note: We use ORDER BY id DESC instead of created_at because not all models
 // have a created_at column (e.g., Rating, User). Since IDs are auto-incrementing,
 // ordering by id achieves the same result of keeping the newest records.
-func (c *Catalog) enforceTableSizeLimits(ctx context.Context, tx bun.Tx, model any, fixed, maximum int) error {
+func (c *Catalog) enforceTableSizeLimits(ctx context.Context, tx bun.Tx, model any, fixed, maximum int, keep ...*bun.SelectQuery) error {
 	if maximum <= 0 {
 		return nil
 	}
-	_, err := tx.NewDelete().
+	q := tx.NewDelete().
 		Model(model).
 		Where(fmt.Sprintf("id NOT IN (?) AND id > %v", fixed), tx.NewSelect().
 			Model(model).
 			Order("id DESC").
 			Column("id").
-			Limit(maximum)).
-		Exec(ctx)
-	return err
-}
-
-func envInt(name string, defaultVal int) int {
-	v, found := os.LookupEnv(name)
-	if !found {
-		return defaultVal
-	}
-
-	b, err := strconv.Atoi(v)
-	if err != nil {
-		return defaultVal
+			Limit(maximum))
+	for _, k := range keep {
+		q = q.Where("id NOT IN (?)", k)
 	}
-
-	return b
+	_, err := q.Exec(ctx)
+	return err
 }
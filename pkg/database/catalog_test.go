@@ -0,0 +1,236 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/password"
+)
+
+// newTestCatalog returns a Catalog backed by a SQLite database of its own, which is deleted when the test ends.
+func newTestCatalog(t *testing.T, modify func(c *CatalogConfig)) *Catalog {
+	t.Helper()
+
+	config := DefaultCatalogConfig()
+	config.DevStaffPasswords = true
+	modify(&config)
+
+	c, err := NewCatalog(Options{ConnString: filepath.Join(t.TempDir(), "catalog.db")}, config)
+	if err != nil {
+		t.Fatalf("creating catalog: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestLockoutLeft(t *testing.T) {
+	t.Parallel()
+
+	c := &Catalog{loginLockout: 30 * time.Second, loginFailureWindow: 15 * time.Minute}
+	now := time.Unix(1000, 0)
+
+	for _, tc := range []struct {
+		name     string
+		failures int
+		last     time.Time
+		want     time.Duration
+	}{
+		{name: "below the limit", failures: 4, last: now, want: 0},
+		{name: "at the limit", failures: 5, last: now, want: 30 * time.Second},
+		{name: "doubles with every further failure", failures: 7, last: now, want: 2 * time.Minute},
+		{name: "capped at the window", failures: 20, last: now, want: 15 * time.Minute},
+		{name: "counts from the last failure", failures: 5, last: now.Add(-10 * time.Second), want: 20 * time.Second},
+		{name: "expired", failures: 5, last: now.Add(-time.Minute), want: -30 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := c.lockoutLeft(now, tc.failures, tc.last, 5); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+// login is an attempt to log in, and its expected outcome.
+type login struct {
+	username string
+	password string
+	ip       string
+	// lockout is the scope of the expected LockoutError, or empty if the attempt is not locked out.
+	lockout string
+	ok      bool
+}
+
+func TestLoginUserLockout(t *testing.T) {
+	t.Parallel()
+
+	const (
+		alice = "alice"
+		bob   = "bob"
+		pass  = "correct-horse"
+		wrong = "wrong-password"
+		ip1   = "192.0.2.1"
+		ip2   = "192.0.2.2"
+	)
+
+	for _, tc := range []struct {
+		name   string
+		config func(c *CatalogConfig)
+		logins []login
+	}{
+		{
+			name:   "account is locked out after too many failures",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 3 },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip2},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip2, lockout: "account"},
+				{username: bob, password: pass, ip: ip1, ok: true},
+			},
+		},
+		{
+			name:   "successful login resets the count of the account",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 3 },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip1, ok: true},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip1, ok: true},
+			},
+		},
+		{
+			name:   "IP address is locked out across accounts",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 0; c.LoginMaxIPFailures = 3 },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: bob, password: wrong, ip: ip1},
+				{username: "nobody", password: wrong, ip: ip1},
+				{username: bob, password: pass, ip: ip1, lockout: "ip"},
+				{username: bob, password: pass, ip: ip2, ok: true},
+			},
+		},
+		{
+			name:   "successful logins do not reset the count of the IP address",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 0; c.LoginMaxIPFailures = 2 },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip1, ok: true},
+				{username: bob, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip1, lockout: "ip"},
+			},
+		},
+		{
+			name:   "locked out attempts do not count as failures",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 1; c.LoginLockout = time.Hour },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip1, lockout: "account"},
+				{username: alice, password: wrong, ip: ip1, lockout: "account"},
+			},
+		},
+		{
+			name:   "disabled",
+			config: func(c *CatalogConfig) { c.LoginMaxFailures = 0; c.LoginMaxIPFailures = 0 },
+			logins: []login{
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: wrong, ip: ip1},
+				{username: alice, password: pass, ip: ip1, ok: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestCatalog(t, tc.config)
+			ctx := context.Background()
+			for _, username := range []string{alice, bob} {
+				if err := c.RecordUser(ctx, &model.User{Username: username, Password: pass}); err != nil {
+					t.Fatalf("creating user: %v", err)
+				}
+			}
+
+			for i, l := range tc.logins {
+				user, err := c.LoginUser(ctx, l.username, l.password, l.ip)
+
+				var lockout *LockoutError
+				switch {
+				case l.lockout != "":
+					if !errors.As(err, &lockout) || lockout.Scope != l.lockout || lockout.RetryAfter <= 0 {
+						t.Fatalf("login %d: expected a lockout of the %s, got %v", i, l.lockout, err)
+					}
+				case err != nil:
+					t.Fatalf("login %d: unexpected error: %v", i, err)
+				case l.ok != (user != nil):
+					t.Fatalf("login %d: expected success to be %t, got user %v", i, l.ok, user)
+				}
+			}
+
+			attempts, err := c.GetLoginAttempts(ctx, "", "", 100)
+			if err != nil {
+				t.Fatalf("getting login attempts: %v", err)
+			}
+			if len(attempts) != len(tc.logins) {
+				t.Fatalf("expected %d recorded attempts, got %d", len(tc.logins), len(attempts))
+			}
+		})
+	}
+}
+
+func TestSetStaffPasswords(t *testing.T) {
+	t.Parallel()
+
+	c := newTestCatalog(t, func(c *CatalogConfig) { c.DevStaffPasswords = false })
+	ctx := context.Background()
+
+	staffPassword := func(username string) string {
+		var user model.User
+		if err := c.db.NewSelect().Model(&user).Where("username = ?", username).Scan(ctx); err != nil {
+			t.Fatalf("getting %s: %v", username, err)
+		}
+		return user.PasswordHash
+	}
+
+	// Staff users of new databases get random passwords.
+	for _, username := range staffUsernames {
+		hash := staffPassword(username)
+		if hash == "" || password.CheckPassword(username, hash) {
+			t.Fatalf("expected a random password for %s", username)
+		}
+	}
+
+	// Random passwords are kept, even in development.
+	hash := staffPassword("admin")
+	if err := setStaffPasswords(ctx, c.db, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if staffPassword("admin") != hash {
+		t.Fatal("expected the random password to be kept")
+	}
+
+	// Well-known passwords are replaced, unless in development.
+	wellKnown, _ := password.HashPassword("admin")
+	if _, err := c.db.NewUpdate().Model((*model.User)(nil)).Set("password_hash = ?", wellKnown).Where("username = 'admin'").Exec(ctx); err != nil {
+		t.Fatalf("setting password: %v", err)
+	}
+	if err := setStaffPasswords(ctx, c.db, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if staffPassword("admin") != wellKnown {
+		t.Fatal("expected the well-known password to be kept in development")
+	}
+	if err := setStaffPasswords(ctx, c.db, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if password.CheckPassword("admin", staffPassword("admin")) {
+		t.Fatal("expected the well-known password to be replaced")
+	}
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"log/slog"
 
@@ -14,10 +15,12 @@ import (
 
 type Copy struct {
 	db *bun.DB
+
+	queryTimeout time.Duration
 }
 
-func NewCopy(connString string) (*Copy, error) {
-	db, err := initializeDB(connString)
+func NewCopy(options Options) (*Copy, error) {
+	db, err := initializeDB(options)
 	if err != nil {
 		return nil, err
 	}
@@ -33,24 +36,61 @@ func NewCopy(connString string) (*Copy, error) {
 		return nil, err
 	}
 	return &Copy{
-		db: db,
+		db:           db,
+		queryTimeout: options.QueryTimeout,
 	}, nil
 }
 
+// Ping checks that the database can be reached.
+func (c *Copy) Ping(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	return c.db.PingContext(ctx)
+}
+
+// CheckMigrations returns an error if some of the migrations of the Copy are not applied to the database.
+func (c *Copy) CheckMigrations(ctx context.Context) error {
+	return checkMigrations(ctx, c.db, migrations.Copy)
+}
+
+// Close closes the connections to the database. It must only be called once nothing uses the Copy anymore.
+func (c *Copy) Close() error {
+	return c.db.Close()
+}
+
 func (c *Copy) GetQuotes(ctx context.Context) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var quotes []string
 	err := c.db.NewSelect().Model(&model.Quote{}).Column("name").Scan(ctx, &quotes)
 	return quotes, err
 }
 
 func (c *Copy) GetAdjectives(ctx context.Context) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var adjectives []string
 	err := c.db.NewSelect().Model(&model.Adjective{}).Column("name").Scan(ctx, &adjectives)
 	return adjectives, err
 }
 
 func (c *Copy) GetClassicalNames(ctx context.Context) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
 	var classicalNames []string
 	err := c.db.NewSelect().Model(&model.ClassicalName{}).Column("name").Scan(ctx, &classicalNames)
 	return classicalNames, err
 }
+
+func (c *Copy) GetBannedWords(ctx context.Context) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	var words []string
+	err := c.db.NewSelect().Model(&model.BannedWord{}).Column("name").Scan(ctx, &words)
+	return words, err
+}
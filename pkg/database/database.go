@@ -1,11 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"log/slog"
 
@@ -16,9 +17,21 @@ import (
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/driver/sqliteshim"
 	"github.com/uptrace/bun/extra/bunotel"
+	"github.com/uptrace/bun/migrate"
 )
 
-func initializeDB(connString string) (*bun.DB, error) {
+// Options configure the connection of the Catalog and the Copy to their database.
+type Options struct {
+	// ConnString is either a postgres:// URL, or the name of a SQLite database, e.g. file::memory:?cache=shared.
+	ConnString string
+	// QueryTimeout bounds every query, if positive.
+	QueryTimeout time.Duration
+	// OTelDBName is the name of the database in the spans of queries.
+	OTelDBName string
+}
+
+func initializeDB(options Options) (*bun.DB, error) {
+	connString := options.ConnString
 	var db *bun.DB
 	if strings.HasPrefix(connString, "postgres://") {
 		sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(connString)))
@@ -41,14 +54,32 @@ func initializeDB(connString string) (*bun.DB, error) {
 			return nil, err
 		}
 	}
-	dbName, ok := os.LookupEnv("QUICKPIZZA_OTEL_DB_NAME")
-	if !ok {
-		dbName = "quickpizza-database"
-	}
 	db.AddQueryHook(logging.NewBunSlogHook(slog.Default()))
 	db.AddQueryHook(bunotel.NewQueryHook(
 		bunotel.WithFormattedQueries(true),
-		bunotel.WithDBName(dbName),
+		bunotel.WithDBName(options.OTelDBName),
 	))
 	return db, nil
 }
+
+// withQueryTimeout bounds ctx by timeout, if positive. Deadlines already present in ctx (e.g. propagated from the
+// incoming request) are kept if they are earlier.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// checkMigrations returns an error if some of the migrations are not applied to db.
+func checkMigrations(ctx context.Context, db *bun.DB, migrations *migrate.Migrations) error {
+	ms, err := migrate.NewMigrator(db, migrations).MigrationsWithStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("getting the status of migrations: %w", err)
+	}
+
+	if unapplied := ms.Unapplied(); len(unapplied) > 0 {
+		return fmt.Errorf("%d migrations are not applied yet, starting with %s", len(unapplied), unapplied[0].Name)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model(&model.Session{}).
+			ForeignKey(`("user_id") REFERENCES "users" ("id") ON DELETE CASCADE`).
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model(&model.Session{}).
+			Index("sessions_user_id_idx").
+			Column("user_id").
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Model(&model.Session{}).IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/util"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Databases created after roles were introduced already have the column, as the users table is created from
+		// the current model. The column is not quoted, as SQLite takes unknown quoted identifiers for strings.
+		if _, err := db.NewSelect().Table("users").ColumnExpr("role").Limit(1).Exec(ctx); err != nil {
+			_, err := db.NewAddColumn().
+				Model((*model.User)(nil)).
+				ColumnExpr("role VARCHAR NOT NULL DEFAULT 'user'").
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		// The pre-created admin and kitchen staff users are given their passwords when the Catalog starts, so that
+		// they are not the same in every database.
+		users := []*model.User{
+			{Username: "admin", Role: model.RoleAdmin},
+			{Username: "kitchen", Role: model.RoleKitchen},
+		}
+		for _, user := range users {
+			user.Token = util.GenerateAlphaNumToken(model.UserTokenLength)
+			_, err := db.NewInsert().Model(user).On("CONFLICT (username) DO NOTHING").Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDelete().
+			Model((*model.User)(nil)).
+			Where("username IN (?)", bun.In([]string{"admin", "kitchen"})).
+			Exec(ctx)
+		return err
+	})
+}
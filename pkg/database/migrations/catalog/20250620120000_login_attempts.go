@@ -0,0 +1,40 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model(&model.LoginAttempt{}).
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		for index, columns := range map[string][]string{
+			"login_attempts_username_idx": {"username", "created_at"},
+			"login_attempts_ip_idx":       {"ip", "created_at"},
+		} {
+			_, err = db.NewCreateIndex().
+				Model(&model.LoginAttempt{}).
+				Index(index).
+				Column(columns...).
+				IfNotExists().
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Model(&model.LoginAttempt{}).IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,43 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/password"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Pre-created users are seeded with plaintext passwords, which are hashed here so that all users are
+		// authenticated the same way.
+		var users []model.User
+		err := db.NewSelect().Model(&users).Where("password_plaintext != ''").Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			hash, err := password.HashPassword(user.PasswordPlaintext)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.NewUpdate().
+				Model((*model.User)(nil)).
+				Set("password_hash = ?", hash).
+				Set("password_plaintext = ''").
+				Where("id = ?", user.ID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		// Plaintext passwords cannot be recovered.
+		return nil
+	})
+}
@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().
+			Model(&model.Favorite{}).
+			ForeignKey(`("user_id") REFERENCES "users" ("id") ON DELETE CASCADE`).
+			ForeignKey(`("pizza_id") REFERENCES "pizzas" ("id") ON DELETE CASCADE`).
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model(&model.Favorite{}).
+			Index("favorites_pizza_id_idx").
+			Column("pizza_id").
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Model(&model.Favorite{}).IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,53 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+// defaultUserID is the ID of the default user, which is shared by all clients using an unknown token. It is allowed to
+// rate a pizza more than once.
+const defaultUserID = 1
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Databases created after reviews were introduced already have the columns, as the ratings table is created
+		// from the current model. The column is not quoted, so that SQLite fails if it is missing.
+		if _, err := db.NewSelect().Table("ratings").ColumnExpr("review").Limit(1).Exec(ctx); err != nil {
+			for _, column := range []string{
+				"review VARCHAR NOT NULL DEFAULT ''",
+				"status VARCHAR NOT NULL DEFAULT 'approved'",
+			} {
+				_, err := db.NewAddColumn().Model((*model.Rating)(nil)).ColumnExpr(column).Exec(ctx)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Keep only the latest rating of every user for every pizza, so that the unique index can be created.
+		_, err := db.NewDelete().
+			Model((*model.Rating)(nil)).
+			Where("rating.user_id <> ?", defaultUserID).
+			Where("EXISTS (SELECT 1 FROM ratings AS newer WHERE newer.user_id = rating.user_id AND newer.pizza_id = rating.pizza_id AND newer.id > rating.id)").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model((*model.Rating)(nil)).
+			Unique().
+			Index("ratings_user_id_pizza_id_key").
+			Column("user_id", "pizza_id").
+			Where("user_id <> ?", defaultUserID).
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropIndex().Model((*model.Rating)(nil)).Index("ratings_user_id_pizza_id_key").IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,28 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().Model(&model.IdempotencyKey{}).IfNotExists().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.NewCreateIndex().
+			Model(&model.IdempotencyKey{}).
+			Index("idempotency_keys_expires_at_idx").
+			Column("expires_at").
+			IfNotExists().
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Model(&model.IdempotencyKey{}).IfExists().Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,26 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Databases created after versions were introduced already have the column, as the ratings table is created
+		// from the current model. The column is not quoted, so that SQLite fails if it is missing.
+		if _, err := db.NewSelect().Table("ratings").ColumnExpr("version").Limit(1).Exec(ctx); err == nil {
+			return nil
+		}
+
+		_, err := db.NewAddColumn().
+			Model((*model.Rating)(nil)).
+			ColumnExpr("version BIGINT NOT NULL DEFAULT 1").
+			Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,29 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/util"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// The admin and kitchen staff users used to be seeded with static tokens, which are replaced with random ones.
+		for _, username := range []string{"admin", "kitchen"} {
+			_, err := db.NewUpdate().
+				Model((*model.User)(nil)).
+				Set("token = ?", util.GenerateAlphaNumToken(model.UserTokenLength)).
+				Where("username = ?", username).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
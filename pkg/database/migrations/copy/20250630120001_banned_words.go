@@ -0,0 +1,35 @@
+package copy
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewCreateTable().Model(&model.BannedWord{}).IfNotExists().Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		words := []model.BannedWord{
+			{Name: "awful"},
+			{Name: "disgusting"},
+			{Name: "garbage"},
+			{Name: "gross"},
+			{Name: "idiot"},
+			{Name: "inedible"},
+			{Name: "scam"},
+			{Name: "spam"},
+			{Name: "stupid"},
+			{Name: "trash"},
+		}
+		_, err = db.NewInsert().Model(&words).On("CONFLICT DO NOTHING").Exec(ctx)
+		return err
+	}, func(ctx context.Context, db *bun.DB) error {
+		_, err := db.NewDropTable().Model(&model.BannedWord{}).IfExists().Exec(ctx)
+		return err
+	})
+}
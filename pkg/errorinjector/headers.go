@@ -31,10 +31,15 @@ func addHeaderFromContext(ctx context.Context, headerName string, request *http.
 
 func InjectErrorHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		for _, header := range headerList {
-			ctx = context.WithValue(ctx, header, r.Header.Get(header))
-		}
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithHeaders(r.Context(), r.Header.Get)))
 	})
 }
+
+// WithHeaders returns a copy of ctx carrying the error injection headers, read with get. It lets other servers than
+// the HTTP one inject errors, e.g. gRPC with the same names as metadata keys.
+func WithHeaders(ctx context.Context, get func(name string) string) context.Context {
+	for _, header := range headerList {
+		ctx = context.WithValue(ctx, header, get(header))
+	}
+	return ctx
+}
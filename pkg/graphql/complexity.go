@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// assumedListSize is the number of elements the complexity of a query assumes lists have, unless their size is an
+// argument, like the limit of recentPizzas.
+const assumedListSize = 10
+
+// listFields are the fields of the schema that return lists of objects, whose selections are resolved for every
+// element.
+var listFields = map[string]bool{
+	"recentPizzas": true,
+	"ingredients":  true,
+	"doughs":       true,
+	"ratings":      true,
+}
+
+// spendComplexity charges the complexity of the field being resolved, which must be a field of an operation type, to
+// the budget of the request. The complexity of a field is one, plus one per field selected below it, multiplied by the
+// size of every list above it: the field itself if it returns size elements, and the selected list fields. It is
+// estimated from the selections, before resolving anything, so that complex queries are rejected before they hit the
+// database.
+func (h *Handler) spendComplexity(ctx context.Context, size int) error {
+	complexity := 1
+	for _, path := range graphql.SelectedFieldNames(ctx) {
+		n := size
+		fields := strings.Split(path, ".")
+		for _, field := range fields[:len(fields)-1] {
+			if listFields[field] {
+				n *= assumedListSize
+			}
+		}
+		complexity += n
+	}
+
+	if h.maxComplexity <= 0 {
+		return nil
+	}
+
+	req := requestFrom(ctx)
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	req.complexity += complexity
+	if req.complexity > h.maxComplexity {
+		return &queryError{
+			message: fmt.Sprintf("query is too complex: its complexity exceeds the limit of %d", h.maxComplexity),
+			code:    "COMPLEXITY_LIMIT_EXCEEDED",
+		}
+	}
+	return nil
+}
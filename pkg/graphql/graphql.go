@@ -0,0 +1,250 @@
+// Package graphql serves the GraphQL API of QuickPizza at /graphql. Like the gRPC API, it mirrors the REST API, running
+// the business logic of the Catalog, Copy and Recommendations services itself, on the same database.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	oteltracer "github.com/graph-gophers/graphql-go/trace/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/logging"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/moderation"
+)
+
+//go:embed schema.graphql
+var schema string
+
+const (
+	// DefaultMaxDepth is the default maximum nesting of the fields of a query.
+	DefaultMaxDepth = 8
+	// DefaultMaxComplexity is the default maximum complexity of a query, see spendComplexity.
+	DefaultMaxComplexity = 1000
+
+	// maxRequestBytes is the largest request body accepted.
+	maxRequestBytes = 1 << 20
+)
+
+// Handler serves the GraphQL API: queries and mutations as POST requests, and subscriptions, as well as queries and
+// mutations, over WebSocket.
+type Handler struct {
+	schema        *graphql.Schema
+	catalog       *database.Catalog
+	copy          *database.Copy
+	moderator     *moderation.Moderator
+	log           *slog.Logger
+	dataLoaders   bool
+	maxDepth      int
+	maxComplexity int
+	tracer        trace.Tracer
+
+	connsMu sync.Mutex
+	conns   map[*wsConnection]struct{}
+	closed  bool
+}
+
+type HandlerOption func(*Handler)
+
+// WithDataLoaders sets whether the pizzas of ratings are loaded in batches, see loader. It is enabled by default, and
+// can be disabled to demonstrate the N+1 queries it saves.
+func WithDataLoaders(enabled bool) HandlerOption {
+	return func(h *Handler) {
+		h.dataLoaders = enabled
+	}
+}
+
+// WithLimits sets the maximum depth and complexity of queries, instead of DefaultMaxDepth and DefaultMaxComplexity. Zero
+// disables a limit.
+func WithLimits(maxDepth, maxComplexity int) HandlerOption {
+	return func(h *Handler) {
+		h.maxDepth = maxDepth
+		h.maxComplexity = maxComplexity
+	}
+}
+
+// WithTracing traces the fields resolved by every query with the given provider, in spans below the one of the
+// request.
+func WithTracing(tp trace.TracerProvider) HandlerOption {
+	return func(h *Handler) {
+		h.tracer = tp.Tracer("github.com/grafana/quickpizza/pkg/graphql")
+	}
+}
+
+func NewHandler(catalog *database.Catalog, copy *database.Copy, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		catalog:       catalog,
+		copy:          copy,
+		log:           slog.New(logging.NewContextLogger(slog.Default().Handler())),
+		dataLoaders:   true,
+		maxDepth:      DefaultMaxDepth,
+		maxComplexity: DefaultMaxComplexity,
+		conns:         map[*wsConnection]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.moderator = moderation.New(copy.GetBannedWords, h.log)
+
+	schemaOpts := []graphql.SchemaOpt{
+		graphql.MaxDepth(h.maxDepth),
+		graphql.MaxQueryLength(maxRequestBytes),
+		graphql.UseStringDescriptions(),
+	}
+	if h.tracer != nil {
+		schemaOpts = append(schemaOpts, graphql.Tracer(&oteltracer.Tracer{Tracer: h.tracer}))
+	}
+	h.schema = graphql.MustParseSchema(schema, &resolver{h}, schemaOpts...)
+
+	return h
+}
+
+// params are the parameters of GraphQL requests, see https://graphql.github.io/graphql-over-http/draft/.
+type params struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		h.serveWebSocket(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrors(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed, use POST, or GET to open a WebSocket", r.Method))
+		return
+	}
+
+	var p params
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&p); err != nil {
+		writeErrors(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if p.Query == "" {
+		writeErrors(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	ctx := h.newRequest(r.Context(), r.Header.Get("Authorization"))
+	resp := h.schema.Exec(ctx, p.Query, p.OperationName, p.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// writeErrors responds with a GraphQL response without data, for requests that cannot be executed.
+func writeErrors(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": []map[string]string{{"message": message}}})
+}
+
+type contextKeyType int
+
+const requestKey contextKeyType = iota
+
+// request holds the state of a GraphQL request, which its resolvers share: its user, its loaders and the complexity
+// resolved so far.
+type request struct {
+	authorization string
+	pizzas        *loader[int64, *model.Pizza]
+
+	userOnce sync.Once
+	user     *model.User
+	userErr  error
+
+	mu         sync.Mutex
+	complexity int
+}
+
+// newRequest returns a context for a GraphQL request, authenticated by the given authorization header, if any.
+func (h *Handler) newRequest(ctx context.Context, authorization string) context.Context {
+	req := &request{authorization: authorization}
+	req.pizzas = newLoader(ctx, h.dataLoaders, h.fetchPizzas)
+	return context.WithValue(ctx, requestKey, req)
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey).(*request)
+}
+
+func (h *Handler) fetchPizzas(ctx context.Context, ids []int64) (map[int64]*model.Pizza, error) {
+	pizzas, err := h.catalog.GetRecommendations(ctx, ids)
+	if err != nil {
+		return nil, h.internalError(ctx, "Failed to get recommendations", err)
+	}
+
+	byID := make(map[int64]*model.Pizza, len(pizzas))
+	for i := range pizzas {
+		byID[pizzas[i].ID] = &pizzas[i]
+	}
+	return byID, nil
+}
+
+// user returns the user authenticated by the token of the request, like the AuthMiddleware of the HTTP server: it
+// accepts the "Token" and "Bearer" schemes, and unknown tokens belong to the default user. The user is only looked
+// up once per request, and only if a resolver needs it.
+func (h *Handler) user(ctx context.Context) (*model.User, error) {
+	req := requestFrom(ctx)
+	req.userOnce.Do(func() {
+		prefix, token, found := strings.Cut(req.authorization, " ")
+		prefix = strings.ToLower(prefix)
+		if prefix == "" {
+			req.userErr = &queryError{message: "authentication required", code: "UNAUTHENTICATED"}
+			return
+		} else if !found || (prefix != "token" && prefix != "bearer") || len(token) != model.UserTokenLength {
+			req.userErr = &queryError{message: "invalid authorization header", code: "UNAUTHENTICATED"}
+			return
+		}
+
+		req.user, req.userErr = h.catalog.Authenticate(ctx, token)
+		if errors.Is(req.userErr, database.ErrTokenExpired) || errors.Is(req.userErr, database.ErrTokenRevoked) || errors.Is(req.userErr, database.ErrTokenNotFound) {
+			req.userErr = &queryError{message: req.userErr.Error(), code: "UNAUTHENTICATED"}
+		} else if req.userErr != nil {
+			req.userErr = h.internalError(ctx, "Failed to authenticate", req.userErr)
+		}
+	})
+	return req.user, req.userErr
+}
+
+// queryError is an error reported to clients with a code in its extensions, following the conventions of Apollo
+// Server, e.g. {"message": "authentication required", "extensions": {"code": "UNAUTHENTICATED"}}.
+type queryError struct {
+	message string
+	code    string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func (e *queryError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func badUserInput(err error) error {
+	return &queryError{message: err.Error(), code: "BAD_USER_INPUT"}
+}
+
+// internalError logs an unexpected error, and returns the error reported for it, unless the request was cancelled.
+func (h *Handler) internalError(ctx context.Context, msg string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	h.log.ErrorContext(ctx, msg, "err", err)
+	return &queryError{message: err.Error(), code: "INTERNAL_SERVER_ERROR"}
+}
@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loaderWait is how long loaders wait for more keys before fetching a batch. graphql-go resolves the elements of lists
+// concurrently, so their loads all happen within it.
+const loaderWait = 2 * time.Millisecond
+
+// loader batches the loads of values by key, like DataLoader: the keys loaded within loaderWait of each other are
+// fetched with a single call, and values are cached for the rest of the request. This turns the N+1 queries of
+// resolving a field of every element of a list, e.g. the pizza of every rating, into one. Without batching, every load
+// calls fetch with its key alone.
+type loader[K comparable, V any] struct {
+	ctx      context.Context // of the request, which batches outlive the field that started them
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	batching bool
+
+	mu      sync.Mutex
+	cache   map[K]*loaderResult[V]
+	pending map[K]*loaderResult[V]
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](ctx context.Context, batching bool, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		batching: batching,
+		cache:    map[K]*loaderResult[V]{},
+		pending:  map[K]*loaderResult[V]{},
+	}
+}
+
+// load returns the value of key, which is the zero value if fetch did not return one.
+func (l *loader[K, V]) load(ctx context.Context, key K) (V, error) {
+	if !l.batching {
+		values, err := l.fetch(ctx, []K{key})
+		return values[key], err
+	}
+
+	l.mu.Lock()
+	result, ok := l.cache[key]
+	if !ok {
+		result = &loaderResult[V]{done: make(chan struct{})}
+		l.cache[key] = result
+		l.pending[key] = result
+		if len(l.pending) == 1 {
+			time.AfterFunc(loaderWait, l.dispatch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches the pending keys.
+func (l *loader[K, V]) dispatch() {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = map[K]*loaderResult[V]{}
+	l.mu.Unlock()
+
+	keys := make([]K, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+
+	values, err := l.fetch(l.ctx, keys)
+	for key, result := range pending {
+		result.value, result.err = values[key], err
+		close(result.done)
+	}
+}
@@ -0,0 +1,434 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/recommendation"
+)
+
+const (
+	// maxRecentPizzas is the largest limit of recentPizzas.
+	maxRecentPizzas = 100
+
+	// recommendationPollInterval is how often newRecommendation subscriptions look for new pizzas in the database,
+	// which is where the recommendations of all APIs and instances end up.
+	recommendationPollInterval = time.Second
+)
+
+// resolver resolves the fields of the operation types: Query, Mutation and Subscription. Each of them charges its
+// complexity first.
+type resolver struct {
+	h *Handler
+}
+
+func (r *resolver) Pizza(ctx context.Context, args struct{ ID graphql.ID }) (*pizzaResolver, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	return loadPizza(ctx, id)
+}
+
+func (r *resolver) RecentPizzas(ctx context.Context, args struct{ Limit int32 }) ([]*pizzaResolver, error) {
+	if args.Limit < 1 || args.Limit > maxRecentPizzas {
+		return nil, badUserInput(fmt.Errorf("limit must be between 1 and %d", maxRecentPizzas))
+	}
+	if err := r.h.spendComplexity(ctx, int(args.Limit)); err != nil {
+		return nil, err
+	}
+
+	pizzas, err := r.h.catalog.GetHistory(ctx, int(args.Limit))
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get recommendation history", err)
+	}
+
+	resolvers := make([]*pizzaResolver, 0, len(pizzas))
+	for i := range pizzas {
+		resolvers = append(resolvers, &pizzaResolver{&pizzas[i]})
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Ingredients(ctx context.Context, args struct{ Type string }) ([]*ingredientResolver, error) {
+	if err := r.h.spendComplexity(ctx, assumedListSize); err != nil {
+		return nil, err
+	}
+
+	ingredients, err := r.h.catalog.GetIngredients(ctx, args.Type)
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get ingredients", err)
+	}
+	if len(ingredients) == 0 {
+		return nil, badUserInput(fmt.Errorf("unknown ingredient type %q", args.Type))
+	}
+
+	resolvers := make([]*ingredientResolver, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		resolvers = append(resolvers, &ingredientResolver{ingredient})
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Doughs(ctx context.Context) ([]*doughResolver, error) {
+	if err := r.h.spendComplexity(ctx, assumedListSize); err != nil {
+		return nil, err
+	}
+
+	doughs, err := r.h.catalog.GetDoughs(ctx)
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get doughs", err)
+	}
+
+	resolvers := make([]*doughResolver, 0, len(doughs))
+	for _, dough := range doughs {
+		resolvers = append(resolvers, &doughResolver{dough})
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Tools(ctx context.Context) ([]string, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	tools, err := r.h.catalog.GetTools(ctx)
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get tools", err)
+	}
+	return tools, nil
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	user, err := r.h.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &userResolver{user}, nil
+}
+
+func (r *resolver) Ratings(ctx context.Context) ([]*ratingResolver, error) {
+	if err := r.h.spendComplexity(ctx, assumedListSize); err != nil {
+		return nil, err
+	}
+
+	user, err := r.h.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, err := r.h.catalog.GetRatings(ctx, user)
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get ratings", err)
+	}
+
+	resolvers := make([]*ratingResolver, 0, len(ratings))
+	for _, rating := range ratings {
+		resolvers = append(resolvers, &ratingResolver{rating})
+	}
+	return resolvers, nil
+}
+
+type restrictionsInput struct {
+	MaxCaloriesPerSlice *int32
+	MustBeVegetarian    *bool
+	ExcludedIngredients *[]string
+	ExcludedTools       *[]string
+	MaxNumberOfToppings *int32
+	MinNumberOfToppings *int32
+	CustomName          *string
+}
+
+func (r *resolver) Recommend(ctx context.Context, args struct{ Restrictions *restrictionsInput }) (*pizzaResolver, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	var restrictions recommendation.Restrictions
+	if in := args.Restrictions; in != nil {
+		restrictions = recommendation.Restrictions{
+			MaxCaloriesPerSlice: int(deref(in.MaxCaloriesPerSlice)),
+			MustBeVegetarian:    deref(in.MustBeVegetarian),
+			ExcludedIngredients: deref(in.ExcludedIngredients),
+			ExcludedTools:       deref(in.ExcludedTools),
+			MaxNumberOfToppings: int(deref(in.MaxNumberOfToppings)),
+			MinNumberOfToppings: int(deref(in.MinNumberOfToppings)),
+			CustomName:          deref(in.CustomName),
+		}
+	}
+
+	p, err := recommendation.Generate(ctx, recommendation.NewDatabaseCatalog(r.h.catalog), recommendation.NewDatabaseCopy(r.h.copy), restrictions)
+	if errors.Is(err, recommendation.ErrNoMatch) {
+		return nil, badUserInput(err)
+	} else if err != nil {
+		return nil, r.h.internalError(ctx, "Generating pizza", err)
+	}
+
+	if err := r.h.catalog.RecordRecommendation(ctx, &p); err != nil {
+		return nil, r.h.internalError(ctx, "Storing recommendation", err)
+	}
+
+	r.h.log.InfoContext(ctx, "New pizza recommendation", "pizza", p.Name)
+	return &pizzaResolver{&p}, nil
+}
+
+type ratingInput struct {
+	PizzaID graphql.ID
+	Stars   int32
+	Review  *string
+}
+
+// Rate validates, moderates and records a rating, like the REST and gRPC APIs.
+func (r *resolver) Rate(ctx context.Context, args struct{ Input ratingInput }) (*ratingResolver, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	user, err := r.h.user(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pizzaID, err := parseID(args.Input.PizzaID)
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	rating := model.Rating{
+		Stars:   int(args.Input.Stars),
+		Review:  deref(args.Input.Review),
+		PizzaID: pizzaID,
+	}
+	if err := rating.Validate(); err != nil {
+		return nil, badUserInput(err)
+	}
+
+	rating.Status = r.h.moderator.Moderate(ctx, rating.Review)
+
+	err = r.h.catalog.RecordRating(ctx, user, &rating)
+	var exists *database.RatingExistsError
+	if errors.As(err, &exists) {
+		return nil, &queryError{message: exists.Error(), code: "ALREADY_EXISTS"}
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	} else if err != nil {
+		return nil, badUserInput(err)
+	}
+
+	rating.User = user
+	return &ratingResolver{&rating}, nil
+}
+
+// NewRecommendation polls the database for pizzas recommended after the subscription started, and sends them in the
+// order they were recommended.
+func (r *resolver) NewRecommendation(ctx context.Context) (<-chan *pizzaResolver, error) {
+	if err := r.h.spendComplexity(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	lastID, err := r.h.catalog.GetLastRecommendationID(ctx)
+	if err != nil {
+		return nil, r.h.internalError(ctx, "Failed to get the last recommendation", err)
+	}
+
+	c := make(chan *pizzaResolver)
+	go func() {
+		defer close(c)
+
+		ticker := time.NewTicker(recommendationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			pizzas, err := r.h.catalog.GetRecommendationsAfter(ctx, lastID, maxRecentPizzas)
+			if err != nil {
+				if ctx.Err() == nil {
+					r.h.log.WarnContext(ctx, "Polling new recommendations", "err", err)
+				}
+				continue
+			}
+
+			for i := range pizzas {
+				lastID = pizzas[i].ID
+				select {
+				case c <- &pizzaResolver{&pizzas[i]}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return c, nil
+}
+
+// loadPizza loads a pizza with the loader of the request, returning nil if it does not exist.
+func loadPizza(ctx context.Context, id int64) (*pizzaResolver, error) {
+	p, err := requestFrom(ctx).pizzas.load(ctx, id)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return &pizzaResolver{p}, nil
+}
+
+type pizzaResolver struct {
+	p *model.Pizza
+}
+
+func (r *pizzaResolver) ID() graphql.ID {
+	return formatID(r.p.ID)
+}
+
+func (r *pizzaResolver) Name() string {
+	return r.p.Name
+}
+
+func (r *pizzaResolver) Dough() *doughResolver {
+	return &doughResolver{r.p.Dough}
+}
+
+func (r *pizzaResolver) Ingredients() []*ingredientResolver {
+	resolvers := make([]*ingredientResolver, 0, len(r.p.Ingredients))
+	for _, ingredient := range r.p.Ingredients {
+		resolvers = append(resolvers, &ingredientResolver{ingredient})
+	}
+	return resolvers
+}
+
+func (r *pizzaResolver) Tool() string {
+	return r.p.Tool
+}
+
+func (r *pizzaResolver) Calories() int32 {
+	return int32(r.p.CalculateCalories())
+}
+
+func (r *pizzaResolver) Vegetarian() bool {
+	return r.p.IsVegetarian()
+}
+
+type ingredientResolver struct {
+	i model.Ingredient
+}
+
+func (r *ingredientResolver) ID() graphql.ID {
+	return formatID(r.i.ID)
+}
+
+func (r *ingredientResolver) Name() string {
+	return r.i.Name
+}
+
+func (r *ingredientResolver) CaloriesPerSlice() int32 {
+	return int32(r.i.CaloriesPerSlice)
+}
+
+func (r *ingredientResolver) Vegetarian() bool {
+	return r.i.Vegetarian
+}
+
+type doughResolver struct {
+	d model.Dough
+}
+
+func (r *doughResolver) ID() graphql.ID {
+	return formatID(r.d.ID)
+}
+
+func (r *doughResolver) Name() string {
+	return r.d.Name
+}
+
+func (r *doughResolver) CaloriesPerSlice() int32 {
+	return int32(r.d.CaloriesPerSlice)
+}
+
+type ratingResolver struct {
+	r *model.Rating
+}
+
+func (r *ratingResolver) ID() graphql.ID {
+	return formatID(r.r.ID)
+}
+
+func (r *ratingResolver) Stars() int32 {
+	return int32(r.r.Stars)
+}
+
+func (r *ratingResolver) Review() string {
+	return r.r.Review
+}
+
+func (r *ratingResolver) Status() string {
+	return r.r.Status
+}
+
+func (r *ratingResolver) Version() int32 {
+	return int32(r.r.Version)
+}
+
+// Pizza loads the rated pizza, with its dough and ingredients, which the rating does not have. This is where ratings
+// take a query each unless loads are batched.
+func (r *ratingResolver) Pizza(ctx context.Context) (*pizzaResolver, error) {
+	return loadPizza(ctx, r.r.PizzaID)
+}
+
+func (r *ratingResolver) User() *userResolver {
+	return &userResolver{r.r.User}
+}
+
+type userResolver struct {
+	u *model.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return formatID(r.u.ID)
+}
+
+func (r *userResolver) Username() string {
+	return r.u.Username
+}
+
+func (r *userResolver) Role() string {
+	return r.u.Role
+}
+
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", id)
+	}
+	return n, nil
+}
+
+func formatID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+// deref returns the value of an optional argument, or its zero value if it is not set.
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
@@ -0,0 +1,223 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketProtocol is the subprotocol of GraphQL over WebSocket, see
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md.
+const webSocketProtocol = "graphql-transport-ws"
+
+// connectionInitTimeout is how long clients have to send connection_init once connected.
+const connectionInitTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{webSocketProtocol},
+	// Like the HTTP API, which allows all origins.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// wsMessage is a message of the graphql-transport-ws protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConnection is a WebSocket connection of a client, which may run several operations at once.
+type wsConnection struct {
+	h    *Handler
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	authorization string
+	acknowledged  bool
+	operations    map[string]context.CancelFunc
+}
+
+// serveWebSocket serves the operations a client sends over a WebSocket, following the graphql-transport-ws protocol.
+// Clients authenticate with the Authorization header of the upgrade request, or with an Authorization field in the
+// payload of connection_init, as browsers cannot set headers.
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already responded with an error.
+		h.log.WarnContext(r.Context(), "Upgrading GraphQL request to WebSocket", "err", err)
+		return
+	}
+	defer conn.Close()
+
+	c := &wsConnection{
+		h:             h,
+		conn:          conn,
+		authorization: r.Header.Get("Authorization"),
+		operations:    map[string]context.CancelFunc{},
+	}
+
+	if conn.Subprotocol() != webSocketProtocol {
+		c.close(4406, "Subprotocol not acceptable")
+		return
+	}
+
+	h.connsMu.Lock()
+	if h.closed {
+		h.connsMu.Unlock()
+		c.close(websocket.CloseGoingAway, "Server shutting down")
+		return
+	}
+	h.conns[c] = struct{}{}
+	h.connsMu.Unlock()
+	defer func() {
+		h.connsMu.Lock()
+		delete(h.conns, c)
+		h.connsMu.Unlock()
+	}()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	initTimer := time.AfterFunc(connectionInitTimeout, func() {
+		c.close(4408, "Connection initialisation timeout")
+	})
+	defer initTimer.Stop()
+
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			var payload struct {
+				Authorization string `json:"Authorization"`
+			}
+			_ = json.Unmarshal(msg.Payload, &payload)
+
+			c.mu.Lock()
+			if c.acknowledged {
+				c.mu.Unlock()
+				c.close(4429, "Too many initialisation requests")
+				return
+			}
+			c.acknowledged = true
+			if payload.Authorization != "" {
+				c.authorization = payload.Authorization
+			}
+			c.mu.Unlock()
+
+			initTimer.Stop()
+			c.write(wsMessage{Type: "connection_ack"})
+
+		case "ping":
+			c.write(wsMessage{Type: "pong"})
+
+		case "pong":
+
+		case "subscribe":
+			var p params
+			if err := json.Unmarshal(msg.Payload, &p); err != nil || msg.ID == "" {
+				c.close(4400, "Invalid subscribe message")
+				return
+			}
+			if !c.start(ctx, msg.ID, p) {
+				return
+			}
+
+		case "complete":
+			c.mu.Lock()
+			if cancel, ok := c.operations[msg.ID]; ok {
+				cancel()
+				delete(c.operations, msg.ID)
+			}
+			c.mu.Unlock()
+
+		default:
+			c.close(4400, fmt.Sprintf("Unknown message type %q", msg.Type))
+			return
+		}
+	}
+}
+
+// start runs an operation until it completes or the client completes it, sending its results as next messages. It
+// returns false if the connection was closed instead, because the client broke the protocol.
+func (c *wsConnection) start(ctx context.Context, id string, p params) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.acknowledged {
+		c.close(4401, "Unauthorized")
+		return false
+	}
+	if _, ok := c.operations[id]; ok {
+		c.close(4409, fmt.Sprintf("Subscriber for %s already exists", id))
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(c.h.newRequest(ctx, c.authorization))
+	c.operations[id] = cancel
+
+	go func() {
+		defer cancel()
+
+		responses, err := c.h.schema.Subscribe(ctx, p.Query, p.OperationName, p.Variables)
+		if err != nil {
+			payload, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+			c.write(wsMessage{ID: id, Type: "error", Payload: payload})
+		} else {
+			for resp := range responses {
+				payload, err := json.Marshal(resp)
+				if err != nil {
+					c.h.log.ErrorContext(ctx, "Encoding GraphQL response", "err", err)
+					continue
+				}
+				c.write(wsMessage{ID: id, Type: "next", Payload: payload})
+			}
+		}
+
+		// Operations the client completed must not be completed again.
+		c.mu.Lock()
+		_, running := c.operations[id]
+		delete(c.operations, id)
+		c.mu.Unlock()
+		if running && ctx.Err() == nil {
+			c.write(wsMessage{ID: id, Type: "complete"})
+		}
+	}()
+	return true
+}
+
+// Close closes the WebSocket connections of all clients with a "going away" close frame, which cancels their
+// operations, and refuses new ones. Queries and mutations sent as POST requests are not affected.
+func (h *Handler) Close() {
+	h.connsMu.Lock()
+	defer h.connsMu.Unlock()
+
+	h.closed = true
+	for c := range h.conns {
+		c.close(websocket.CloseGoingAway, "Server shutting down")
+	}
+}
+
+func (c *wsConnection) write(msg wsMessage) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.WriteJSON(msg)
+}
+
+// close closes the connection with one of the close codes of the protocol, e.g. 4400 for invalid messages.
+func (c *wsConnection) close(code int, reason string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	_ = c.conn.Close()
+}
@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	pb "github.com/grafana/quickpizza/pkg/grpc/quickpizza"
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+func ingredientToPB(i model.Ingredient) *pb.Ingredient {
+	return &pb.Ingredient{
+		Id:               i.ID,
+		Name:             i.Name,
+		CaloriesPerSlice: int32(i.CaloriesPerSlice),
+		Vegetarian:       i.Vegetarian,
+	}
+}
+
+func doughToPB(d model.Dough) *pb.Dough {
+	return &pb.Dough{
+		Id:               d.ID,
+		Name:             d.Name,
+		CaloriesPerSlice: int32(d.CaloriesPerSlice),
+	}
+}
+
+func pizzaToPB(p *model.Pizza) *pb.Pizza {
+	pizza := &pb.Pizza{
+		Id:    p.ID,
+		Name:  p.Name,
+		Dough: doughToPB(p.Dough),
+		Tool:  p.Tool,
+	}
+	for _, ingredient := range p.Ingredients {
+		pizza.Ingredients = append(pizza.Ingredients, ingredientToPB(ingredient))
+	}
+	return pizza
+}
+
+func ratingToPB(r *model.Rating) *pb.Rating {
+	return &pb.Rating{
+		Id:      r.ID,
+		Stars:   int32(r.Stars),
+		Review:  r.Review,
+		Status:  r.Status,
+		Version: r.Version,
+		PizzaId: r.PizzaID,
+	}
+}
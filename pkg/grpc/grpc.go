@@ -2,27 +2,74 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/grafana/quickpizza/pkg/database"
 	pb "github.com/grafana/quickpizza/pkg/grpc/quickpizza"
+	"github.com/grafana/quickpizza/pkg/logging"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/moderation"
+	"github.com/grafana/quickpizza/pkg/recommendation"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 type serverImplementation struct {
 	pb.UnimplementedGRPCServer
+
+	catalog   *database.Catalog
+	copy      *database.Copy
+	moderator *moderation.Moderator
 }
 
 type Server struct {
 	grpcServer    *grpc.Server
+	health        *health.Server
+	healthz       *http.Server
 	listen        string
 	healthzListen string
+	tlsConfig     *tls.Config
+
+	catalog            *database.Catalog
+	log                *slog.Logger
+	tracerProvider     trace.TracerProvider
+	trustClientTraceID bool
+}
+
+type ServerOption func(*Server)
+
+// WithTLS serves gRPC over TLS with config, which may require client certificates for mutual TLS. The server is
+// plaintext otherwise. The /grpchealthz endpoint is always plaintext, for probes.
+func WithTLS(config *tls.Config) ServerOption {
+	return func(s *Server) {
+		s.tlsConfig = config
+	}
+}
+
+// WithTracing traces calls with tp. Like for HTTP, the trace IDs sent by clients are only trusted if
+// trustClientTraceID is set, or for internal calls.
+func WithTracing(tp trace.TracerProvider, trustClientTraceID bool) ServerOption {
+	return func(s *Server) {
+		s.tracerProvider = tp
+		s.trustClientTraceID = trustClientTraceID
+	}
 }
 
 func (s *serverImplementation) Status(_ context.Context, in *pb.StatusRequest) (*pb.StatusResponse, error) {
@@ -42,31 +89,245 @@ func (s *serverImplementation) RatePizza(_ context.Context, in *pb.PizzaRatingRe
 	}, nil
 }
 
-func NewServer(listen string, healthzListen string) *Server {
-	s := grpc.NewServer()
-	pb.RegisterGRPCServer(s, &serverImplementation{})
-	reflection.Register(s)
+func (s *serverImplementation) Login(ctx context.Context, in *pb.LoginRequest) (*pb.LoginResponse, error) {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+
+	user, err := s.catalog.LoginUser(ctx, in.Username, in.Password, ip)
+	var lockout *database.LockoutError
+	if errors.As(err, &lockout) {
+		return nil, status.Error(codes.ResourceExhausted, lockout.Error())
+	} else if err != nil {
+		return nil, internalError(ctx, "Failed to log in", err)
+	} else if user == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
 
-	return &Server{grpcServer: s, listen: listen, healthzListen: healthzListen}
+	session, err := s.catalog.CreateSession(ctx, user)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to create session", err)
+	}
+
+	return &pb.LoginResponse{
+		Token:        session.Token,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int32(time.Until(session.ExpiresAt).Round(time.Second).Seconds()),
+	}, nil
 }
 
-func (s *Server) listenHealthz() {
+func (s *serverImplementation) RecommendPizza(ctx context.Context, in *pb.Restrictions) (*pb.PizzaRecommendation, error) {
+	return s.recommend(ctx, in)
+}
+
+// recommend generates and records a pizza following in, which may be nil to use the default restrictions.
+func (s *serverImplementation) recommend(ctx context.Context, in *pb.Restrictions) (*pb.PizzaRecommendation, error) {
+	restrictions := recommendation.Restrictions{
+		MaxCaloriesPerSlice: int(in.GetMaxCaloriesPerSlice()),
+		MustBeVegetarian:    in.GetMustBeVegetarian(),
+		ExcludedIngredients: in.GetExcludedIngredients(),
+		ExcludedTools:       in.GetExcludedTools(),
+		MaxNumberOfToppings: int(in.GetMaxNumberOfToppings()),
+		MinNumberOfToppings: int(in.GetMinNumberOfToppings()),
+		CustomName:          in.GetCustomName(),
+	}
+
+	p, err := recommendation.Generate(ctx, recommendation.NewDatabaseCatalog(s.catalog), recommendation.NewDatabaseCopy(s.copy), restrictions)
+	if errors.Is(err, recommendation.ErrNoMatch) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, internalError(ctx, "Generating pizza", err)
+	}
+
+	if err := s.catalog.RecordRecommendation(ctx, &p); err != nil {
+		return nil, internalError(ctx, "Storing recommendation", err)
+	}
+
+	slog.InfoContext(ctx, "New pizza recommendation", "pizza", p.Name)
+	return &pb.PizzaRecommendation{
+		Pizza:      pizzaToPB(&p),
+		Calories:   int32(p.CalculateCalories()),
+		Vegetarian: p.IsVegetarian(),
+	}, nil
+}
+
+func (s *serverImplementation) GetPizza(ctx context.Context, in *pb.GetPizzaRequest) (*pb.Pizza, error) {
+	p, err := s.catalog.GetRecommendation(ctx, int(in.Id))
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get recommendation", err)
+	}
+	if p == nil {
+		return nil, status.Error(codes.NotFound, "pizza not found")
+	}
+
+	return pizzaToPB(p), nil
+}
+
+func (s *serverImplementation) GetIngredients(ctx context.Context, in *pb.GetIngredientsRequest) (*pb.GetIngredientsResponse, error) {
+	ingredients, err := s.catalog.GetIngredients(ctx, in.Type)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get ingredients", err)
+	}
+	if len(ingredients) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown ingredient type %q", in.Type)
+	}
+
+	resp := &pb.GetIngredientsResponse{}
+	for _, ingredient := range ingredients {
+		resp.Ingredients = append(resp.Ingredients, ingredientToPB(ingredient))
+	}
+	return resp, nil
+}
+
+func (s *serverImplementation) GetDoughs(ctx context.Context, _ *pb.GetDoughsRequest) (*pb.GetDoughsResponse, error) {
+	doughs, err := s.catalog.GetDoughs(ctx)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get doughs", err)
+	}
+
+	resp := &pb.GetDoughsResponse{}
+	for _, dough := range doughs {
+		resp.Doughs = append(resp.Doughs, doughToPB(dough))
+	}
+	return resp, nil
+}
+
+func (s *serverImplementation) GetTools(ctx context.Context, _ *pb.GetToolsRequest) (*pb.GetToolsResponse, error) {
+	tools, err := s.catalog.GetTools(ctx)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get tools", err)
+	}
+
+	return &pb.GetToolsResponse{Tools: tools}, nil
+}
+
+func (s *serverImplementation) CreateRating(ctx context.Context, in *pb.CreateRatingRequest) (*pb.Rating, error) {
+	user := contextUser(ctx)
+	if user == nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	return s.createRating(ctx, user, in)
+}
+
+// createRating validates, moderates and records the rating of user described by in.
+func (s *serverImplementation) createRating(ctx context.Context, user *model.User, in *pb.CreateRatingRequest) (*pb.Rating, error) {
+	rating := model.Rating{
+		Stars:   int(in.Stars),
+		Review:  in.Review,
+		PizzaID: in.PizzaId,
+	}
+	if err := rating.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	rating.Status = s.moderator.Moderate(ctx, rating.Review)
+
+	err := s.catalog.RecordRating(ctx, user, &rating)
+	var exists *database.RatingExistsError
+	if errors.As(err, &exists) {
+		return nil, status.Error(codes.AlreadyExists, exists.Error())
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, status.FromContextError(ctxErr).Err()
+	} else if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return ratingToPB(&rating), nil
+}
+
+func (s *serverImplementation) ListRatings(ctx context.Context, _ *pb.ListRatingsRequest) (*pb.ListRatingsResponse, error) {
+	user := contextUser(ctx)
+	if user == nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	ratings, err := s.catalog.GetRatings(ctx, user)
+	if err != nil {
+		return nil, internalError(ctx, "Failed to get ratings", err)
+	}
+
+	resp := &pb.ListRatingsResponse{}
+	for _, rating := range ratings {
+		resp.Ratings = append(resp.Ratings, ratingToPB(rating))
+	}
+	return resp, nil
+}
+
+// internalError logs err and returns it as an Internal status, or as DeadlineExceeded or Canceled if the call ended
+// before err happened.
+func internalError(ctx context.Context, msg string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+
+	slog.ErrorContext(ctx, msg, "err", err)
+	return status.Error(codes.Internal, err.Error())
+}
+
+func NewServer(listen string, healthzListen string, catalog *database.Catalog, copy *database.Copy, opts ...ServerOption) *Server {
+	s := &Server{
+		health:        health.NewServer(),
+		listen:        listen,
+		healthzListen: healthzListen,
+		catalog:       catalog,
+		log:           slog.New(logging.NewContextLogger(slog.Default().Handler())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Interceptors run in this order, so that logs and metrics of calls are linked to their trace, and panics are
+	// logged like any other error.
+	interceptors := []interceptor{s.tracing, s.metrics, s.logging, s.recovery, s.errorInjection, s.auth}
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary(interceptors...)),
+		grpc.ChainStreamInterceptor(stream(interceptors...)),
+	}
+	if s.tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+	s.grpcServer = grpc.NewServer(serverOpts...)
+
+	pb.RegisterGRPCServer(s.grpcServer, &serverImplementation{
+		catalog:   catalog,
+		copy:      copy,
+		moderator: moderation.New(copy.GetBannedWords, s.log),
+	})
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	reflection.Register(s.grpcServer)
+
+	// The server is ready as soon as it is created, as its dependencies are already set up.
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.health.SetServingStatus(pb.GRPC_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	s.setUpHealthz()
+
+	return s
+}
+
+// setUpHealthz sets up the server of /grpchealthz for HTTP probes, e.g. of Kubernetes, which cannot use the gRPC health service. It
+// reports the overall status of the health service: 204 if serving, and 503 otherwise.
+func (s *Server) setUpHealthz() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/grpchealthz", func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("grpc-status", "12")
-		w.Header().Set("grpc-message", "unimplemented")
+		resp, err := s.health.Check(req.Context(), &healthpb.HealthCheckRequest{})
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			w.Header().Set("grpc-status", strconv.Itoa(int(codes.Unavailable)))
+			w.Header().Set("grpc-message", "not serving")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("grpc-status", strconv.Itoa(int(codes.OK)))
 		w.WriteHeader(http.StatusNoContent)
 	})
 
-	health := &http.Server{
+	s.healthz = &http.Server{
 		Addr:    s.healthzListen,
 		Handler: h2c.NewHandler(mux, &http2.Server{}),
 	}
-
-	slog.Info("Starting QuickPizza gRPC health check server", "listenAddress", s.healthzListen)
-	if err := health.ListenAndServe(); err != nil {
-		slog.Error("Error listening for gRPC health check server", "err", err)
-	}
 }
 
 func (s *Server) ListenAndServe() error {
@@ -75,8 +336,40 @@ func (s *Server) ListenAndServe() error {
 		return fmt.Errorf("failed to listen on port: %w", err)
 	}
 
-	go s.listenHealthz()
+	go func() {
+		slog.Info("Starting QuickPizza gRPC health check server", "listenAddress", s.healthzListen)
+		if err := s.healthz.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Error listening for gRPC health check server", "err", err)
+		}
+	}()
 
-	slog.Info("Starting QuickPizza gRPC server", "listenAddress", s.listen)
+	slog.Info("Starting QuickPizza gRPC server", "listenAddress", s.listen, "tls", s.tlsConfig != nil)
 	return s.grpcServer.Serve(lis)
 }
+
+// Shutdown stops the server gracefully. The health service reports NOT_SERVING right away, so that load balancers stop
+// sending calls, new calls are refused, and pending calls, including streams, are waited for. If ctx is done before
+// they end, they are cancelled and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.grpcServer.Stop()
+		<-stopped
+	}
+
+	if healthzErr := s.healthz.Shutdown(ctx); err == nil {
+		err = healthzErr
+	}
+	return err
+}
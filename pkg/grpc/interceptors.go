@@ -0,0 +1,305 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/errorinjector"
+	pb "github.com/grafana/quickpizza/pkg/grpc/quickpizza"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "quickpizza",
+		Subsystem: "server",
+		Name:      "grpc_requests_total",
+		Help:      "The total number of gRPC calls",
+	}, []string{"method", "code"})
+
+	grpcRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "quickpizza",
+		Subsystem: "server",
+		Name:      "grpc_request_duration_seconds",
+		Help:      "The duration of gRPC calls",
+	}, []string{"method", "code"})
+
+	grpcRequestDurationNativeHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:                       "quickpizza",
+		Subsystem:                       "server",
+		Name:                            "grpc_request_duration_seconds_native",
+		Help:                            "The duration of gRPC calls (Native Histogram)",
+		NativeHistogramBucketFactor:     1.1,
+		NativeHistogramMaxBucketNumber:  100,
+		NativeHistogramMinResetDuration: 1 * time.Hour,
+	}, []string{"method", "code"})
+)
+
+// authenticatedMethods are the methods that act on behalf of a user, and so require a token, like the REST routes
+// behind AuthMiddleware.
+var authenticatedMethods = map[string]bool{
+	pb.GRPC_CreateRating_FullMethodName:   true,
+	pb.GRPC_ListRatings_FullMethodName:    true,
+	pb.GRPC_BulkRatePizzas_FullMethodName: true,
+}
+
+type contextKeyType int
+
+const (
+	userKey contextKeyType = iota
+	callLogKey
+)
+
+// callLog holds what inner interceptors learn about a call for the logging interceptor, which stores a pointer in the
+// context before calling them.
+type callLog struct {
+	user *model.User
+}
+
+// contextUser returns the user the auth interceptor authenticated, or nil for methods that do not require one.
+func contextUser(ctx context.Context) *model.User {
+	user, _ := ctx.Value(userKey).(*model.User)
+	return user
+}
+
+// isInternalMethod returns true for the methods of the health and reflection services, which are excluded from
+// traces, metrics and logs, like the probes of the HTTP server.
+func isInternalMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.") || strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// interceptor wraps the handler of unary and streaming calls alike. It calls next to go on with the call, possibly with
+// a new context, and returns its result, which it may replace.
+type interceptor func(ctx context.Context, fullMethod string, next func(context.Context) error) error
+
+// unary adapts interceptors, the first one being the outermost, to a unary interceptor.
+func unary(interceptors ...interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := intercept(ctx, info.FullMethod, interceptors, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// stream adapts interceptors, the first one being the outermost, to a stream interceptor.
+func stream(interceptors ...interceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return intercept(ss.Context(), info.FullMethod, interceptors, func(ctx context.Context) error {
+			return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+func intercept(ctx context.Context, fullMethod string, interceptors []interceptor, handler func(context.Context) error) error {
+	if len(interceptors) == 0 {
+		return handler(ctx)
+	}
+
+	return interceptors[0](ctx, fullMethod, func(ctx context.Context) error {
+		return intercept(ctx, fullMethod, interceptors[1:], handler)
+	})
+}
+
+// contextStream is a grpc.ServerStream whose context was replaced by interceptors.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// tracing starts a server span for every call, following the OpenTelemetry semantic conventions for RPC. Like for
+// HTTP, the trace context sent by clients only becomes the parent of the span for internal calls, or if client trace
+// IDs are trusted. Otherwise, the span starts a new trace, linked to the one of the client.
+func (s *Server) tracing(ctx context.Context, fullMethod string, next func(context.Context) error) error {
+	if s.tracerProvider == nil || isInternalMethod(fullMethod) {
+		return next(ctx)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	remote := otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		),
+	}
+
+	parent := remote
+	if !s.trustClientTraceID && len(md.Get("x-is-internal")) == 0 {
+		parent = ctx
+		opts = append(opts, trace.WithNewRoot())
+		if link := trace.LinkFromContext(remote); link.SpanContext.IsValid() {
+			opts = append(opts, trace.WithLinks(link))
+		}
+	}
+
+	ctx, span := s.tracerProvider.Tracer("github.com/grafana/quickpizza/pkg/grpc").Start(parent, strings.TrimPrefix(fullMethod, "/"), opts...)
+	defer span.End()
+
+	err := next(ctx)
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if isServerError(code) {
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+	return err
+}
+
+// metrics records the quickpizza_server_grpc_* Prometheus metrics of every call, with exemplars linking to its trace.
+func (s *Server) metrics(ctx context.Context, fullMethod string, next func(context.Context) error) error {
+	if isInternalMethod(fullMethod) {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	err := next(ctx)
+	duration := time.Since(start).Seconds()
+	code := status.Code(err).String()
+
+	grpcRequests.WithLabelValues(fullMethod, code).Inc()
+	spanCtx := trace.SpanContextFromContext(ctx)
+	for _, histogram := range []*prometheus.HistogramVec{grpcRequestDuration, grpcRequestDurationNativeHistogram} {
+		observer := histogram.WithLabelValues(fullMethod, code)
+		if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && spanCtx.HasTraceID() {
+			exemplarObserver.ObserveWithExemplar(duration, prometheus.Labels{"trace_id": spanCtx.TraceID().String()})
+		} else {
+			observer.Observe(duration)
+		}
+	}
+	return err
+}
+
+// logging logs every call once it ends, at the error level for server errors, at the warning level for client errors,
+// and at the info level otherwise, like the request logs of the HTTP server.
+func (s *Server) logging(ctx context.Context, fullMethod string, next func(context.Context) error) error {
+	if isInternalMethod(fullMethod) {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	cl := &callLog{}
+	err := next(context.WithValue(ctx, callLogKey, cl))
+
+	code := status.Code(err)
+	attrs := []any{"method", fullMethod, "code", code.String(), "duration", time.Since(start)}
+	if cl.user != nil {
+		attrs = append(attrs, "user", cl.user.Username)
+	}
+
+	switch {
+	case isServerError(code):
+		s.log.ErrorContext(ctx, "gRPC call", append(attrs, "err", status.Convert(err).Message())...)
+	case code != codes.OK:
+		s.log.WarnContext(ctx, "gRPC call", append(attrs, "err", status.Convert(err).Message())...)
+	default:
+		s.log.InfoContext(ctx, "gRPC call", attrs...)
+	}
+	return err
+}
+
+// recovery turns panics of handlers into Internal errors, logging them with their stack trace, like the Recoverer
+// middleware of the HTTP server.
+func (s *Server) recovery(ctx context.Context, fullMethod string, next func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.ErrorContext(ctx, "Panic in gRPC handler", "method", fullMethod, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+
+	return next(ctx)
+}
+
+// errorInjection reads the error injection headers of the HTTP API, e.g. x-error-get-ingredients, from the metadata
+// of the call, see docs/inject-errors.md.
+func (s *Server) errorInjection(ctx context.Context, _ string, next func(context.Context) error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return next(errorinjector.WithHeaders(ctx, metadataCarrier(md).Get))
+}
+
+// auth authenticates the user of the token in the authorization metadata for authenticatedMethods, and stores it in
+// the context for contextUser. Like REST, it accepts the "Token" and "Bearer" schemes, and unknown tokens belong to the
+// default user. JWTs are not accepted: Login issues opaque tokens.
+func (s *Server) auth(ctx context.Context, fullMethod string, next func(context.Context) error) error {
+	if !authenticatedMethods[fullMethod] {
+		return next(ctx)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	prefix, token, found := strings.Cut(metadataCarrier(md).Get("authorization"), " ")
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	} else if !found || (prefix != "token" && prefix != "bearer") || len(token) != model.UserTokenLength {
+		return status.Error(codes.Unauthenticated, "invalid authorization metadata")
+	}
+
+	user, err := s.catalog.Authenticate(ctx, token)
+	if errors.Is(err, database.ErrTokenExpired) || errors.Is(err, database.ErrTokenRevoked) || errors.Is(err, database.ErrTokenNotFound) {
+		return status.Error(codes.Unauthenticated, err.Error())
+	} else if err != nil {
+		return internalError(ctx, "Failed to authenticate", err)
+	}
+
+	if cl, ok := ctx.Value(callLogKey).(*callLog); ok {
+		cl.user = user
+	}
+	return next(context.WithValue(ctx, userKey, user))
+}
+
+// isServerError returns whether code means that the server failed, rather than the client, like HTTP 5xx statuses.
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -200,6 +200,1258 @@ func (x *PizzaRatingResponse) GetStarsRating() int32 {
 	return 0
 }
 
+type LoginRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token        string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	RefreshToken string `protobuf:"bytes,2,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	// Seconds until the token expires.
+	ExpiresIn int32 `protobuf:"varint,3,opt,name=expires_in,json=expiresIn,proto3" json:"expires_in,omitempty"`
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LoginResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetExpiresIn() int32 {
+	if x != nil {
+		return x.ExpiresIn
+	}
+	return 0
+}
+
+// Restrictions have the same meaning and defaults as the body of POST /api/pizza.
+type Restrictions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MaxCaloriesPerSlice int32    `protobuf:"varint,1,opt,name=max_calories_per_slice,json=maxCaloriesPerSlice,proto3" json:"max_calories_per_slice,omitempty"`
+	MustBeVegetarian    bool     `protobuf:"varint,2,opt,name=must_be_vegetarian,json=mustBeVegetarian,proto3" json:"must_be_vegetarian,omitempty"`
+	ExcludedIngredients []string `protobuf:"bytes,3,rep,name=excluded_ingredients,json=excludedIngredients,proto3" json:"excluded_ingredients,omitempty"`
+	ExcludedTools       []string `protobuf:"bytes,4,rep,name=excluded_tools,json=excludedTools,proto3" json:"excluded_tools,omitempty"`
+	MaxNumberOfToppings int32    `protobuf:"varint,5,opt,name=max_number_of_toppings,json=maxNumberOfToppings,proto3" json:"max_number_of_toppings,omitempty"`
+	MinNumberOfToppings int32    `protobuf:"varint,6,opt,name=min_number_of_toppings,json=minNumberOfToppings,proto3" json:"min_number_of_toppings,omitempty"`
+	CustomName          string   `protobuf:"bytes,7,opt,name=custom_name,json=customName,proto3" json:"custom_name,omitempty"`
+}
+
+func (x *Restrictions) Reset() {
+	*x = Restrictions{}
+	mi := &file_proto_quickpizza_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Restrictions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Restrictions) ProtoMessage() {}
+
+func (x *Restrictions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Restrictions.ProtoReflect.Descriptor instead.
+func (*Restrictions) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Restrictions) GetMaxCaloriesPerSlice() int32 {
+	if x != nil {
+		return x.MaxCaloriesPerSlice
+	}
+	return 0
+}
+
+func (x *Restrictions) GetMustBeVegetarian() bool {
+	if x != nil {
+		return x.MustBeVegetarian
+	}
+	return false
+}
+
+func (x *Restrictions) GetExcludedIngredients() []string {
+	if x != nil {
+		return x.ExcludedIngredients
+	}
+	return nil
+}
+
+func (x *Restrictions) GetExcludedTools() []string {
+	if x != nil {
+		return x.ExcludedTools
+	}
+	return nil
+}
+
+func (x *Restrictions) GetMaxNumberOfToppings() int32 {
+	if x != nil {
+		return x.MaxNumberOfToppings
+	}
+	return 0
+}
+
+func (x *Restrictions) GetMinNumberOfToppings() int32 {
+	if x != nil {
+		return x.MinNumberOfToppings
+	}
+	return 0
+}
+
+func (x *Restrictions) GetCustomName() string {
+	if x != nil {
+		return x.CustomName
+	}
+	return ""
+}
+
+type Ingredient struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id               int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CaloriesPerSlice int32  `protobuf:"varint,3,opt,name=calories_per_slice,json=caloriesPerSlice,proto3" json:"calories_per_slice,omitempty"`
+	Vegetarian       bool   `protobuf:"varint,4,opt,name=vegetarian,proto3" json:"vegetarian,omitempty"`
+}
+
+func (x *Ingredient) Reset() {
+	*x = Ingredient{}
+	mi := &file_proto_quickpizza_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ingredient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ingredient) ProtoMessage() {}
+
+func (x *Ingredient) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ingredient.ProtoReflect.Descriptor instead.
+func (*Ingredient) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Ingredient) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ingredient) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Ingredient) GetCaloriesPerSlice() int32 {
+	if x != nil {
+		return x.CaloriesPerSlice
+	}
+	return 0
+}
+
+func (x *Ingredient) GetVegetarian() bool {
+	if x != nil {
+		return x.Vegetarian
+	}
+	return false
+}
+
+type Dough struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id               int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	CaloriesPerSlice int32  `protobuf:"varint,3,opt,name=calories_per_slice,json=caloriesPerSlice,proto3" json:"calories_per_slice,omitempty"`
+}
+
+func (x *Dough) Reset() {
+	*x = Dough{}
+	mi := &file_proto_quickpizza_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dough) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dough) ProtoMessage() {}
+
+func (x *Dough) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dough.ProtoReflect.Descriptor instead.
+func (*Dough) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Dough) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Dough) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Dough) GetCaloriesPerSlice() int32 {
+	if x != nil {
+		return x.CaloriesPerSlice
+	}
+	return 0
+}
+
+type Pizza struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          int64         `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Dough       *Dough        `protobuf:"bytes,3,opt,name=dough,proto3" json:"dough,omitempty"`
+	Ingredients []*Ingredient `protobuf:"bytes,4,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	Tool        string        `protobuf:"bytes,5,opt,name=tool,proto3" json:"tool,omitempty"`
+}
+
+func (x *Pizza) Reset() {
+	*x = Pizza{}
+	mi := &file_proto_quickpizza_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pizza) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pizza) ProtoMessage() {}
+
+func (x *Pizza) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pizza.ProtoReflect.Descriptor instead.
+func (*Pizza) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Pizza) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Pizza) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Pizza) GetDough() *Dough {
+	if x != nil {
+		return x.Dough
+	}
+	return nil
+}
+
+func (x *Pizza) GetIngredients() []*Ingredient {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *Pizza) GetTool() string {
+	if x != nil {
+		return x.Tool
+	}
+	return ""
+}
+
+type PizzaRecommendation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pizza      *Pizza `protobuf:"bytes,1,opt,name=pizza,proto3" json:"pizza,omitempty"`
+	Calories   int32  `protobuf:"varint,2,opt,name=calories,proto3" json:"calories,omitempty"`
+	Vegetarian bool   `protobuf:"varint,3,opt,name=vegetarian,proto3" json:"vegetarian,omitempty"`
+}
+
+func (x *PizzaRecommendation) Reset() {
+	*x = PizzaRecommendation{}
+	mi := &file_proto_quickpizza_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PizzaRecommendation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PizzaRecommendation) ProtoMessage() {}
+
+func (x *PizzaRecommendation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PizzaRecommendation.ProtoReflect.Descriptor instead.
+func (*PizzaRecommendation) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PizzaRecommendation) GetPizza() *Pizza {
+	if x != nil {
+		return x.Pizza
+	}
+	return nil
+}
+
+func (x *PizzaRecommendation) GetCalories() int32 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *PizzaRecommendation) GetVegetarian() bool {
+	if x != nil {
+		return x.Vegetarian
+	}
+	return false
+}
+
+type GetPizzaRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetPizzaRequest) Reset() {
+	*x = GetPizzaRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPizzaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPizzaRequest) ProtoMessage() {}
+
+func (x *GetPizzaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPizzaRequest.ProtoReflect.Descriptor instead.
+func (*GetPizzaRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetPizzaRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetIngredientsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// One of olive_oil, tomato, mozzarella or topping.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+}
+
+func (x *GetIngredientsRequest) Reset() {
+	*x = GetIngredientsRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIngredientsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIngredientsRequest) ProtoMessage() {}
+
+func (x *GetIngredientsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIngredientsRequest.ProtoReflect.Descriptor instead.
+func (*GetIngredientsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetIngredientsRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type GetIngredientsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ingredients []*Ingredient `protobuf:"bytes,1,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+}
+
+func (x *GetIngredientsResponse) Reset() {
+	*x = GetIngredientsResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetIngredientsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetIngredientsResponse) ProtoMessage() {}
+
+func (x *GetIngredientsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetIngredientsResponse.ProtoReflect.Descriptor instead.
+func (*GetIngredientsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetIngredientsResponse) GetIngredients() []*Ingredient {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+type GetDoughsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetDoughsRequest) Reset() {
+	*x = GetDoughsRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDoughsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDoughsRequest) ProtoMessage() {}
+
+func (x *GetDoughsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDoughsRequest.ProtoReflect.Descriptor instead.
+func (*GetDoughsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{14}
+}
+
+type GetDoughsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Doughs []*Dough `protobuf:"bytes,1,rep,name=doughs,proto3" json:"doughs,omitempty"`
+}
+
+func (x *GetDoughsResponse) Reset() {
+	*x = GetDoughsResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDoughsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDoughsResponse) ProtoMessage() {}
+
+func (x *GetDoughsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDoughsResponse.ProtoReflect.Descriptor instead.
+func (*GetDoughsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetDoughsResponse) GetDoughs() []*Dough {
+	if x != nil {
+		return x.Doughs
+	}
+	return nil
+}
+
+type GetToolsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetToolsRequest) Reset() {
+	*x = GetToolsRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetToolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetToolsRequest) ProtoMessage() {}
+
+func (x *GetToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetToolsRequest.ProtoReflect.Descriptor instead.
+func (*GetToolsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{16}
+}
+
+type GetToolsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tools []string `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+}
+
+func (x *GetToolsResponse) Reset() {
+	*x = GetToolsResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetToolsResponse) ProtoMessage() {}
+
+func (x *GetToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetToolsResponse.ProtoReflect.Descriptor instead.
+func (*GetToolsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetToolsResponse) GetTools() []string {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+type Rating struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Stars  int32  `protobuf:"varint,2,opt,name=stars,proto3" json:"stars,omitempty"`
+	Review string `protobuf:"bytes,3,opt,name=review,proto3" json:"review,omitempty"`
+	// One of pending, approved or rejected.
+	Status  string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Version int64  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	PizzaId int64  `protobuf:"varint,6,opt,name=pizza_id,json=pizzaId,proto3" json:"pizza_id,omitempty"`
+}
+
+func (x *Rating) Reset() {
+	*x = Rating{}
+	mi := &file_proto_quickpizza_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rating) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rating) ProtoMessage() {}
+
+func (x *Rating) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rating.ProtoReflect.Descriptor instead.
+func (*Rating) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Rating) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Rating) GetStars() int32 {
+	if x != nil {
+		return x.Stars
+	}
+	return 0
+}
+
+func (x *Rating) GetReview() string {
+	if x != nil {
+		return x.Review
+	}
+	return ""
+}
+
+func (x *Rating) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Rating) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Rating) GetPizzaId() int64 {
+	if x != nil {
+		return x.PizzaId
+	}
+	return 0
+}
+
+type CreateRatingRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PizzaId int64  `protobuf:"varint,1,opt,name=pizza_id,json=pizzaId,proto3" json:"pizza_id,omitempty"`
+	Stars   int32  `protobuf:"varint,2,opt,name=stars,proto3" json:"stars,omitempty"`
+	Review  string `protobuf:"bytes,3,opt,name=review,proto3" json:"review,omitempty"`
+}
+
+func (x *CreateRatingRequest) Reset() {
+	*x = CreateRatingRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateRatingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateRatingRequest) ProtoMessage() {}
+
+func (x *CreateRatingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateRatingRequest.ProtoReflect.Descriptor instead.
+func (*CreateRatingRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CreateRatingRequest) GetPizzaId() int64 {
+	if x != nil {
+		return x.PizzaId
+	}
+	return 0
+}
+
+func (x *CreateRatingRequest) GetStars() int32 {
+	if x != nil {
+		return x.Stars
+	}
+	return 0
+}
+
+func (x *CreateRatingRequest) GetReview() string {
+	if x != nil {
+		return x.Review
+	}
+	return ""
+}
+
+type ListRatingsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListRatingsRequest) Reset() {
+	*x = ListRatingsRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRatingsRequest) ProtoMessage() {}
+
+func (x *ListRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRatingsRequest.ProtoReflect.Descriptor instead.
+func (*ListRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{20}
+}
+
+type ListRatingsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ratings []*Rating `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+}
+
+func (x *ListRatingsResponse) Reset() {
+	*x = ListRatingsResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRatingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRatingsResponse) ProtoMessage() {}
+
+func (x *ListRatingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRatingsResponse.ProtoReflect.Descriptor instead.
+func (*ListRatingsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListRatingsResponse) GetRatings() []*Rating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+type WatchRecommendationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Restrictions *Restrictions `protobuf:"bytes,1,opt,name=restrictions,proto3" json:"restrictions,omitempty"`
+	// Number of pizzas to send. Zero sends pizzas until the call is cancelled or its deadline expires.
+	Count int32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// Milliseconds between pizzas. Zero means 1000.
+	IntervalMs int32 `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+}
+
+func (x *WatchRecommendationsRequest) Reset() {
+	*x = WatchRecommendationsRequest{}
+	mi := &file_proto_quickpizza_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRecommendationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRecommendationsRequest) ProtoMessage() {}
+
+func (x *WatchRecommendationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRecommendationsRequest.ProtoReflect.Descriptor instead.
+func (*WatchRecommendationsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *WatchRecommendationsRequest) GetRestrictions() *Restrictions {
+	if x != nil {
+		return x.Restrictions
+	}
+	return nil
+}
+
+func (x *WatchRecommendationsRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *WatchRecommendationsRequest) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+type BulkRatePizzasResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Received int32 `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Created  int32 `protobuf:"varint,2,opt,name=created,proto3" json:"created,omitempty"`
+	Failed   int32 `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	// Average stars of the created ratings.
+	AverageStars float64            `protobuf:"fixed64,4,opt,name=average_stars,json=averageStars,proto3" json:"average_stars,omitempty"`
+	Errors       []*BulkRatingError `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+}
+
+func (x *BulkRatePizzasResponse) Reset() {
+	*x = BulkRatePizzasResponse{}
+	mi := &file_proto_quickpizza_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkRatePizzasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkRatePizzasResponse) ProtoMessage() {}
+
+func (x *BulkRatePizzasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkRatePizzasResponse.ProtoReflect.Descriptor instead.
+func (*BulkRatePizzasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BulkRatePizzasResponse) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *BulkRatePizzasResponse) GetCreated() int32 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *BulkRatePizzasResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BulkRatePizzasResponse) GetAverageStars() float64 {
+	if x != nil {
+		return x.AverageStars
+	}
+	return 0
+}
+
+func (x *BulkRatePizzasResponse) GetErrors() []*BulkRatingError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type BulkRatingError struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Position of the rating in the stream, starting at 0.
+	Index   int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *BulkRatingError) Reset() {
+	*x = BulkRatingError{}
+	mi := &file_proto_quickpizza_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BulkRatingError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BulkRatingError) ProtoMessage() {}
+
+func (x *BulkRatingError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BulkRatingError.ProtoReflect.Descriptor instead.
+func (*BulkRatingError) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *BulkRatingError) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BulkRatingError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ChatMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Set by the server to "kitchen". Ignored in messages from the client.
+	Sender string `protobuf:"bytes,1,opt,name=sender,proto3" json:"sender,omitempty"`
+	Text   string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_proto_quickpizza_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_quickpizza_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_proto_quickpizza_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ChatMessage) GetSender() string {
+	if x != nil {
+		return x.Sender
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
 var File_proto_quickpizza_proto protoreflect.FileDescriptor
 
 var file_proto_quickpizza_proto_rawDesc = []byte{
@@ -217,18 +1469,207 @@ var file_proto_quickpizza_proto_rawDesc = []byte{
 	0x69, 0x7a, 0x7a, 0x61, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
 	0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x73, 0x5f, 0x72, 0x61, 0x74, 0x69,
 	0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x73, 0x52,
-	0x61, 0x74, 0x69, 0x6e, 0x67, 0x32, 0x99, 0x01, 0x0a, 0x04, 0x47, 0x52, 0x50, 0x43, 0x12, 0x41,
-	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
-	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61,
-	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
-	0x00, 0x12, 0x4e, 0x0a, 0x09, 0x52, 0x61, 0x74, 0x65, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x12, 0x1e,
-	0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50, 0x69, 0x7a, 0x7a,
-	0x61, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f,
-	0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50, 0x69, 0x7a, 0x7a,
-	0x61, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
-	0x00, 0x42, 0x15, 0x5a, 0x13, 0x70, 0x6b, 0x67, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x71, 0x75,
-	0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x46, 0x0a, 0x0c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0x69, 0x0a,
+	0x0d, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x5f,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x72, 0x65, 0x66,
+	0x72, 0x65, 0x73, 0x68, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x65, 0x73, 0x5f, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x49, 0x6e, 0x22, 0xd6, 0x02, 0x0a, 0x0c, 0x52, 0x65, 0x73,
+	0x74, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x6d, 0x61, 0x78,
+	0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x6c,
+	0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x6d, 0x61, 0x78, 0x43, 0x61,
+	0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x12, 0x2c,
+	0x0a, 0x12, 0x6d, 0x75, 0x73, 0x74, 0x5f, 0x62, 0x65, 0x5f, 0x76, 0x65, 0x67, 0x65, 0x74, 0x61,
+	0x72, 0x69, 0x61, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x6d, 0x75, 0x73, 0x74,
+	0x42, 0x65, 0x56, 0x65, 0x67, 0x65, 0x74, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x12, 0x31, 0x0a, 0x14,
+	0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x65, 0x78, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x64, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12,
+	0x25, 0x0a, 0x0e, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x5f, 0x74, 0x6f, 0x6f, 0x6c,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65,
+	0x64, 0x54, 0x6f, 0x6f, 0x6c, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x6d, 0x61, 0x78, 0x5f, 0x6e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x5f, 0x6f, 0x66, 0x5f, 0x74, 0x6f, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x6d, 0x61, 0x78, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x4f, 0x66, 0x54, 0x6f, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x6d,
+	0x69, 0x6e, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x5f, 0x6f, 0x66, 0x5f, 0x74, 0x6f, 0x70,
+	0x70, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x6d, 0x69, 0x6e,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4f, 0x66, 0x54, 0x6f, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x4e, 0x61, 0x6d,
+	0x65, 0x22, 0x7e, 0x0a, 0x0a, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x2c, 0x0a, 0x12, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x5f,
+	0x70, 0x65, 0x72, 0x5f, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x10, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x6c, 0x69, 0x63,
+	0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x76, 0x65, 0x67, 0x65, 0x74, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x76, 0x65, 0x67, 0x65, 0x74, 0x61, 0x72, 0x69, 0x61,
+	0x6e, 0x22, 0x59, 0x0a, 0x05, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2c,
+	0x0a, 0x12, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73,
+	0x6c, 0x69, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x63, 0x61, 0x6c, 0x6f,
+	0x72, 0x69, 0x65, 0x73, 0x50, 0x65, 0x72, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x22, 0xa2, 0x01, 0x0a,
+	0x05, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x05, 0x64, 0x6f,
+	0x75, 0x67, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x71, 0x75, 0x69, 0x63,
+	0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x52, 0x05, 0x64, 0x6f,
+	0x75, 0x67, 0x68, 0x12, 0x38, 0x0a, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
+	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74,
+	0x52, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x6f, 0x6f, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x6f, 0x6f,
+	0x6c, 0x22, 0x7a, 0x0a, 0x13, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x65, 0x63, 0x6f, 0x6d, 0x6d,
+	0x65, 0x6e, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a, 0x05, 0x70, 0x69, 0x7a, 0x7a,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70,
+	0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x05, 0x70, 0x69, 0x7a, 0x7a,
+	0x61, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x1e, 0x0a,
+	0x0a, 0x76, 0x65, 0x67, 0x65, 0x74, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x76, 0x65, 0x67, 0x65, 0x74, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x22, 0x21, 0x0a,
+	0x0f, 0x47, 0x65, 0x74, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64,
+	0x22, 0x2b, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x22, 0x52, 0x0a,
+	0x16, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65,
+	0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x71,
+	0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64,
+	0x69, 0x65, 0x6e, 0x74, 0x52, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74,
+	0x73, 0x22, 0x12, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3e, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x75, 0x67,
+	0x68, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x64, 0x6f,
+	0x75, 0x67, 0x68, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x71, 0x75, 0x69,
+	0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x52, 0x06, 0x64,
+	0x6f, 0x75, 0x67, 0x68, 0x73, 0x22, 0x11, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6f, 0x6c,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x28, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x54,
+	0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x6f, 0x6f, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6f,
+	0x6c, 0x73, 0x22, 0x93, 0x01, 0x0a, 0x06, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a,
+	0x08, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x49, 0x64, 0x22, 0x5e, 0x0a, 0x13, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x19, 0x0a, 0x08, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x43,
+	0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69,
+	0x7a, 0x7a, 0x61, 0x2e, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x07, 0x72, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x73, 0x22, 0x92, 0x01, 0x0a, 0x1b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x63,
+	0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x3c, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x71, 0x75, 0x69, 0x63,
+	0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x22, 0xc0, 0x01, 0x0a, 0x16, 0x42, 0x75, 0x6c,
+	0x6b, 0x52, 0x61, 0x74, 0x65, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61, 0x69,
+	0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c, 0x65,
+	0x64, 0x12, 0x23, 0x0a, 0x0d, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x74, 0x61,
+	0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67,
+	0x65, 0x53, 0x74, 0x61, 0x72, 0x73, 0x12, 0x33, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69,
+	0x7a, 0x7a, 0x61, 0x2e, 0x42, 0x75, 0x6c, 0x6b, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x22, 0x41, 0x0a, 0x0f, 0x42,
+	0x75, 0x6c, 0x6b, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x39,
+	0x0a, 0x0b, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x32, 0xf7, 0x07, 0x0a, 0x04, 0x47, 0x52,
+	0x50, 0x43, 0x12, 0x41, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x2e, 0x71,
+	0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70,
+	0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4e, 0x0a, 0x09, 0x52, 0x61, 0x74, 0x65, 0x50, 0x69, 0x7a,
+	0x7a, 0x61, 0x12, 0x1e, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e,
+	0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e,
+	0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x05, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x18,
+	0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x4c, 0x6f, 0x67, 0x69,
+	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
+	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4d, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x65,
+	0x6e, 0x64, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x12, 0x18, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70,
+	0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x1a, 0x1f, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50,
+	0x69, 0x7a, 0x7a, 0x61, 0x52, 0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x64, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x50, 0x69, 0x7a, 0x7a, 0x61,
+	0x12, 0x1b, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x47, 0x65,
+	0x74, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e,
+	0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50, 0x69, 0x7a, 0x7a, 0x61,
+	0x22, 0x00, 0x12, 0x59, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x21, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a,
+	0x61, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70,
+	0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x4a, 0x0a,
+	0x09, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x73, 0x12, 0x1c, 0x2e, 0x71, 0x75, 0x69,
+	0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x75, 0x67, 0x68,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
+	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x6f, 0x75, 0x67, 0x68, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x47, 0x0a, 0x08, 0x47, 0x65, 0x74,
+	0x54, 0x6f, 0x6f, 0x6c, 0x73, 0x12, 0x1b, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a,
+	0x7a, 0x61, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e,
+	0x47, 0x65, 0x74, 0x54, 0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x12, 0x45, 0x0a, 0x0c, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x61, 0x74, 0x69,
+	0x6e, 0x67, 0x12, 0x1f, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61,
+	0x2e, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x0b, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1e, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
+	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b,
+	0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x14, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x64, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x27, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61,
+	0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x64, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x71,
+	0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x52,
+	0x65, 0x63, 0x6f, 0x6d, 0x6d, 0x65, 0x6e, 0x64, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x00, 0x30,
+	0x01, 0x12, 0x59, 0x0a, 0x0e, 0x42, 0x75, 0x6c, 0x6b, 0x52, 0x61, 0x74, 0x65, 0x50, 0x69, 0x7a,
+	0x7a, 0x61, 0x73, 0x12, 0x1f, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61,
+	0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x52, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a,
+	0x61, 0x2e, 0x42, 0x75, 0x6c, 0x6b, 0x52, 0x61, 0x74, 0x65, 0x50, 0x69, 0x7a, 0x7a, 0x61, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x12, 0x45, 0x0a, 0x0b,
+	0x4b, 0x69, 0x74, 0x63, 0x68, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x74, 0x12, 0x17, 0x2e, 0x71, 0x75,
+	0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x2e, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x1a, 0x17, 0x2e, 0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a,
+	0x61, 0x2e, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x00, 0x28,
+	0x01, 0x30, 0x01, 0x42, 0x15, 0x5a, 0x13, 0x70, 0x6b, 0x67, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f,
+	0x71, 0x75, 0x69, 0x63, 0x6b, 0x70, 0x69, 0x7a, 0x7a, 0x61, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -243,23 +1684,75 @@ func file_proto_quickpizza_proto_rawDescGZIP() []byte {
 	return file_proto_quickpizza_proto_rawDescData
 }
 
-var file_proto_quickpizza_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_quickpizza_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
 var file_proto_quickpizza_proto_goTypes = []any{
-	(*StatusRequest)(nil),       // 0: quickpizza.StatusRequest
-	(*StatusResponse)(nil),      // 1: quickpizza.StatusResponse
-	(*PizzaRatingRequest)(nil),  // 2: quickpizza.PizzaRatingRequest
-	(*PizzaRatingResponse)(nil), // 3: quickpizza.PizzaRatingResponse
+	(*StatusRequest)(nil),               // 0: quickpizza.StatusRequest
+	(*StatusResponse)(nil),              // 1: quickpizza.StatusResponse
+	(*PizzaRatingRequest)(nil),          // 2: quickpizza.PizzaRatingRequest
+	(*PizzaRatingResponse)(nil),         // 3: quickpizza.PizzaRatingResponse
+	(*LoginRequest)(nil),                // 4: quickpizza.LoginRequest
+	(*LoginResponse)(nil),               // 5: quickpizza.LoginResponse
+	(*Restrictions)(nil),                // 6: quickpizza.Restrictions
+	(*Ingredient)(nil),                  // 7: quickpizza.Ingredient
+	(*Dough)(nil),                       // 8: quickpizza.Dough
+	(*Pizza)(nil),                       // 9: quickpizza.Pizza
+	(*PizzaRecommendation)(nil),         // 10: quickpizza.PizzaRecommendation
+	(*GetPizzaRequest)(nil),             // 11: quickpizza.GetPizzaRequest
+	(*GetIngredientsRequest)(nil),       // 12: quickpizza.GetIngredientsRequest
+	(*GetIngredientsResponse)(nil),      // 13: quickpizza.GetIngredientsResponse
+	(*GetDoughsRequest)(nil),            // 14: quickpizza.GetDoughsRequest
+	(*GetDoughsResponse)(nil),           // 15: quickpizza.GetDoughsResponse
+	(*GetToolsRequest)(nil),             // 16: quickpizza.GetToolsRequest
+	(*GetToolsResponse)(nil),            // 17: quickpizza.GetToolsResponse
+	(*Rating)(nil),                      // 18: quickpizza.Rating
+	(*CreateRatingRequest)(nil),         // 19: quickpizza.CreateRatingRequest
+	(*ListRatingsRequest)(nil),          // 20: quickpizza.ListRatingsRequest
+	(*ListRatingsResponse)(nil),         // 21: quickpizza.ListRatingsResponse
+	(*WatchRecommendationsRequest)(nil), // 22: quickpizza.WatchRecommendationsRequest
+	(*BulkRatePizzasResponse)(nil),      // 23: quickpizza.BulkRatePizzasResponse
+	(*BulkRatingError)(nil),             // 24: quickpizza.BulkRatingError
+	(*ChatMessage)(nil),                 // 25: quickpizza.ChatMessage
 }
 var file_proto_quickpizza_proto_depIdxs = []int32{
-	0, // 0: quickpizza.GRPC.Status:input_type -> quickpizza.StatusRequest
-	2, // 1: quickpizza.GRPC.RatePizza:input_type -> quickpizza.PizzaRatingRequest
-	1, // 2: quickpizza.GRPC.Status:output_type -> quickpizza.StatusResponse
-	3, // 3: quickpizza.GRPC.RatePizza:output_type -> quickpizza.PizzaRatingResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	8,  // 0: quickpizza.Pizza.dough:type_name -> quickpizza.Dough
+	7,  // 1: quickpizza.Pizza.ingredients:type_name -> quickpizza.Ingredient
+	9,  // 2: quickpizza.PizzaRecommendation.pizza:type_name -> quickpizza.Pizza
+	7,  // 3: quickpizza.GetIngredientsResponse.ingredients:type_name -> quickpizza.Ingredient
+	8,  // 4: quickpizza.GetDoughsResponse.doughs:type_name -> quickpizza.Dough
+	18, // 5: quickpizza.ListRatingsResponse.ratings:type_name -> quickpizza.Rating
+	6,  // 6: quickpizza.WatchRecommendationsRequest.restrictions:type_name -> quickpizza.Restrictions
+	24, // 7: quickpizza.BulkRatePizzasResponse.errors:type_name -> quickpizza.BulkRatingError
+	0,  // 8: quickpizza.GRPC.Status:input_type -> quickpizza.StatusRequest
+	2,  // 9: quickpizza.GRPC.RatePizza:input_type -> quickpizza.PizzaRatingRequest
+	4,  // 10: quickpizza.GRPC.Login:input_type -> quickpizza.LoginRequest
+	6,  // 11: quickpizza.GRPC.RecommendPizza:input_type -> quickpizza.Restrictions
+	11, // 12: quickpizza.GRPC.GetPizza:input_type -> quickpizza.GetPizzaRequest
+	12, // 13: quickpizza.GRPC.GetIngredients:input_type -> quickpizza.GetIngredientsRequest
+	14, // 14: quickpizza.GRPC.GetDoughs:input_type -> quickpizza.GetDoughsRequest
+	16, // 15: quickpizza.GRPC.GetTools:input_type -> quickpizza.GetToolsRequest
+	19, // 16: quickpizza.GRPC.CreateRating:input_type -> quickpizza.CreateRatingRequest
+	20, // 17: quickpizza.GRPC.ListRatings:input_type -> quickpizza.ListRatingsRequest
+	22, // 18: quickpizza.GRPC.WatchRecommendations:input_type -> quickpizza.WatchRecommendationsRequest
+	19, // 19: quickpizza.GRPC.BulkRatePizzas:input_type -> quickpizza.CreateRatingRequest
+	25, // 20: quickpizza.GRPC.KitchenChat:input_type -> quickpizza.ChatMessage
+	1,  // 21: quickpizza.GRPC.Status:output_type -> quickpizza.StatusResponse
+	3,  // 22: quickpizza.GRPC.RatePizza:output_type -> quickpizza.PizzaRatingResponse
+	5,  // 23: quickpizza.GRPC.Login:output_type -> quickpizza.LoginResponse
+	10, // 24: quickpizza.GRPC.RecommendPizza:output_type -> quickpizza.PizzaRecommendation
+	9,  // 25: quickpizza.GRPC.GetPizza:output_type -> quickpizza.Pizza
+	13, // 26: quickpizza.GRPC.GetIngredients:output_type -> quickpizza.GetIngredientsResponse
+	15, // 27: quickpizza.GRPC.GetDoughs:output_type -> quickpizza.GetDoughsResponse
+	17, // 28: quickpizza.GRPC.GetTools:output_type -> quickpizza.GetToolsResponse
+	18, // 29: quickpizza.GRPC.CreateRating:output_type -> quickpizza.Rating
+	21, // 30: quickpizza.GRPC.ListRatings:output_type -> quickpizza.ListRatingsResponse
+	10, // 31: quickpizza.GRPC.WatchRecommendations:output_type -> quickpizza.PizzaRecommendation
+	23, // 32: quickpizza.GRPC.BulkRatePizzas:output_type -> quickpizza.BulkRatePizzasResponse
+	25, // 33: quickpizza.GRPC.KitchenChat:output_type -> quickpizza.ChatMessage
+	21, // [21:34] is the sub-list for method output_type
+	8,  // [8:21] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_proto_quickpizza_proto_init() }
@@ -273,7 +1766,7 @@ func file_proto_quickpizza_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_proto_quickpizza_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   26,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
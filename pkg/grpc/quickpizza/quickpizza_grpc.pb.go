@@ -20,16 +20,59 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GRPC_Status_FullMethodName    = "/quickpizza.GRPC/Status"
-	GRPC_RatePizza_FullMethodName = "/quickpizza.GRPC/RatePizza"
+	GRPC_Status_FullMethodName               = "/quickpizza.GRPC/Status"
+	GRPC_RatePizza_FullMethodName            = "/quickpizza.GRPC/RatePizza"
+	GRPC_Login_FullMethodName                = "/quickpizza.GRPC/Login"
+	GRPC_RecommendPizza_FullMethodName       = "/quickpizza.GRPC/RecommendPizza"
+	GRPC_GetPizza_FullMethodName             = "/quickpizza.GRPC/GetPizza"
+	GRPC_GetIngredients_FullMethodName       = "/quickpizza.GRPC/GetIngredients"
+	GRPC_GetDoughs_FullMethodName            = "/quickpizza.GRPC/GetDoughs"
+	GRPC_GetTools_FullMethodName             = "/quickpizza.GRPC/GetTools"
+	GRPC_CreateRating_FullMethodName         = "/quickpizza.GRPC/CreateRating"
+	GRPC_ListRatings_FullMethodName          = "/quickpizza.GRPC/ListRatings"
+	GRPC_WatchRecommendations_FullMethodName = "/quickpizza.GRPC/WatchRecommendations"
+	GRPC_BulkRatePizzas_FullMethodName       = "/quickpizza.GRPC/BulkRatePizzas"
+	GRPC_KitchenChat_FullMethodName          = "/quickpizza.GRPC/KitchenChat"
 )
 
 // GRPCClient is the client API for GRPC service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GRPC mirrors the REST API of QuickPizza, running the same business logic on the same database, so that gRPC and
+// REST load tests can be compared.
+//
+// Calls on behalf of a user send the token returned by Login in the authorization metadata, as "Token <token>".
+// Like in REST, calls without a token are rejected as unauthenticated, and unknown tokens act as the default user.
 type GRPCClient interface {
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// RatePizza returns a random rating for a combination of ingredients, without recording it. See CreateRating to
+	// rate a recommended pizza.
 	RatePizza(ctx context.Context, in *PizzaRatingRequest, opts ...grpc.CallOption) (*PizzaRatingResponse, error)
+	// Login returns a token for a user, like POST /api/users/token/login.
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// RecommendPizza generates and records a pizza following the restrictions, like POST /api/pizza.
+	RecommendPizza(ctx context.Context, in *Restrictions, opts ...grpc.CallOption) (*PizzaRecommendation, error)
+	// GetPizza returns a pizza recommended before, like GET /api/pizza/{id}.
+	GetPizza(ctx context.Context, in *GetPizzaRequest, opts ...grpc.CallOption) (*Pizza, error)
+	// GetIngredients returns the ingredients of a type, like GET /api/ingredients/{type}.
+	GetIngredients(ctx context.Context, in *GetIngredientsRequest, opts ...grpc.CallOption) (*GetIngredientsResponse, error)
+	// GetDoughs returns the doughs, like GET /api/doughs.
+	GetDoughs(ctx context.Context, in *GetDoughsRequest, opts ...grpc.CallOption) (*GetDoughsResponse, error)
+	// GetTools returns the tools, like GET /api/tools.
+	GetTools(ctx context.Context, in *GetToolsRequest, opts ...grpc.CallOption) (*GetToolsResponse, error)
+	// CreateRating rates a pizza for the user, like POST /api/ratings. Reviews are moderated the same way.
+	CreateRating(ctx context.Context, in *CreateRatingRequest, opts ...grpc.CallOption) (*Rating, error)
+	// ListRatings returns the ratings of the user, like GET /api/ratings.
+	ListRatings(ctx context.Context, in *ListRatingsRequest, opts ...grpc.CallOption) (*ListRatingsResponse, error)
+	// WatchRecommendations streams pizzas recommended following the restrictions, one per interval, until count pizzas
+	// were sent or the call is cancelled.
+	WatchRecommendations(ctx context.Context, in *WatchRecommendationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PizzaRecommendation], error)
+	// BulkRatePizzas rates the pizzas streamed by the client, like CreateRating, and returns a summary once the client
+	// closes the stream. Invalid ratings are counted as failed, without ending the call.
+	BulkRatePizzas(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[CreateRatingRequest, BulkRatePizzasResponse], error)
+	// KitchenChat lets the client chat with the kitchen, which replies to every message.
+	KitchenChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatMessage, ChatMessage], error)
 }
 
 type gRPCClient struct {
@@ -60,12 +103,169 @@ func (c *gRPCClient) RatePizza(ctx context.Context, in *PizzaRatingRequest, opts
 	return out, nil
 }
 
+func (c *gRPCClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, GRPC_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) RecommendPizza(ctx context.Context, in *Restrictions, opts ...grpc.CallOption) (*PizzaRecommendation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PizzaRecommendation)
+	err := c.cc.Invoke(ctx, GRPC_RecommendPizza_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) GetPizza(ctx context.Context, in *GetPizzaRequest, opts ...grpc.CallOption) (*Pizza, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pizza)
+	err := c.cc.Invoke(ctx, GRPC_GetPizza_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) GetIngredients(ctx context.Context, in *GetIngredientsRequest, opts ...grpc.CallOption) (*GetIngredientsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetIngredientsResponse)
+	err := c.cc.Invoke(ctx, GRPC_GetIngredients_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) GetDoughs(ctx context.Context, in *GetDoughsRequest, opts ...grpc.CallOption) (*GetDoughsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDoughsResponse)
+	err := c.cc.Invoke(ctx, GRPC_GetDoughs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) GetTools(ctx context.Context, in *GetToolsRequest, opts ...grpc.CallOption) (*GetToolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetToolsResponse)
+	err := c.cc.Invoke(ctx, GRPC_GetTools_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) CreateRating(ctx context.Context, in *CreateRatingRequest, opts ...grpc.CallOption) (*Rating, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Rating)
+	err := c.cc.Invoke(ctx, GRPC_CreateRating_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) ListRatings(ctx context.Context, in *ListRatingsRequest, opts ...grpc.CallOption) (*ListRatingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRatingsResponse)
+	err := c.cc.Invoke(ctx, GRPC_ListRatings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gRPCClient) WatchRecommendations(ctx context.Context, in *WatchRecommendationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PizzaRecommendation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GRPC_ServiceDesc.Streams[0], GRPC_WatchRecommendations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRecommendationsRequest, PizzaRecommendation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_WatchRecommendationsClient = grpc.ServerStreamingClient[PizzaRecommendation]
+
+func (c *gRPCClient) BulkRatePizzas(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[CreateRatingRequest, BulkRatePizzasResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GRPC_ServiceDesc.Streams[1], GRPC_BulkRatePizzas_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CreateRatingRequest, BulkRatePizzasResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_BulkRatePizzasClient = grpc.ClientStreamingClient[CreateRatingRequest, BulkRatePizzasResponse]
+
+func (c *gRPCClient) KitchenChat(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ChatMessage, ChatMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GRPC_ServiceDesc.Streams[2], GRPC_KitchenChat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatMessage, ChatMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_KitchenChatClient = grpc.BidiStreamingClient[ChatMessage, ChatMessage]
+
 // GRPCServer is the server API for GRPC service.
 // All implementations must embed UnimplementedGRPCServer
 // for forward compatibility.
+//
+// GRPC mirrors the REST API of QuickPizza, running the same business logic on the same database, so that gRPC and
+// REST load tests can be compared.
+//
+// Calls on behalf of a user send the token returned by Login in the authorization metadata, as "Token <token>".
+// Like in REST, calls without a token are rejected as unauthenticated, and unknown tokens act as the default user.
 type GRPCServer interface {
 	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	// RatePizza returns a random rating for a combination of ingredients, without recording it. See CreateRating to
+	// rate a recommended pizza.
 	RatePizza(context.Context, *PizzaRatingRequest) (*PizzaRatingResponse, error)
+	// Login returns a token for a user, like POST /api/users/token/login.
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// RecommendPizza generates and records a pizza following the restrictions, like POST /api/pizza.
+	RecommendPizza(context.Context, *Restrictions) (*PizzaRecommendation, error)
+	// GetPizza returns a pizza recommended before, like GET /api/pizza/{id}.
+	GetPizza(context.Context, *GetPizzaRequest) (*Pizza, error)
+	// GetIngredients returns the ingredients of a type, like GET /api/ingredients/{type}.
+	GetIngredients(context.Context, *GetIngredientsRequest) (*GetIngredientsResponse, error)
+	// GetDoughs returns the doughs, like GET /api/doughs.
+	GetDoughs(context.Context, *GetDoughsRequest) (*GetDoughsResponse, error)
+	// GetTools returns the tools, like GET /api/tools.
+	GetTools(context.Context, *GetToolsRequest) (*GetToolsResponse, error)
+	// CreateRating rates a pizza for the user, like POST /api/ratings. Reviews are moderated the same way.
+	CreateRating(context.Context, *CreateRatingRequest) (*Rating, error)
+	// ListRatings returns the ratings of the user, like GET /api/ratings.
+	ListRatings(context.Context, *ListRatingsRequest) (*ListRatingsResponse, error)
+	// WatchRecommendations streams pizzas recommended following the restrictions, one per interval, until count pizzas
+	// were sent or the call is cancelled.
+	WatchRecommendations(*WatchRecommendationsRequest, grpc.ServerStreamingServer[PizzaRecommendation]) error
+	// BulkRatePizzas rates the pizzas streamed by the client, like CreateRating, and returns a summary once the client
+	// closes the stream. Invalid ratings are counted as failed, without ending the call.
+	BulkRatePizzas(grpc.ClientStreamingServer[CreateRatingRequest, BulkRatePizzasResponse]) error
+	// KitchenChat lets the client chat with the kitchen, which replies to every message.
+	KitchenChat(grpc.BidiStreamingServer[ChatMessage, ChatMessage]) error
 	mustEmbedUnimplementedGRPCServer()
 }
 
@@ -82,6 +282,39 @@ func (UnimplementedGRPCServer) Status(context.Context, *StatusRequest) (*StatusR
 func (UnimplementedGRPCServer) RatePizza(context.Context, *PizzaRatingRequest) (*PizzaRatingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RatePizza not implemented")
 }
+func (UnimplementedGRPCServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedGRPCServer) RecommendPizza(context.Context, *Restrictions) (*PizzaRecommendation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecommendPizza not implemented")
+}
+func (UnimplementedGRPCServer) GetPizza(context.Context, *GetPizzaRequest) (*Pizza, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPizza not implemented")
+}
+func (UnimplementedGRPCServer) GetIngredients(context.Context, *GetIngredientsRequest) (*GetIngredientsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetIngredients not implemented")
+}
+func (UnimplementedGRPCServer) GetDoughs(context.Context, *GetDoughsRequest) (*GetDoughsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDoughs not implemented")
+}
+func (UnimplementedGRPCServer) GetTools(context.Context, *GetToolsRequest) (*GetToolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTools not implemented")
+}
+func (UnimplementedGRPCServer) CreateRating(context.Context, *CreateRatingRequest) (*Rating, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateRating not implemented")
+}
+func (UnimplementedGRPCServer) ListRatings(context.Context, *ListRatingsRequest) (*ListRatingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRatings not implemented")
+}
+func (UnimplementedGRPCServer) WatchRecommendations(*WatchRecommendationsRequest, grpc.ServerStreamingServer[PizzaRecommendation]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRecommendations not implemented")
+}
+func (UnimplementedGRPCServer) BulkRatePizzas(grpc.ClientStreamingServer[CreateRatingRequest, BulkRatePizzasResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BulkRatePizzas not implemented")
+}
+func (UnimplementedGRPCServer) KitchenChat(grpc.BidiStreamingServer[ChatMessage, ChatMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method KitchenChat not implemented")
+}
 func (UnimplementedGRPCServer) mustEmbedUnimplementedGRPCServer() {}
 func (UnimplementedGRPCServer) testEmbeddedByValue()              {}
 
@@ -139,6 +372,175 @@ func _GRPC_RatePizza_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GRPC_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_RecommendPizza_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Restrictions)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).RecommendPizza(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_RecommendPizza_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).RecommendPizza(ctx, req.(*Restrictions))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_GetPizza_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPizzaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).GetPizza(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_GetPizza_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).GetPizza(ctx, req.(*GetPizzaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_GetIngredients_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIngredientsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).GetIngredients(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_GetIngredients_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).GetIngredients(ctx, req.(*GetIngredientsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_GetDoughs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDoughsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).GetDoughs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_GetDoughs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).GetDoughs(ctx, req.(*GetDoughsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_GetTools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetToolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).GetTools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_GetTools_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).GetTools(ctx, req.(*GetToolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_CreateRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).CreateRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_CreateRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).CreateRating(ctx, req.(*CreateRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_ListRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GRPCServer).ListRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GRPC_ListRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GRPCServer).ListRatings(ctx, req.(*ListRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GRPC_WatchRecommendations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRecommendationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GRPCServer).WatchRecommendations(m, &grpc.GenericServerStream[WatchRecommendationsRequest, PizzaRecommendation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_WatchRecommendationsServer = grpc.ServerStreamingServer[PizzaRecommendation]
+
+func _GRPC_BulkRatePizzas_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GRPCServer).BulkRatePizzas(&grpc.GenericServerStream[CreateRatingRequest, BulkRatePizzasResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_BulkRatePizzasServer = grpc.ClientStreamingServer[CreateRatingRequest, BulkRatePizzasResponse]
+
+func _GRPC_KitchenChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GRPCServer).KitchenChat(&grpc.GenericServerStream[ChatMessage, ChatMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GRPC_KitchenChatServer = grpc.BidiStreamingServer[ChatMessage, ChatMessage]
+
 // GRPC_ServiceDesc is the grpc.ServiceDesc for GRPC service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -154,7 +556,56 @@ var GRPC_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RatePizza",
 			Handler:    _GRPC_RatePizza_Handler,
 		},
+		{
+			MethodName: "Login",
+			Handler:    _GRPC_Login_Handler,
+		},
+		{
+			MethodName: "RecommendPizza",
+			Handler:    _GRPC_RecommendPizza_Handler,
+		},
+		{
+			MethodName: "GetPizza",
+			Handler:    _GRPC_GetPizza_Handler,
+		},
+		{
+			MethodName: "GetIngredients",
+			Handler:    _GRPC_GetIngredients_Handler,
+		},
+		{
+			MethodName: "GetDoughs",
+			Handler:    _GRPC_GetDoughs_Handler,
+		},
+		{
+			MethodName: "GetTools",
+			Handler:    _GRPC_GetTools_Handler,
+		},
+		{
+			MethodName: "CreateRating",
+			Handler:    _GRPC_CreateRating_Handler,
+		},
+		{
+			MethodName: "ListRatings",
+			Handler:    _GRPC_ListRatings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRecommendations",
+			Handler:       _GRPC_WatchRecommendations_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BulkRatePizzas",
+			Handler:       _GRPC_BulkRatePizzas_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "KitchenChat",
+			Handler:       _GRPC_KitchenChat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/quickpizza.proto",
 }
@@ -0,0 +1,135 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"time"
+
+	pb "github.com/grafana/quickpizza/pkg/grpc/quickpizza"
+	"github.com/grafana/quickpizza/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The streaming RPCs never buffer messages: Send blocks while the client does not read, and the next message is only
+// received once the previous one was handled, so gRPC flow control slows down both sides. They stop as soon as the
+// call is cancelled or its deadline expires, returning the matching status.
+
+// defaultWatchInterval is the time between pizzas sent by WatchRecommendations, unless the request sets one.
+const defaultWatchInterval = time.Second
+
+// kitchenSender is the sender of the messages of the kitchen in KitchenChat.
+const kitchenSender = "kitchen"
+
+func (s *serverImplementation) WatchRecommendations(in *pb.WatchRecommendationsRequest, stream grpc.ServerStreamingServer[pb.PizzaRecommendation]) error {
+	ctx := stream.Context()
+	if in.Count < 0 || in.IntervalMs < 0 {
+		return status.Error(codes.InvalidArgument, "count and interval_ms must not be negative")
+	}
+
+	interval := defaultWatchInterval
+	if in.IntervalMs > 0 {
+		interval = time.Duration(in.IntervalMs) * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for sent := int32(0); in.Count == 0 || sent < in.Count; sent++ {
+		if sent > 0 {
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-ticker.C:
+			}
+		}
+
+		recommendation, err := s.recommend(ctx, in.Restrictions)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(recommendation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *serverImplementation) BulkRatePizzas(stream grpc.ClientStreamingServer[pb.CreateRatingRequest, pb.BulkRatePizzasResponse]) error {
+	ctx := stream.Context()
+	user := contextUser(ctx)
+	if user == nil {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	var summary pb.BulkRatePizzasResponse
+	var totalStars int32
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		index := summary.Received
+		summary.Received++
+
+		rating, err := s.createRating(ctx, user, in)
+		if code := status.Code(err); code == codes.Canceled || code == codes.DeadlineExceeded || code == codes.Internal {
+			return err
+		} else if err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, &pb.BulkRatingError{Index: index, Message: status.Convert(err).Message()})
+			continue
+		}
+
+		summary.Created++
+		totalStars += rating.Stars
+	}
+
+	if summary.Created > 0 {
+		summary.AverageStars = float64(totalStars) / float64(summary.Created)
+	}
+
+	slog.InfoContext(ctx, "Bulk rating", "received", summary.Received, "created", summary.Created, "failed", summary.Failed)
+	return stream.SendAndClose(&summary)
+}
+
+func (s *serverImplementation) KitchenChat(stream grpc.BidiStreamingServer[pb.ChatMessage, pb.ChatMessage]) error {
+	ctx := stream.Context()
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var reply string
+		switch {
+		case strings.TrimSpace(in.Text) == "":
+			reply = "Sorry, I didn't catch that."
+		case s.moderator.Moderate(ctx, in.Text) != model.RatingApproved:
+			reply = "Let's keep it friendly, please."
+		default:
+			quotes, err := s.copy.GetQuotes(ctx)
+			if err != nil {
+				return internalError(ctx, "Failed to get quotes", err)
+			}
+			reply = "Coming right up!"
+			if len(quotes) > 0 {
+				reply = quotes[rand.Intn(len(quotes))]
+			}
+		}
+
+		if err := stream.Send(&pb.ChatMessage{Sender: kitchenSender, Text: reply}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// selfSignedValidity is how long self-signed certificates are valid for.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// TLSConfig configures TLS for the gRPC server. The certificate is either loaded from CertFile and KeyFile, or
+// generated on startup if SelfSigned is set. Clients must present a certificate signed by a CA of ClientCAFile, if
+// set, which enables mutual TLS.
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	SelfSigned   bool
+	ClientCAFile string
+}
+
+// Load returns the tls.Config described by c.
+func (c TLSConfig) Load() (*tls.Config, error) {
+	var cert tls.Certificate
+	var err error
+	switch {
+	case c.CertFile != "" && c.KeyFile != "":
+		cert, err = tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading certificate: %w", err)
+		}
+	case c.CertFile != "" || c.KeyFile != "":
+		return nil, errors.New("both a certificate and a key file are required")
+	case c.SelfSigned:
+		cert, err = selfSignedCertificate()
+		if err != nil {
+			return nil, fmt.Errorf("generating self-signed certificate: %w", err)
+		}
+	default:
+		return nil, errors.New("either certificate files or a self-signed certificate are required")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading client CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.ClientCAFile)
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// selfSignedCertificate generates a certificate for localhost and the hostname of the machine. Its fingerprint is
+// logged, so that clients can check it.
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	dnsNames := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "localhost" {
+		dnsNames = append(dnsNames, hostname)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"QuickPizza"}, CommonName: "localhost"},
+		DNSNames:              dnsNames,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	fingerprint := sha256.Sum256(der)
+	slog.Info("Generated self-signed certificate for the gRPC server", "dnsNames", dnsNames, "sha256", hex.EncodeToString(fingerprint[:]))
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -0,0 +1,452 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// WebPathPrefix is the path prefix of the calls WebHandler serves, e.g. /quickpizza.GRPC/GetPizza.
+const WebPathPrefix = "/quickpizza.GRPC/"
+
+// maxConnectUnaryBytes is the largest request message of Connect unary calls, the default limit of gRPC servers.
+const maxConnectUnaryBytes = 4 << 20
+
+// Flags of the prefix of gRPC-Web and Connect messages, see
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md and https://connectrpc.com/docs/protocol.
+const (
+	flagCompressed  = 0x01
+	flagEndStream   = 0x02
+	flagWebTrailers = 0x80
+)
+
+func init() {
+	// Let the server encode messages as JSON for calls with the json content subtype, e.g. application/grpc+json, which
+	// the gRPC-Web and Connect calls of WebHandler map to.
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec encodes messages with the canonical JSON mapping of protobuf.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T as JSON", v)
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	// protojson randomly adds whitespace, so that its output is not relied upon to be stable. Remove it, like other
+	// JSON responses of QuickPizza.
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, err
+	}
+	return compact.Bytes(), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cannot decode JSON into %T", v)
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+// webProtocol is a wire protocol WebHandler serves the gRPC service with.
+type webProtocol int
+
+const (
+	protocolGRPCWeb webProtocol = iota
+	protocolGRPCWebText
+	protocolConnectUnary
+	protocolConnectStream
+)
+
+// parseWebContentType returns the protocol and the codec of a call from its content type, e.g. Connect and json for
+// application/json.
+func parseWebContentType(contentType string) (webProtocol, string, bool) {
+	contentType, _, _ = strings.Cut(strings.ToLower(contentType), ";")
+	contentType = strings.TrimSpace(contentType)
+
+	switch contentType {
+	case "application/json":
+		return protocolConnectUnary, "json", true
+	case "application/proto":
+		return protocolConnectUnary, "proto", true
+	}
+
+	prefixes := []struct {
+		prefix   string
+		protocol webProtocol
+	}{
+		{"application/grpc-web-text", protocolGRPCWebText},
+		{"application/grpc-web", protocolGRPCWeb},
+		{"application/connect", protocolConnectStream},
+	}
+	for _, p := range prefixes {
+		rest, ok := strings.CutPrefix(contentType, p.prefix)
+		if !ok {
+			continue
+		}
+		switch rest {
+		case "", "+proto":
+			return p.protocol, "proto", p.protocol != protocolConnectStream || rest != ""
+		case "+json":
+			return p.protocol, "json", true
+		}
+		return 0, "", false
+	}
+	return 0, "", false
+}
+
+// WebHandler serves the gRPC service over gRPC-Web, including its base64 text variant, and the Connect protocol, with
+// JSON or binary messages, so that browsers and HTTP clients can call it, e.g. from the main HTTP listener. Calls go
+// through the same handlers and interceptors as native gRPC calls: requests are translated to gRPC requests served by
+// the gRPC server, and its responses are translated back to the protocol of the call.
+func (s *Server) WebHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocol, codec, ok := parseWebContentType(r.Header.Get("Content-Type"))
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		rw := &webResponseWriter{w: w, header: http.Header{}, protocol: protocol, codec: codec}
+		defer rw.finish()
+
+		if r.Method != http.MethodPost {
+			rw.fail(codes.Unimplemented, fmt.Sprintf("method %s not supported, use POST", r.Method))
+			return
+		}
+
+		req := r.Clone(r.Context())
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2.0", 2, 0
+		req.Header.Set("Content-Type", "application/grpc+"+codec)
+		req.Header.Del("Content-Length")
+
+		switch protocol {
+		case protocolGRPCWebText:
+			req.Body = readCloser{base64.NewDecoder(base64.StdEncoding, r.Body), r.Body}
+		case protocolConnectUnary, protocolConnectStream:
+			encodingHeader := "Content-Encoding"
+			if protocol == protocolConnectStream {
+				encodingHeader = "Connect-Content-Encoding"
+			}
+			if enc := r.Header.Get(encodingHeader); enc != "" && enc != "identity" {
+				rw.fail(codes.Unimplemented, fmt.Sprintf("compression %q is not supported", enc))
+				return
+			}
+
+			if timeout := r.Header.Get("Connect-Timeout-Ms"); timeout != "" {
+				ms, err := strconv.ParseUint(timeout, 10, 64)
+				if err != nil || len(timeout) > 10 {
+					rw.fail(codes.InvalidArgument, fmt.Sprintf("invalid Connect-Timeout-Ms %q", timeout))
+					return
+				}
+				req.Header.Set("Grpc-Timeout", fmt.Sprintf("%dm", ms))
+			}
+
+			if protocol == protocolConnectUnary {
+				// Connect unary messages are not prefixed, unlike the ones of the other protocols and gRPC.
+				body, err := io.ReadAll(io.LimitReader(r.Body, maxConnectUnaryBytes+1))
+				if err != nil {
+					rw.fail(codes.InvalidArgument, fmt.Sprintf("reading request body: %v", err))
+					return
+				} else if len(body) > maxConnectUnaryBytes {
+					rw.fail(codes.ResourceExhausted, fmt.Sprintf("request message larger than %d bytes", maxConnectUnaryBytes))
+					return
+				}
+				req.Body = readCloser{bytes.NewReader(append(messagePrefix(0, len(body)), body...)), r.Body}
+			} else {
+				// Bidirectional streams need to read the request while writing the response, which HTTP/1 servers do
+				// not allow by default. HTTP/2 ones always do, and fail to enable it, which is fine.
+				_ = http.NewResponseController(w).EnableFullDuplex()
+			}
+		}
+
+		s.grpcServer.ServeHTTP(rw, req)
+	})
+}
+
+// readCloser reads from a Reader wrapping the body of a request, and closes the body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// messagePrefix returns the prefix of a message of gRPC and the protocols that build on its framing: a byte of flags
+// followed by the length of the message as a big-endian uint32.
+func messagePrefix(flags byte, length int) []byte {
+	prefix := make([]byte, 5)
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(length))
+	return prefix
+}
+
+// webResponseWriter is the http.ResponseWriter of the gRPC server for the calls of WebHandler. The server writes the
+// response as for HTTP/2: headers, then prefixed messages, and finally the status and the trailers, as headers set
+// after the body. gRPC-Web and Connect streams forward the messages as they are written, while Connect unary calls,
+// whose HTTP status depends on the gRPC one, buffer the response until finish.
+type webResponseWriter struct {
+	w        http.ResponseWriter
+	header   http.Header
+	protocol webProtocol
+	codec    string
+
+	wroteHeader bool
+	statusCode  int
+	out         io.Writer      // where messages are forwarded, once headers are written
+	text        io.WriteCloser // base64 encoder of gRPC-Web text responses
+	body        bytes.Buffer   // response of Connect unary calls
+}
+
+func (rw *webResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+func (rw *webResponseWriter) WriteHeader(statusCode int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = statusCode
+	}
+}
+
+func (rw *webResponseWriter) Write(p []byte) (int, error) {
+	if rw.rejected() || rw.protocol == protocolConnectUnary {
+		return rw.body.Write(p)
+	}
+	rw.writeHeader()
+	return rw.out.Write(p)
+}
+
+func (rw *webResponseWriter) Flush() {
+	if rw.rejected() || rw.protocol == protocolConnectUnary {
+		return
+	}
+	rw.writeHeader()
+	_ = http.NewResponseController(rw.w).Flush()
+}
+
+// rejected returns whether the gRPC server rejected the request before serving it, with a plain text error.
+func (rw *webResponseWriter) rejected() bool {
+	return rw.statusCode != 0 && rw.statusCode != http.StatusOK
+}
+
+// writeHeader writes the headers of streaming responses, the ones the gRPC server set so far, except for the ones
+// about HTTP/2 trailers.
+func (rw *webResponseWriter) writeHeader() {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+
+	h := rw.w.Header()
+	for key, values := range rw.header {
+		if key == "Trailer" || key == "Content-Type" || strings.HasPrefix(key, trailerPrefix) {
+			continue
+		}
+		h[key] = values
+	}
+	h.Set("Content-Type", rw.contentType())
+	rw.w.WriteHeader(http.StatusOK)
+
+	rw.out = rw.w
+	if rw.protocol == protocolGRPCWebText {
+		rw.text = base64.NewEncoder(base64.StdEncoding, rw.w)
+		rw.out = rw.text
+	}
+}
+
+func (rw *webResponseWriter) contentType() string {
+	switch rw.protocol {
+	case protocolGRPCWeb:
+		return "application/grpc-web+" + rw.codec
+	case protocolGRPCWebText:
+		return "application/grpc-web-text+" + rw.codec
+	case protocolConnectStream:
+		return "application/connect+" + rw.codec
+	}
+	if rw.codec == "json" {
+		return "application/json"
+	}
+	return "application/proto"
+}
+
+// fail ends a call that cannot be served, with the gRPC status it would have been rejected with.
+func (rw *webResponseWriter) fail(code codes.Code, message string) {
+	rw.header.Set("Grpc-Status", strconv.Itoa(int(code)))
+	rw.header.Set("Grpc-Message", message)
+}
+
+// trailerPrefix is the prefix of the headers of the gRPC server that are trailers, see http2.TrailerPrefix.
+const trailerPrefix = "Trailer:"
+
+// trailers returns the trailers the gRPC server set, other than the status.
+func (rw *webResponseWriter) trailers() http.Header {
+	trailers := http.Header{}
+	for key, values := range rw.header {
+		if name, ok := strings.CutPrefix(key, trailerPrefix); ok {
+			trailers[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return trailers
+}
+
+// finish writes the end of the response, with the status of the call.
+func (rw *webResponseWriter) finish() {
+	if rw.rejected() {
+		// Pass on the errors of the gRPC server about requests it could not handle.
+		rw.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.w.WriteHeader(rw.statusCode)
+		_, _ = rw.w.Write(rw.body.Bytes())
+		return
+	}
+
+	code := codes.Unknown
+	if c, err := strconv.Atoi(rw.header.Get("Grpc-Status")); err == nil {
+		code = codes.Code(c)
+	}
+	// The gRPC server percent-encodes the message, as gRPC-Web does, unlike Connect.
+	message := rw.header.Get("Grpc-Message")
+
+	switch rw.protocol {
+	case protocolGRPCWeb, protocolGRPCWebText:
+		var trailers bytes.Buffer
+		fmt.Fprintf(&trailers, "grpc-status: %d\r\n", code)
+		if message != "" {
+			fmt.Fprintf(&trailers, "grpc-message: %s\r\n", message)
+		}
+		if details := rw.header.Get("Grpc-Status-Details-Bin"); details != "" {
+			fmt.Fprintf(&trailers, "grpc-status-details-bin: %s\r\n", details)
+		}
+		for key, values := range rw.trailers() {
+			for _, value := range values {
+				fmt.Fprintf(&trailers, "%s: %s\r\n", strings.ToLower(key), value)
+			}
+		}
+		rw.writeHeader()
+		_, _ = rw.out.Write(messagePrefix(flagWebTrailers, trailers.Len()))
+		_, _ = rw.out.Write(trailers.Bytes())
+		if rw.text != nil {
+			_ = rw.text.Close()
+		}
+
+	case protocolConnectStream:
+		end := connectEndStream{Metadata: rw.trailers()}
+		if code != codes.OK {
+			end.Error = &connectError{Code: connectCode(code), Message: decodeGRPCMessage(message)}
+		}
+		data, _ := json.Marshal(end)
+		rw.writeHeader()
+		_, _ = rw.out.Write(messagePrefix(flagEndStream, len(data)))
+		_, _ = rw.out.Write(data)
+
+	case protocolConnectUnary:
+		h := rw.w.Header()
+		for key, values := range rw.header {
+			if key == "Trailer" || key == "Content-Type" || strings.HasPrefix(key, "Grpc-") || strings.HasPrefix(key, trailerPrefix) {
+				continue
+			}
+			h[key] = values
+		}
+		for key, values := range rw.trailers() {
+			h["Trailer-"+key] = values
+		}
+
+		body := rw.body.Bytes()
+		if code == codes.OK && (len(body) < 5 || body[0]&flagCompressed != 0) {
+			code, message = codes.Internal, "unexpected response message"
+		}
+		if code != codes.OK {
+			h.Set("Content-Type", "application/json")
+			rw.w.WriteHeader(connectHTTPStatus(code))
+			_ = json.NewEncoder(rw.w).Encode(connectError{Code: connectCode(code), Message: decodeGRPCMessage(message)})
+			return
+		}
+
+		h.Set("Content-Type", rw.contentType())
+		rw.w.WriteHeader(http.StatusOK)
+		_, _ = rw.w.Write(body[5:])
+	}
+}
+
+// connectError is the body of Connect unary errors, and the error of the end of Connect streams.
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// connectEndStream is the last message of Connect streams.
+type connectEndStream struct {
+	Error    *connectError `json:"error,omitempty"`
+	Metadata http.Header   `json:"metadata,omitempty"`
+}
+
+// connectCode returns the name Connect gives to a gRPC status code, e.g. not_found for NotFound.
+func connectCode(code codes.Code) string {
+	var name strings.Builder
+	for i, r := range code.String() {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// connectHTTPStatus returns the HTTP status of Connect unary calls that fail with a gRPC status code.
+func connectHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// decodeGRPCMessage decodes the percent-encoding of the grpc-message header.
+func decodeGRPCMessage(message string) string {
+	if decoded, err := url.PathUnescape(message); err == nil {
+		return decoded
+	}
+	return message
+}
@@ -18,6 +18,8 @@ var errNotFound = errors.New("Entity not found")
 // httpClient is a convenience wrapper for an HTTP client that GETs and POSTs JSON requests with QuickPizza-specifics.
 type httpClient struct {
 	client *http.Client
+	// internalToken authenticates the requests as made by a QuickPizza service, see WithInternalToken.
+	internalToken string
 }
 
 // getJSON queries the specified URL, expecting a JSON response which gets unmarshalled in dest.
@@ -45,6 +47,10 @@ func (hc httpClient) getJSON(parentCtx context.Context, url string, dest any) er
 
 	if resp.StatusCode == http.StatusNotFound {
 		return errNotFound
+	} else if resp.StatusCode == http.StatusGatewayTimeout {
+		return fmt.Errorf("%w: upstream returned status code %d", errDeadlineExhausted, resp.StatusCode)
+	} else if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: upstream returned status code %d", authError, resp.StatusCode)
 	} else if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
@@ -76,6 +82,9 @@ func (hc httpClient) postJSON(parentCtx context.Context, url string, src any, de
 		return fmt.Errorf("building http request: %w", err)
 	}
 
+	// Retries reuse the request, and thus the key, so endpoints supporting idempotency apply the request only once.
+	request.Header.Set(idempotencyKeyHeader, newIdempotencyKey())
+
 	errorinjector.AddErrorHeaders(parentCtx, request)
 
 	resp, err := hc.do(request)
@@ -90,7 +99,11 @@ func (hc httpClient) postJSON(parentCtx context.Context, url string, src any, de
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusGatewayTimeout {
+		return fmt.Errorf("%w: upstream returned status code %d", errDeadlineExhausted, resp.StatusCode)
+	} else if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: upstream returned status code %d", authError, resp.StatusCode)
+	} else if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
@@ -106,9 +119,18 @@ func (hc httpClient) postJSON(parentCtx context.Context, url string, src any, de
 
 // do performs the supplied request.
 // The supplied request is expected to include on its context the k6 user.
+// If the request context has a deadline, the remaining budget is propagated to the called service. Requests whose
+// budget is already exhausted fail right away with errDeadlineExhausted.
 func (hc httpClient) do(request *http.Request) (*http.Response, error) {
-	// Authenticate request with the super-secret internal token.
+	if err := setRequestTimeoutHeader(request.Context(), request.Header); err != nil {
+		return nil, err
+	}
+
+	// Mark the request as internal, so that its trace context is trusted, and authenticate it with the internal token.
 	request.Header.Add("X-Is-Internal", "1")
+	if hc.internalToken != "" {
+		request.Header.Set(internalTokenHeader, hc.internalToken)
+	}
 
 	// Propagate Authorization if present in request context.
 	if auth, ok := request.Context().Value(authKey).(string); ok {
@@ -136,7 +158,14 @@ func NewCatalogClient(url string) CatalogClient {
 
 // WithClient returns a CatalogClient that uses the specified http.Client, instead of http.DefaultClient.
 func (c CatalogClient) WithClient(client *http.Client) CatalogClient {
-	c.client = httpClient{client: client}
+	c.client.client = client
+	return c
+}
+
+// WithInternalToken returns a CatalogClient that authenticates its requests with the internal token of the Catalog
+// service, so that they are not rate limited and can use internal endpoints.
+func (c CatalogClient) WithInternalToken(token string) CatalogClient {
+	c.client.internalToken = token
 	return c
 }
 
@@ -223,6 +252,29 @@ func (c CatalogClient) Authenticate() (*model.User, error) {
 	return &result, err
 }
 
+// ReserveIdempotencyKey is the Catalog service equivalent of database.Catalog.ReserveIdempotencyKey.
+func (c CatalogClient) ReserveIdempotencyKey(key *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	var result struct {
+		Existing *model.IdempotencyKey `json:"existing"`
+	}
+	err := c.client.postJSON(c.ctx, c.catalogUrl+"/api/internal/idempotency-keys/reserve", key, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Existing, nil
+}
+
+// CompleteIdempotencyKey is the Catalog service equivalent of database.Catalog.CompleteIdempotencyKey.
+func (c CatalogClient) CompleteIdempotencyKey(key *model.IdempotencyKey) error {
+	return c.client.postJSON(c.ctx, c.catalogUrl+"/api/internal/idempotency-keys/complete", key, nil)
+}
+
+// ReleaseIdempotencyKey is the Catalog service equivalent of database.Catalog.ReleaseIdempotencyKey.
+func (c CatalogClient) ReleaseIdempotencyKey(key *model.IdempotencyKey) error {
+	return c.client.postJSON(c.ctx, c.catalogUrl+"/api/internal/idempotency-keys/release", key, nil)
+}
+
 // CopyClient is a client that queries the Copy service.
 type CopyClient struct {
 	copyURL string
@@ -241,7 +293,13 @@ func NewCopyClient(url string) CopyClient {
 
 // WithClient is the Copy service equivalent of CatalogClient.
 func (c CopyClient) WithClient(client *http.Client) CopyClient {
-	c.client = httpClient{client: client}
+	c.client.client = client
+	return c
+}
+
+// WithInternalToken is the Copy service equivalent of CatalogClient.
+func (c CopyClient) WithInternalToken(token string) CopyClient {
+	c.client.internalToken = token
 	return c
 }
 
@@ -278,3 +336,48 @@ func (c CopyClient) Names() ([]string, error) {
 
 	return names.Names, nil
 }
+
+// BannedWords returns the words that reviews must not contain to be published without moderation.
+func (c CopyClient) BannedWords() ([]string, error) {
+	var words struct {
+		BannedWords []string `json:"banned_words"`
+	}
+
+	url := c.copyURL + "/api/banned-words"
+	err := c.client.getJSON(c.ctx, url, &words)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w", url, err)
+	}
+
+	return words.BannedWords, nil
+}
+
+// recommendationCatalog is the recommendation.Catalog of the Recommendations service, backed by the Catalog service.
+type recommendationCatalog struct {
+	client CatalogClient
+}
+
+func (c recommendationCatalog) Ingredients(ctx context.Context, ingredientType string) ([]model.Ingredient, error) {
+	return c.client.WithRequestContext(ctx).Ingredients(ingredientType)
+}
+
+func (c recommendationCatalog) Tools(ctx context.Context) ([]string, error) {
+	return c.client.WithRequestContext(ctx).Tools()
+}
+
+func (c recommendationCatalog) Doughs(ctx context.Context) ([]model.Dough, error) {
+	return c.client.WithRequestContext(ctx).Doughs()
+}
+
+// recommendationCopy is the recommendation.Copy of the Recommendations service, backed by the Copy service.
+type recommendationCopy struct {
+	client CopyClient
+}
+
+func (c recommendationCopy) Adjectives(ctx context.Context) ([]string, error) {
+	return c.client.WithRequestContext(ctx).Adjectives()
+}
+
+func (c recommendationCopy) Names(ctx context.Context) ([]string, error) {
+	return c.client.WithRequestContext(ctx).Names()
+}
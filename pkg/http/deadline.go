@@ -0,0 +1,158 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeoutHeader carries the remaining time budget of a request from one QuickPizza service to the next.
+// Its value uses the same format as the grpc-timeout header: a positive integer of at most 8 digits followed by a
+// unit, which is one of H (hours), M (minutes), S (seconds), m (milliseconds), u (microseconds) or n (nanoseconds).
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// errDeadlineExhausted is returned when the time budget of a request runs out before the work could be done.
+var errDeadlineExhausted = errors.New("request deadline exhausted")
+
+// timeoutUnits lists the units accepted in requestTimeoutHeader, from the coarsest to the finest.
+var timeoutUnits = []struct {
+	unit     byte
+	duration time.Duration
+}{
+	{'H', time.Hour},
+	{'M', time.Minute},
+	{'S', time.Second},
+	{'m', time.Millisecond},
+	{'u', time.Microsecond},
+	{'n', time.Nanosecond},
+}
+
+// parseRequestTimeout parses a grpc-timeout style value into a duration.
+func parseRequestTimeout(v string) (time.Duration, error) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, fmt.Errorf("invalid timeout %q", v)
+	}
+
+	amount, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid timeout %q", v)
+	}
+
+	for _, u := range timeoutUnits {
+		if u.unit == v[len(v)-1] {
+			return time.Duration(amount) * u.duration, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid timeout unit in %q", v)
+}
+
+// formatRequestTimeout formats d as a grpc-timeout style value, using the finest unit that fits in 8 digits.
+func formatRequestTimeout(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+
+	for i := len(timeoutUnits) - 1; i >= 0; i-- {
+		u := timeoutUnits[i]
+		if amount := d / u.duration; amount < 1e8 {
+			return strconv.FormatInt(int64(amount), 10) + string(u.unit)
+		}
+	}
+
+	return "99999999H"
+}
+
+// setRequestTimeoutHeader propagates the remaining budget of ctx, if it has a deadline, into the outgoing request.
+// It returns errDeadlineExhausted if the budget already ran out, in which case the request should not be sent.
+func setRequestTimeoutHeader(ctx context.Context, header http.Header) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return errDeadlineExhausted
+	}
+
+	header.Set(requestTimeoutHeader, formatRequestTimeout(remaining))
+	return nil
+}
+
+// isDeadlineError returns whether err was caused by a request running out of time.
+func isDeadlineError(err error) bool {
+	return errors.Is(err, errDeadlineExhausted) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// errorStatus returns the HTTP status code that should be used to report err: 504 if the request ran out of time,
+// 401 if another service rejected the credentials of the request, and fallback otherwise.
+func errorStatus(err error, fallback int) int {
+	if isDeadlineError(err) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, authError) {
+		return http.StatusUnauthorized
+	}
+	return fallback
+}
+
+// deadlineMiddleware attaches a deadline to the context of incoming requests. The deadline is taken from the budget
+// in requestTimeoutHeader if the caller sent one, or from s.requestTimeout otherwise. Only other QuickPizza services
+// can extend the budget beyond s.requestTimeout, as they forward what is left of the budget of the original request;
+// clients can only shorten it. Requests whose budget is already exhausted are rejected right away with 504, without
+// doing any work.
+//
+// WebSocket connections and gRPC-Web and Connect calls, which may stream for as long as they like, are left alone. The
+// latter carry deadlines of their own, applied by the gRPC server.
+func (s *Server) deadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isLongLived(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		budget := s.requestTimeout
+
+		if v := r.Header.Get(requestTimeoutHeader); v != "" {
+			requested, err := parseRequestTimeout(v)
+			if err != nil {
+				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				return
+			}
+
+			if budget <= 0 || requested < budget || s.isInternalRequest(r) {
+				budget = requested
+			}
+
+			if budget <= 0 {
+				s.log.WarnContext(r.Context(), "Rejecting request with exhausted deadline")
+				s.writeJSONErrorResponse(w, r, errDeadlineExhausted, http.StatusGatewayTimeout)
+				return
+			}
+		}
+
+		if budget <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), budget)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isLongLived returns whether r is a WebSocket upgrade or a gRPC-Web or Connect call, which may last for as long as
+// the connection or the stream.
+func (s *Server) isLongLived(r *http.Request) bool {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return true
+	}
+	return s.grpcWebPrefix != "" && strings.HasPrefix(r.URL.Path, s.grpcWebPrefix)
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "1H", want: time.Hour},
+		{value: "2M", want: 2 * time.Minute},
+		{value: "30S", want: 30 * time.Second},
+		{value: "250m", want: 250 * time.Millisecond},
+		{value: "100u", want: 100 * time.Microsecond},
+		{value: "5n", want: 5},
+		{value: "0m", want: 0},
+		{value: "99999999S", want: 99999999 * time.Second},
+		{value: "", wantErr: true},
+		{value: "S", wantErr: true},
+		{value: "100", wantErr: true},
+		{value: "100s", wantErr: true},
+		{value: "100ms", wantErr: true},
+		{value: "-1S", wantErr: true},
+		{value: "+1S", want: time.Second},
+		{value: "1.5S", wantErr: true},
+		{value: "123456789S", wantErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseRequestTimeout(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: -time.Second, want: "0n"},
+		{d: 0, want: "0n"},
+		{d: 42 * time.Millisecond, want: "42000000n"},
+		{d: 1500 * time.Millisecond, want: "1500000u"},
+		{d: 3 * time.Minute, want: "180000m"},
+		{d: 48 * time.Hour, want: "172800S"},
+		{d: 1 << 62, want: "76861433M"},
+	} {
+		t.Run(tc.want, func(t *testing.T) {
+			t.Parallel()
+
+			got := formatRequestTimeout(tc.d)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+
+			// The formatted value is truncated to its unit, and parses back.
+			parsed, err := parseRequestTimeout(got)
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %v", got, err)
+			}
+			if parsed > max(tc.d, 0) {
+				t.Fatalf("%q parses to %s, more than %s", got, parsed, tc.d)
+			}
+		})
+	}
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	t.Parallel()
+
+	const internalToken = "internal-token"
+
+	for _, tc := range []struct {
+		name           string
+		requestTimeout time.Duration
+		path           string
+		header         http.Header
+		wantStatus     int
+		// wantBudget is the time the handler is given, or 0 if its context must have no deadline.
+		wantBudget time.Duration
+	}{
+		{
+			name:       "no timeout",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:           "server timeout",
+			requestTimeout: time.Minute,
+			wantStatus:     http.StatusOK,
+			wantBudget:     time.Minute,
+		},
+		{
+			name:       "client timeout without server timeout",
+			header:     http.Header{requestTimeoutHeader: {"30S"}},
+			wantStatus: http.StatusOK,
+			wantBudget: 30 * time.Second,
+		},
+		{
+			name:           "client shortens server timeout",
+			requestTimeout: time.Minute,
+			header:         http.Header{requestTimeoutHeader: {"30S"}},
+			wantStatus:     http.StatusOK,
+			wantBudget:     30 * time.Second,
+		},
+		{
+			name:           "client cannot extend server timeout",
+			requestTimeout: time.Minute,
+			header:         http.Header{requestTimeoutHeader: {"1H"}},
+			wantStatus:     http.StatusOK,
+			wantBudget:     time.Minute,
+		},
+		{
+			name:           "client cannot extend server timeout with forged internal header",
+			requestTimeout: time.Minute,
+			header:         http.Header{requestTimeoutHeader: {"1H"}, "X-Is-Internal": {"1"}, internalTokenHeader: {"wrong"}},
+			wantStatus:     http.StatusOK,
+			wantBudget:     time.Minute,
+		},
+		{
+			name:           "service extends server timeout",
+			requestTimeout: time.Minute,
+			header:         http.Header{requestTimeoutHeader: {"1H"}, internalTokenHeader: {internalToken}},
+			wantStatus:     http.StatusOK,
+			wantBudget:     time.Hour,
+		},
+		{
+			name:           "exhausted budget",
+			requestTimeout: time.Minute,
+			header:         http.Header{requestTimeoutHeader: {"0m"}},
+			wantStatus:     http.StatusGatewayTimeout,
+		},
+		{
+			name:       "invalid timeout",
+			header:     http.Header{requestTimeoutHeader: {"soon"}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "WebSocket upgrade",
+			requestTimeout: time.Minute,
+			header:         http.Header{"Upgrade": {"websocket"}, requestTimeoutHeader: {"1S"}},
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name:           "gRPC-Web call",
+			requestTimeout: time.Minute,
+			path:           "/grpc/quickpizza.QuickPizza/StreamRecommendations",
+			wantStatus:     http.StatusOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newTestServer(t, WithDeadlinePropagation(tc.requestTimeout), WithInternalToken(internalToken))
+			s.grpcWebPrefix = "/grpc/"
+
+			var called bool
+			var budget time.Duration
+			handler := s.deadlineMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if deadline, ok := r.Context().Deadline(); ok {
+					budget = time.Until(deadline)
+				}
+			}))
+
+			path := tc.path
+			if path == "" {
+				path = "/api/pizza"
+			}
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			for name, values := range tc.header {
+				r.Header[name] = values
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, w.Code, w.Body)
+			}
+			if called != (tc.wantStatus == http.StatusOK) {
+				t.Fatalf("expected the handler to be called: %t, got %t", !called, called)
+			}
+			if tc.wantBudget == 0 && budget != 0 {
+				t.Fatalf("expected no deadline, got one in %s", budget)
+			}
+			if tc.wantBudget != 0 && (budget > tc.wantBudget || budget < tc.wantBudget-time.Second) {
+				t.Fatalf("expected a deadline in %s, got one in %s", tc.wantBudget, budget)
+			}
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "deadline exhausted", err: errDeadlineExhausted, want: http.StatusGatewayTimeout},
+		{name: "authentication", err: authError, want: http.StatusUnauthorized},
+		{name: "other", err: errInvalidBody, want: http.StatusTeapot},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := errorStatus(tc.err, http.StatusTeapot); got != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
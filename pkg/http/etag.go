@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+var (
+	errPreconditionFailed   = withProblemCode("version_mismatch", errors.New("the resource was changed, fetch it again to get its current ETag"))
+	errPreconditionRequired = withProblemCode("if_match_required", errors.New("the If-Match header is required"))
+)
+
+// WithIfMatchRequired makes updates and deletions of ratings fail with 428 unless they carry an If-Match header, so
+// that clients cannot overwrite changes they have not seen.
+func WithIfMatchRequired() ServerOption {
+	return func(s *Server) {
+		s.ifMatchRequired = true
+	}
+}
+
+// ratingETag returns the entity tag of the current version of a rating.
+func ratingETag(rating *model.Rating) string {
+	return fmt.Sprintf(`"v%d"`, rating.Version)
+}
+
+// ifMatchVersion returns the rating version the request is conditional on, taken from its If-Match header: 0 if the
+// header is missing or "*", which matches any version. ok is false if the request must be rejected: because the
+// header is required and missing (with 428), or because it cannot match any version (with 412).
+func (s *Server) ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int64, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if s.ifMatchRequired {
+			s.writeJSONErrorResponse(w, r, errPreconditionRequired, http.StatusPreconditionRequired)
+			return 0, false
+		}
+		return 0, true
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return 0, true
+	}
+
+	// Only a single, strong entity tag can match, as a rating has a single current version.
+	tag := strings.TrimSpace(header)
+	if strings.HasPrefix(tag, `"v`) && strings.HasSuffix(tag, `"`) {
+		if v, err := strconv.ParseInt(tag[2:len(tag)-1], 10, 64); err == nil && v > 0 {
+			return v, true
+		}
+	}
+
+	s.writeJSONErrorResponse(w, r, errPreconditionFailed, http.StatusPreconditionFailed)
+	return 0, false
+}
+
+// etagMatches returns whether any of the entity tags in an If-None-Match header matches etag, using the weak
+// comparison: W/ prefixes are ignored.
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalGet adds an ETag to successful GET responses, unless the handler already set one, and responds with 304
+// Not Modified if it matches the If-None-Match header of the request. ETags set by this middleware are hashes of the
+// response body, so the response is still computed in full; only sending it is saved.
+func conditionalGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+
+		if bw.status != 0 && bw.status != http.StatusOK {
+			w.WriteHeader(bw.status)
+			_, _ = w.Write(bw.body.Bytes())
+			return
+		}
+
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(bw.body.Bytes())
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+		}
+
+		if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(bw.body.Bytes())
+	})
+}
+
+// bufferedResponseWriter holds back the response, so that headers can still be changed once the body is known.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
@@ -0,0 +1,29 @@
+package http
+
+import "time"
+
+// Faults are artificial delays and failures injected in the services, to see how they show up in tests and telemetry.
+// The delay of a service applies to all of its requests, on top of the delays of its endpoints.
+type Faults struct {
+	CopyDelay           time.Duration
+	CopyQuotesDelay     time.Duration
+	CopyNamesDelay      time.Duration
+	CopyAdjectivesDelay time.Duration
+
+	RecommendationsDelay time.Duration
+	// RecommendationsGetDelay and RecommendationsPostDelay delay GET /api/pizza/{id} and POST /api/pizza respectively.
+	RecommendationsGetDelay  time.Duration
+	RecommendationsPostDelay time.Duration
+	// RecommendationsPostFailRate is the percentage of POST /api/pizza requests that fail with a 503.
+	RecommendationsPostFailRate int
+
+	FrontendCSSDelay time.Duration
+	FrontendPNGDelay time.Duration
+}
+
+// WithFaults injects faults in the services of the server.
+func WithFaults(faults Faults) ServerOption {
+	return func(s *Server) {
+		s.faults = faults
+	}
+}
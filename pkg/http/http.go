@@ -16,11 +16,14 @@ import (
 	"net/http"
 	"net/http/httputil"
 	_ "net/http/pprof"
+	"net/netip"
 	"net/url"
 	"os"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"log/slog"
@@ -33,16 +36,19 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/rs/xid"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
 
 	k6 "github.com/grafana/pyroscope-go/x/k6"
 	"github.com/grafana/quickpizza/pkg/database"
 	"github.com/grafana/quickpizza/pkg/errorinjector"
+	"github.com/grafana/quickpizza/pkg/jwt"
 	"github.com/grafana/quickpizza/pkg/logging"
 	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/moderation"
+	"github.com/grafana/quickpizza/pkg/openapi"
+	"github.com/grafana/quickpizza/pkg/password"
+	"github.com/grafana/quickpizza/pkg/recommendation"
 	"github.com/grafana/quickpizza/pkg/util"
 	"github.com/grafana/quickpizza/pkg/web"
 )
@@ -166,42 +172,18 @@ type PizzaRecommendation struct {
 	Vegetarian bool        `json:"vegetarian"`
 }
 
-// Restrictions are sent by the client to further specify how the target pizza should look like
-type Restrictions struct {
-	MaxCaloriesPerSlice int      `json:"maxCaloriesPerSlice"`
-	MustBeVegetarian    bool     `json:"mustBeVegetarian"`
-	ExcludedIngredients []string `json:"excludedIngredients"`
-	ExcludedTools       []string `json:"excludedTools"`
-	MaxNumberOfToppings int      `json:"maxNumberOfToppings"`
-	MinNumberOfToppings int      `json:"minNumberOfToppings"`
-	CustomName          string   `json:"customName"`
-}
-
-func (r Restrictions) WithDefaults() Restrictions {
-	if r.MaxCaloriesPerSlice == 0 {
-		r.MaxCaloriesPerSlice = 1000
-	}
-	if r.MaxNumberOfToppings == 0 {
-		r.MaxNumberOfToppings = 5
-	}
-	if r.MinNumberOfToppings == 0 {
-		r.MinNumberOfToppings = 3
-	}
-
-	return r
-}
-
 type authKeyType int
 type userKeyType int
 
 const (
-	authKey           authKeyType = 0
-	userKey           userKeyType = 0
-	authHeader                    = "Authorization"
-	qpUserTokenCookie             = "qp_user_token"
-	csrfTokenCookie               = "csrf_token"
-	piDecimals                    = "1415926535897932384626433832795028841971693993751058209749445923078164"
-	csrfTokenLength               = 32
+	authKey              authKeyType = 0
+	userKey              userKeyType = 0
+	authHeader                       = "Authorization"
+	qpUserTokenCookie                = "qp_user_token"
+	qpRefreshTokenCookie             = "qp_refresh_token"
+	csrfTokenCookie                  = "csrf_token"
+	piDecimals                       = "1415926535897932384626433832795028841971693993751058209749445923078164"
+	csrfTokenLength                  = 32
 )
 
 var authError = errors.New("authentication failed")
@@ -218,7 +200,7 @@ func requestTokenFromCookie(r *http.Request) string {
 func getRequestToken(r *http.Request) string {
 	// Try extracting token from Cookies first.
 	cookie_token := requestTokenFromCookie(r)
-	if len(cookie_token) == model.UserTokenLength {
+	if len(cookie_token) == model.UserTokenLength || jwt.LooksLikeJWT(cookie_token) {
 		return cookie_token
 	}
 
@@ -227,7 +209,11 @@ func getRequestToken(r *http.Request) string {
 	prefix, token, found := strings.Cut(auth, " ")
 	prefix = strings.ToLower(prefix)
 
-	if !found || (prefix != "token" && prefix != "bearer") || len(token) != model.UserTokenLength {
+	if !found || (prefix != "token" && prefix != "bearer") {
+		return ""
+	}
+
+	if len(token) != model.UserTokenLength && !jwt.LooksLikeJWT(token) {
 		return ""
 	}
 
@@ -262,21 +248,81 @@ type Server struct {
 	traceInstaller *OTelInstaller
 	router         chi.Router
 	melody         *melody.Melody
+
+	httpServer       *http.Server
+	draining         atomic.Bool
+	shutdownDelay    time.Duration
+	onShutdown       []func()
+	webSocketsMu     sync.Mutex
+	webSocketsClosed bool
+	webSockets       sync.WaitGroup
+
+	probeConfig     ProbeConfig
+	readinessChecks []namedCheck
+	startupChecks   []namedCheck
+
+	deadlines      bool
+	requestTimeout time.Duration
+	grpcWebPrefix  string
+
+	rateLimiter *rateLimiter
+
+	internalToken  string
+	trustedProxies []netip.Prefix
+
+	passwordPolicy password.Policy
+
+	ifMatchRequired bool
+
+	openAPI           *openapi.Document
+	validateRequests  bool
+	validateResponses bool
+
+	jwtSigner   *jwt.Signer
+	jwtVerifier *jwt.Verifier
+	jwksSigners []*jwt.Signer
+
+	faults Faults
+}
+
+// ServerOption configures optional, server-wide behavior. Options are applied by NewServer, before any route is
+// registered.
+type ServerOption func(*Server)
+
+// WithDeadlinePropagation makes the server honor and propagate request deadlines. Incoming requests get a deadline
+// from the X-Request-Timeout header or, if they do not carry one, from defaultTimeout (0 means no deadline).
+// Requests made to other QuickPizza services carry the remaining budget, and fail fast once it is exhausted.
+func WithDeadlinePropagation(defaultTimeout time.Duration) ServerOption {
+	return func(s *Server) {
+		s.deadlines = true
+		s.requestTimeout = defaultTimeout
+	}
 }
 
-func NewServer(profiling bool, traceInstaller *OTelInstaller) *Server {
+func NewServer(profiling bool, traceInstaller *OTelInstaller, opts ...ServerOption) *Server {
 	logger := slog.New(logging.NewContextLogger(slog.Default().Handler()))
 
+	s := &Server{
+		traceInstaller: traceInstaller,
+		melody:         melody.New(),
+		log:            logger,
+		passwordPolicy: password.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	reqLogger := httplog.NewLogger("quickpizza", httplog.Options{
 		JSON:             true,
 		Writer:           os.Stderr,
-		LogLevel:         logging.GetLogLevel(),
+		LogLevel:         logging.Level.Level(),
 		Concise:          true,
 		RequestHeaders:   false,
 		MessageFieldName: "message",
 		QuietDownRoutes: []string{
 			"/",
 			"/ready",
+			"/started",
 			"/healthz",
 			"/metrics",
 			"/contacts.php",
@@ -303,15 +349,43 @@ func NewServer(profiling bool, traceInstaller *OTelInstaller) *Server {
 		httplog.RequestLogger(reqLogger),
 		middleware.Recoverer,
 		cors.New(cors.Options{
-			AllowedOrigins:   []string{"*"},
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", authHeader, "Content-Type", "X-CSRF-Token"},
-			ExposedHeaders:   []string{"Link"},
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{
+				"Accept", authHeader, "Content-Type", "X-CSRF-Token", requestTimeoutHeader, idempotencyKeyHeader, "If-Match", "If-None-Match",
+				// gRPC-Web and Connect calls of the gRPC service
+				"X-Grpc-Web", "X-User-Agent", "Grpc-Timeout", "Connect-Protocol-Version", "Connect-Timeout-Ms",
+			},
+			ExposedHeaders: []string{
+				"Link",
+				"ETag",
+				"Retry-After",
+				"RateLimit-Limit",
+				"RateLimit-Remaining",
+				"RateLimit-Reset",
+				"RateLimit-Policy",
+				idempotentReplayedHeader,
+				"Grpc-Status",
+				"Grpc-Message",
+				"Grpc-Status-Details-Bin",
+			},
 			AllowCredentials: true,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		}).Handler,
 	)
 
+	if s.deadlines {
+		router.Use(s.deadlineMiddleware)
+	}
+
+	if s.rateLimiter != nil {
+		router.Use(s.rateLimitMiddleware)
+	}
+
+	if s.openAPI != nil {
+		router.Use(s.openAPIMiddleware)
+	}
+
 	if profiling {
 		router.Use(k6.LabelsFromBaggageHandler)
 	} else {
@@ -319,12 +393,14 @@ func NewServer(profiling bool, traceInstaller *OTelInstaller) *Server {
 		router.Mount("/debug/pprof/", http.DefaultServeMux)
 	}
 
-	return &Server{
-		traceInstaller: traceInstaller,
-		router:         router,
-		melody:         melody.New(),
-		log:            logger,
-	}
+	router.NotFound(s.notFound)
+	router.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSONErrorResponse(w, r, fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+	})
+
+	s.router = router
+	s.httpServer = &http.Server{Handler: s}
+	return s
 }
 
 func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
@@ -332,26 +408,18 @@ func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) decodeJSONBody(w http.ResponseWriter, r *http.Request, v any) error {
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
-	err := dec.Decode(v)
-	if err != nil {
-		s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
-		return err
+	if !s.decodeJSON(w, r, r.Body, v) {
+		return errInvalidBody
 	}
 	return nil
 }
 
-func (s *Server) writeJSONErrorResponse(w http.ResponseWriter, r *http.Request, err error, status int) {
-	s.writeJSONResponse(w, r, map[string]string{"error": err.Error()}, status)
-}
-
 func (s *Server) writeJSONResponse(w http.ResponseWriter, r *http.Request, v any, status int) {
 	buf := bytes.Buffer{}
 	err := json.NewEncoder(&buf).Encode(v)
 	if err != nil {
 		s.log.ErrorContext(r.Context(), "Failed to encode JSON response", "err", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -368,7 +436,7 @@ func (s *Server) writeXMLResponse(w http.ResponseWriter, r *http.Request, v any,
 	err := xml.NewEncoder(&buf).Encode(v)
 	if err != nil {
 		s.log.ErrorContext(r.Context(), "Failed to encode XML response", "err", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
 		return
 	}
 
@@ -381,9 +449,15 @@ func (s *Server) writeXMLResponse(w http.ResponseWriter, r *http.Request, v any,
 }
 
 func (s *Server) AddLivenessProbes() {
-	// Readiness probe
+	// Readiness probe, which checks the dependencies of the services enabled, see AddReadinessCheck. The server is not
+	// ready anymore once it is shutting down, see Shutdown.
 	s.router.Get("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		s.serveProbe(w, r, s.readinessChecks)
+	})
+
+	// Startup probe, see AddStartupCheck.
+	s.router.Get("/started", func(w http.ResponseWriter, r *http.Request) {
+		s.serveProbe(w, r, s.startupChecks)
 	})
 
 	// Liveness probe
@@ -412,10 +486,10 @@ func (s *Server) AddFrontend(devMode bool) {
 
 		if devMode {
 			// In dev mode, proxy to Vite dev server
-			r.Handle("/*", ViteProxyHandler())
+			r.Handle("/*", s.apiNotFound(ViteProxyHandler()))
 		} else {
 			// Production: serve embedded files
-			r.Handle("/*", SvelteKitHandler())
+			r.Handle("/*", s.apiNotFound(SvelteKitHandler(s.faults)))
 		}
 	})
 }
@@ -445,8 +519,9 @@ func (s *Server) AddGateway(catalogUrl, copyUrl, wsUrl, recommendationsUrl, conf
 			otelhttp.WithPropagators(propagation.TraceContext{}),
 		)
 
-		r.Handle("/api/*", &httputil.ReverseProxy{
-			Transport: otelTransport,
+		apiProxy := &httputil.ReverseProxy{
+			Transport:    otelTransport,
+			ErrorHandler: s.proxyErrorHandler,
 			Rewrite: func(request *httputil.ProxyRequest) {
 				var u *url.URL
 				s.log.Debug("Reverse Proxy Request", "endpoint", request.In.URL.Path)
@@ -476,8 +551,29 @@ func (s *Server) AddGateway(catalogUrl, copyUrl, wsUrl, recommendationsUrl, conf
 
 				// Mark outgoing requests as internal so trace context is trusted.
 				request.Out.Header.Add("X-Is-Internal", "1")
+				// Requests from outside are never authenticated as coming from another service.
+				request.Out.Header.Del(internalTokenHeader)
+
+				// Let services know the original host and scheme, e.g. to build absolute URLs.
+				request.SetXForwarded()
+
+				// Forward whatever is left of the request budget. If it already ran out, the transport will fail
+				// the request before it is sent.
+				_ = setRequestTimeoutHeader(request.In.Context(), request.Out.Header)
 			},
-		})
+		}
+
+		// With JWT authentication, tokens are verified at the edge, so that requests carrying invalid or expired ones
+		// do not reach any service.
+		var apiHandler http.Handler = apiProxy
+		if s.jwtVerifier != nil {
+			apiHandler = s.jwtGatewayMiddleware(apiProxy)
+		}
+
+		r.Handle("/api/*", apiHandler)
+		// Well-known endpoints, such as the JWKS one, and the OpenID Connect provider are served by the Catalog service.
+		r.Handle("/.well-known/*", apiHandler)
+		r.Handle("/oauth2/*", apiHandler)
 
 		r.Handle("/ws", &httputil.ReverseProxy{
 			Transport: otelTransport,
@@ -489,18 +585,26 @@ func (s *Server) AddGateway(catalogUrl, copyUrl, wsUrl, recommendationsUrl, conf
 	})
 }
 
+// proxyErrorHandler reports errors that occurred while proxying a request to another service.
+func (s *Server) proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	s.log.ErrorContext(r.Context(), "Proxying request", "err", err)
+	s.writeJSONErrorResponse(w, r, withProblemCode("upstream_unavailable", errors.New("upstream service unavailable")), errorStatus(err, http.StatusBadGateway))
+}
+
 // AddWebSocket enables serving and handle websockets.
 func (s *Server) AddWebSocket() {
 	s.router.Group(func(r chi.Router) {
 		s.traceInstaller.Install(r, "ws", excludeWebSocketFromOTel())
 
 		r.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
-			err := s.melody.HandleRequest(w, r)
+			ok, err := s.handleWebSocket(w, r)
+			if !ok {
+				s.writeJSONErrorResponse(w, r, withProblemCode("service_unavailable", errors.New("the server is shutting down")), http.StatusServiceUnavailable)
+				return
+			}
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Upgrading request to WS", "err", err)
-
-				w.WriteHeader(http.StatusInternalServerError)
-				_, _ = fmt.Fprint(w, err)
+				s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
 			}
 		})
 	})
@@ -528,6 +632,27 @@ func (s *Server) AddWebSocket() {
 	})
 }
 
+// AddGRPCWeb serves the gRPC-Web and Connect calls of the gRPC service under prefix, e.g. /quickpizza.GRPC/, with a
+// handler of the gRPC server. Its calls are traced, measured and logged by the gRPC server, like the native ones, so
+// the group is not instrumented with OpenTelemetry.
+func (s *Server) AddGRPCWeb(prefix string, handler http.Handler) {
+	s.grpcWebPrefix = prefix
+	s.router.Handle(prefix+"*", handler)
+}
+
+// AddGraphQL serves the GraphQL API at /graphql, with a handler of the graphql package. WebSocket connections, which
+// last as long as their subscriptions, are not traced as a whole: their operations are traced on their own.
+func (s *Server) AddGraphQL(handler http.Handler) {
+	s.router.Group(func(r chi.Router) {
+		s.traceInstaller.Install(r, "graphql", otelhttp.WithFilter(func(r *http.Request) bool {
+			return !strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+		}))
+		r.Use(errorinjector.InjectErrorHeadersMiddleware)
+
+		r.Handle("/graphql", handler)
+	})
+}
+
 // AddTestK6IO enables routes for replacing the legacy test.k6.io service.
 // It tries to follow https://github.com/grafana/test.k6.io as closely as possible,
 // even though the original service was implemented in PHP. For this reason, the paths
@@ -616,12 +741,12 @@ func (s *Server) AddHTTPTesting() {
 		r.HandleFunc("/api/status/{status:\\d+}", func(w http.ResponseWriter, r *http.Request) {
 			status, err := strconv.Atoi(chi.URLParam(r, "status"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "status")
 				return
 			}
 
 			if status < 100 || status > 599 {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeJSONErrorResponse(w, r, model.ValidationError{{Field: "status", Detail: "status must be between 100 and 599"}}, http.StatusBadRequest)
 				return
 			}
 
@@ -648,7 +773,7 @@ func (s *Server) AddHTTPTesting() {
 			if err != nil {
 				delay, err = time.ParseDuration(param + "s")
 				if err != nil {
-					w.WriteHeader(http.StatusBadRequest)
+					s.writeInvalidParamResponse(w, r, "delay")
 					return
 				}
 			}
@@ -762,8 +887,26 @@ func (s *Server) AuthViaCatalogClientMiddleware(catalogClient CatalogClient) fun
 
 			ctx := context.WithValue(r.Context(), authKey, r.Header.Get(authHeader))
 
-			user, err := catalogClient.WithRequestContext(ctx).Authenticate()
-			if err != nil {
+			// JWTs can be verified locally, without asking the Catalog service.
+			start := time.Now()
+			mode := "remote"
+			var user *model.User
+			var err error
+			if token := getRequestToken(r); s.jwtVerifier != nil && jwt.LooksLikeJWT(token) {
+				mode = "local"
+				user, err = s.verifyJWTUser(ctx, token)
+			} else {
+				user, err = catalogClient.WithRequestContext(ctx).Authenticate()
+			}
+			authDuration.WithLabelValues(mode).Observe(time.Since(start).Seconds())
+
+			if isDeadlineError(err) {
+				s.writeJSONErrorResponse(w, r, errDeadlineExhausted, http.StatusGatewayTimeout)
+				return
+			} else if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
 				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
 				return
 			}
@@ -782,8 +925,14 @@ func (s *Server) AuthMiddleware(db *database.Catalog) func(http.Handler) http.Ha
 				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
 				return
 			}
-			user, err := db.Authenticate(r.Context(), token)
-			if err != nil {
+			user, err := s.authenticate(r.Context(), db, token)
+			if isDeadlineError(err) {
+				s.writeJSONErrorResponse(w, r, errDeadlineExhausted, http.StatusGatewayTimeout)
+				return
+			} else if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
 				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
 				return
 			}
@@ -797,27 +946,36 @@ func (s *Server) AuthMiddleware(db *database.Catalog) func(http.Handler) http.Ha
 // AddCatalogHandler enables routes related to the ingredients, doughs, tools, ratings and users.
 // A database.InMemoryDatabase is required to enable this endpoint group.
 // This database is safe to be used concurrently and thus may be shared with other endpoint groups.
-func (s *Server) AddCatalogHandler(db *database.Catalog) {
+// Reviews of ratings are moderated with the banned words of the Copy service, queried with copyClient.
+func (s *Server) AddCatalogHandler(db *database.Catalog, copyClient CopyClient) {
+	if s.jwtSigner != nil {
+		s.addJWKS(s.jwtSigner)
+	}
+
+	moderator := moderation.New(func(ctx context.Context) ([]string, error) {
+		return copyClient.WithRequestContext(ctx).BannedWords()
+	}, s.log)
+
 	s.router.Group(func(r chi.Router) {
 		s.traceInstaller.Install(r, "catalog")
 
 		r.Use(s.AuthMiddleware(db))
 		r.Use(LogUser)
 		r.Use(errorinjector.InjectErrorHeadersMiddleware)
+		r.Use(conditionalGet)
 
 		r.Get("/api/ingredients/{type}", func(w http.ResponseWriter, r *http.Request) {
 			ingredientType := chi.URLParam(r, "type")
 			ingredients, err := db.GetIngredients(r.Context(), ingredientType)
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to get ingredients from database", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
 			if len(ingredients) == 0 {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeJSONErrorResponse(w, r, fmt.Errorf("unknown ingredient type %q", ingredientType), http.StatusBadRequest)
 				slog.Warn("Did not find any ingredients", "type", ingredientType)
-				_, _ = fmt.Fprintf(w, "Unknown ingredient %q", ingredientType)
 				return
 			}
 
@@ -832,7 +990,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 			doughs, err := db.GetDoughs(r.Context())
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to get doughs from database", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -845,7 +1003,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 			tools, err := db.GetTools(r.Context())
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to get tools from database", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -853,7 +1011,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 		})
 
 		// Rating CRUD endpoints
-		r.Post("/api/ratings", func(w http.ResponseWriter, r *http.Request) {
+		r.With(s.idempotent(db)).Post("/api/ratings", func(w http.ResponseWriter, r *http.Request) {
 			user := contextUser(r.Context())
 			if user == nil {
 				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
@@ -870,20 +1028,27 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
-			rating.UserID = user.ID
+			rating.Status = moderator.Moderate(r.Context(), rating.Review)
 
-			if err := db.RecordRating(r.Context(), &rating); err != nil {
+			err := db.RecordRating(r.Context(), user, &rating)
+			var exists *database.RatingExistsError
+			if errors.As(err, &exists) {
+				w.Header().Set("Location", fmt.Sprintf("/api/ratings/%d", exists.RatingID))
+				s.writeJSONErrorResponse(w, r, withProblemCode("rating_exists", err), http.StatusConflict)
+				return
+			} else if err != nil {
 				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
 				return
 			}
 
+			w.Header().Set("ETag", ratingETag(&rating))
 			s.writeJSONResponse(w, r, rating, http.StatusCreated)
 		})
 
 		r.Get("/api/ratings/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
 			idParam, err := strconv.Atoi(chi.URLParam(r, "id"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "id")
 				return
 			}
 
@@ -898,10 +1063,11 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
 				return
 			} else if rating == nil {
-				s.writeJSONErrorResponse(w, r, errors.New("not found"), http.StatusNotFound)
+				s.writeJSONErrorResponse(w, r, database.ErrRatingNotFound, http.StatusNotFound)
 				return
 			}
 
+			w.Header().Set("ETag", ratingETag(rating))
 			s.writeJSONResponse(w, r, rating, http.StatusOK)
 		})
 
@@ -924,7 +1090,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 		updateRating := func(w http.ResponseWriter, r *http.Request) {
 			idParam, err := strconv.Atoi(chi.URLParam(r, "id"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "id")
 				return
 			}
 
@@ -944,35 +1110,104 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
+			ifVersion, ok := s.ifMatchVersion(w, r)
+			if !ok {
+				return
+			}
+
 			rating.ID = int64(idParam)
+			rating.Status = moderator.Moderate(r.Context(), rating.Review)
 
-			updated, err := db.UpdateRating(r.Context(), user, &rating)
+			updated, err := db.UpdateRating(r.Context(), user, &rating, ifVersion)
 			if err != nil {
 				if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
 					s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				} else if errors.Is(err, database.ErrRatingVersionMismatch) {
+					s.writeJSONErrorResponse(w, r, errPreconditionFailed, http.StatusPreconditionFailed)
+				} else if errors.Is(err, database.ErrRatingNotFound) {
+					s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
 				} else {
 					s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
 				}
 				return
 			}
 
+			w.Header().Set("ETag", ratingETag(updated))
 			s.writeJSONResponse(w, r, updated, http.StatusOK)
 		}
 
-		r.Post("/api/users/token/logout", func(w http.ResponseWriter, r *http.Request) {
-			http.SetCookie(w, &http.Cookie{
-				Name:     qpUserTokenCookie,
-				Value:    "",
-				SameSite: http.SameSiteStrictMode,
-				Path:     "/",
-				Expires:  time.Unix(0, 0),
-			})
+		r.Put("/api/ratings/{id:\\d+}", updateRating)
 
-			w.WriteHeader(http.StatusOK)
-		})
+		r.Patch("/api/ratings/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
+			idParam, err := strconv.Atoi(chi.URLParam(r, "id"))
+			if err != nil {
+				s.writeInvalidParamResponse(w, r, "id")
+				return
+			}
 
-		r.Put("/api/ratings/{id:\\d+}", updateRating)
-		r.Patch("/api/ratings/{id:\\d+}", updateRating)
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			ifVersion, ok := s.ifMatchVersion(w, r)
+			if !ok {
+				return
+			}
+
+			existing, err := db.GetRating(r.Context(), user, idParam)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to get rating", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			} else if existing == nil {
+				s.writeJSONErrorResponse(w, r, database.ErrRatingNotFound, http.StatusNotFound)
+				return
+			}
+
+			if ifVersion != 0 && ifVersion != existing.Version {
+				s.writeJSONErrorResponse(w, r, errPreconditionFailed, http.StatusPreconditionFailed)
+				return
+			}
+
+			rating := *existing
+			if !s.applyPatch(w, r, &rating) {
+				return
+			}
+
+			if rating.ID != existing.ID || rating.PizzaID != existing.PizzaID || rating.Status != existing.Status || rating.Version != existing.Version {
+				s.writeJSONErrorResponse(w, r, fmt.Errorf("%w: only stars and review can be changed", errReadOnlyField), http.StatusUnprocessableEntity)
+				return
+			}
+
+			if err := rating.Validate(); err != nil {
+				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				return
+			}
+
+			rating.Status = moderator.Moderate(r.Context(), rating.Review)
+
+			// The patch was applied to the version that was read, so it must not overwrite any change made since.
+			updated, err := db.UpdateRating(r.Context(), user, &rating, existing.Version)
+			if err != nil {
+				if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+					s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				} else if errors.Is(err, database.ErrRatingVersionMismatch) && ifVersion != 0 {
+					s.writeJSONErrorResponse(w, r, errPreconditionFailed, http.StatusPreconditionFailed)
+				} else if errors.Is(err, database.ErrRatingVersionMismatch) {
+					s.writeJSONErrorResponse(w, r, withProblemCode("version_mismatch", err), http.StatusConflict)
+				} else if errors.Is(err, database.ErrRatingNotFound) {
+					s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
+				} else {
+					s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				}
+				return
+			}
+
+			w.Header().Set("ETag", ratingETag(updated))
+			s.writeJSONResponse(w, r, updated, http.StatusOK)
+		})
 
 		r.Delete("/api/ratings", func(w http.ResponseWriter, r *http.Request) {
 			user := contextUser(r.Context())
@@ -997,7 +1232,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 		r.Delete("/api/ratings/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
 			idParam, err := strconv.Atoi(chi.URLParam(r, "id"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "id")
 				return
 			}
 
@@ -1007,10 +1242,19 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
-			err = db.DeleteRating(r.Context(), user, idParam)
+			ifVersion, ok := s.ifMatchVersion(w, r)
+			if !ok {
+				return
+			}
+
+			err = db.DeleteRating(r.Context(), user, idParam, ifVersion)
 			if err != nil {
 				if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
 					s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				} else if errors.Is(err, database.ErrRatingVersionMismatch) {
+					s.writeJSONErrorResponse(w, r, errPreconditionFailed, http.StatusPreconditionFailed)
+				} else if errors.Is(err, database.ErrRatingNotFound) {
+					s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
 				} else {
 					s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
 				}
@@ -1019,6 +1263,271 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 
 			w.WriteHeader(http.StatusNoContent)
 		})
+
+		// Favorites are listed in pages of per_page favorites (default 20, at most 50), newest first.
+		r.Get("/api/favorites", func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			page, perPage := 1, 20
+			if v := r.URL.Query().Get("page"); v != "" {
+				p, err := strconv.Atoi(v)
+				if err != nil || p < 1 {
+					s.writeJSONErrorResponse(w, r, errors.New("page must be a positive integer"), http.StatusBadRequest)
+					return
+				}
+				page = p
+			}
+			if v := r.URL.Query().Get("per_page"); v != "" {
+				pp, err := strconv.Atoi(v)
+				if err != nil || pp < 1 || pp > 50 {
+					s.writeJSONErrorResponse(w, r, errors.New("per_page must be between 1 and 50"), http.StatusBadRequest)
+					return
+				}
+				perPage = pp
+			}
+
+			favorites, total, err := db.GetFavorites(r.Context(), user, (page-1)*perPage, perPage)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to fetch favorites from db", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			s.writeJSONResponse(w, r, map[string]any{
+				"favorites": favorites,
+				"page":      page,
+				"per_page":  perPage,
+				"total":     total,
+			}, http.StatusOK)
+		})
+
+		r.With(s.idempotent(db)).Post("/api/favorites/{pizzaId:\\d+}", func(w http.ResponseWriter, r *http.Request) {
+			pizzaID, err := strconv.ParseInt(chi.URLParam(r, "pizzaId"), 10, 64)
+			if err != nil {
+				s.writeInvalidParamResponse(w, r, "pizzaId")
+				return
+			}
+
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			favorite, added, err := db.AddFavorite(r.Context(), user, pizzaID)
+			if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				return
+			} else if errors.Is(err, database.ErrPizzaNotFound) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to add favorite", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			// Adding a favorite twice is not an error.
+			status := http.StatusOK
+			if added {
+				status = http.StatusCreated
+			}
+			s.writeJSONResponse(w, r, favorite, status)
+		})
+
+		r.Delete("/api/favorites/{pizzaId:\\d+}", func(w http.ResponseWriter, r *http.Request) {
+			pizzaID, err := strconv.ParseInt(chi.URLParam(r, "pizzaId"), 10, 64)
+			if err != nil {
+				s.writeInvalidParamResponse(w, r, "pizzaId")
+				return
+			}
+
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			err = db.RemoveFavorite(r.Context(), user, pizzaID)
+			if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				return
+			} else if errors.Is(err, database.ErrFavoriteNotFound) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to remove favorite", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	s.router.Group(func(r chi.Router) {
+		// These endpoints manage the profile of the user of the request token.
+		s.traceInstaller.Install(r, "users")
+
+		r.Use(s.AuthMiddleware(db))
+		r.Use(LogUser)
+		r.Use(errorinjector.InjectErrorHeadersMiddleware)
+
+		r.Get("/api/users/me", func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			profile := *user
+			profile.Token = ""
+			s.writeJSONResponse(w, r, &profile, http.StatusOK)
+		})
+
+		r.Patch("/api/users/me", func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			current := *user
+			current.Token = ""
+
+			profile := current
+			if !s.applyPatch(w, r, &profile) {
+				return
+			}
+
+			if profile.ID != current.ID || profile.Role != current.Role || profile.Password != "" || profile.Token != "" {
+				s.writeJSONErrorResponse(w, r, fmt.Errorf("%w: only the username can be changed", errReadOnlyField), http.StatusUnprocessableEntity)
+				return
+			}
+
+			if profile.Username == current.Username {
+				s.writeJSONResponse(w, r, &current, http.StatusOK)
+				return
+			}
+
+			if err := model.ValidateUsername(profile.Username); err != nil {
+				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				return
+			}
+
+			err := db.UpdateUsername(r.Context(), user, profile.Username)
+			if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				return
+			} else if errors.Is(err, database.ErrUsernameTaken) {
+				s.writeJSONErrorResponse(w, r, model.ValidationError{{Field: "username", Detail: err.Error()}}, http.StatusBadRequest)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to update username", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			profile = *user
+			profile.Token = ""
+			s.writeJSONResponse(w, r, &profile, http.StatusOK)
+		})
+
+		// Change the password of the user, given the current one. All sessions of the user are revoked, and a new
+		// one is started for the client.
+		r.Post("/api/users/me/password", func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			var data struct {
+				CurrentPassword string `json:"current_password"`
+				NewPassword     string `json:"new_password"`
+			}
+			if s.decodeJSONBody(w, r, &data) != nil {
+				return
+			}
+
+			if user.IsGlobal() {
+				s.writeJSONErrorResponse(w, r, database.ErrGlobalOperationNotPermitted, http.StatusForbidden)
+				return
+			}
+
+			if err := s.passwordPolicy.Check(user.Username, data.NewPassword); err != nil {
+				s.writeJSONErrorResponse(w, r, model.ValidationError{{Field: "new_password", Detail: err.Error()}}, http.StatusBadRequest)
+				return
+			}
+
+			// Checking the current password counts as a login attempt, so that it cannot be brute-forced with a
+			// stolen token either.
+			current, err := s.loginUser(r, db, user.Username, data.CurrentPassword)
+			var lockout *database.LockoutError
+			if errors.As(err, &lockout) {
+				s.writeLockoutResponse(w, r, lockout)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to check current password", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			} else if current == nil {
+				s.writeJSONErrorResponse(w, r, errors.New("current password is incorrect"), http.StatusForbidden)
+				return
+			}
+
+			if err := db.UpdatePassword(r.Context(), user, data.NewPassword); err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to update password", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			session, err := db.CreateSession(r.Context(), user)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to create session", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			resp, err := s.newTokenResponse(session)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to sign token", "err", err)
+				s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+				return
+			}
+
+			// Replace the session cookies, if the client used them.
+			if requestTokenFromCookie(r) != "" {
+				s.setSessionCookies(w, session, resp.Token)
+			}
+
+			s.writeJSONResponse(w, r, resp, http.StatusOK)
+		})
+
+		r.Delete("/api/users/me", func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			err := db.DeleteUser(r.Context(), user)
+			if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to delete user", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			clearSessionCookies(w)
+			w.WriteHeader(http.StatusNoContent)
+		})
 	})
 
 	s.router.Group(func(r chi.Router) {
@@ -1033,7 +1542,7 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 			})
 		})
 
-		r.Post("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		r.With(s.idempotent(db)).Post("/api/users", func(w http.ResponseWriter, r *http.Request) {
 			var user model.User
 			if s.decodeJSONBody(w, r, &user) != nil {
 				return
@@ -1044,13 +1553,18 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
+			if err := s.passwordPolicy.Check(user.Username, user.Password); err != nil {
+				s.writeJSONErrorResponse(w, r, model.ValidationError{{Field: "password", Detail: err.Error()}}, http.StatusBadRequest)
+				return
+			}
+
 			err := db.RecordUser(r.Context(), &user)
 			if err == database.ErrUsernameTaken {
-				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				s.writeJSONErrorResponse(w, r, model.ValidationError{{Field: "username", Detail: err.Error()}}, http.StatusBadRequest)
 				return
 			} else if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to record user", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -1087,10 +1601,14 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				}
 			}
 
-			user, err := db.LoginUser(r.Context(), data.Username, data.Password)
-			if err != nil {
+			user, err := s.loginUser(r, db, data.Username, data.Password)
+			var lockout *database.LockoutError
+			if errors.As(err, &lockout) {
+				s.writeLockoutResponse(w, r, lockout)
+				return
+			} else if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to login user", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -1100,14 +1618,22 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
+			session, err := db.CreateSession(r.Context(), user)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to create session", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			resp, err := s.newTokenResponse(session)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to sign token", "err", err)
+				s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+				return
+			}
+
 			if setCookie {
-				// Set the QP user token cookie
-				http.SetCookie(w, &http.Cookie{
-					Name:     qpUserTokenCookie,
-					Value:    user.Token,
-					SameSite: http.SameSiteStrictMode,
-					Path:     "/",
-				})
+				s.setSessionCookies(w, session, resp.Token)
 
 				// Delete the cookie containing the CSRF token
 				http.SetCookie(w, &http.Cookie{
@@ -1119,7 +1645,71 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				})
 			}
 
-			s.writeJSONResponse(w, r, map[string]string{"token": user.Token}, http.StatusOK)
+			s.writeJSONResponse(w, r, resp, http.StatusOK)
+		})
+
+		// Given a refresh token, either in the body or in a cookie, revoke the session it belongs to and return the
+		// tokens of a new one.
+		r.Post("/api/users/token/refresh", func(w http.ResponseWriter, r *http.Request) {
+			var data struct {
+				RefreshToken string `json:"refresh_token"`
+			}
+			if r.ContentLength != 0 && s.decodeJSONBody(w, r, &data) != nil {
+				return
+			}
+
+			if data.RefreshToken == "" {
+				if cookie, err := r.Cookie(qpRefreshTokenCookie); err == nil {
+					data.RefreshToken = cookie.Value
+				}
+			}
+
+			if data.RefreshToken == "" {
+				s.writeJSONErrorResponse(w, r, errors.New("refresh token is missing"), http.StatusBadRequest)
+				return
+			}
+
+			session, err := db.RefreshSession(r.Context(), data.RefreshToken)
+			if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to refresh session", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			resp, err := s.newTokenResponse(session)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to sign token", "err", err)
+				s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+				return
+			}
+
+			if _, err := r.Cookie(qpRefreshTokenCookie); err == nil {
+				s.setSessionCookies(w, session, resp.Token)
+			}
+
+			s.writeJSONResponse(w, r, resp, http.StatusOK)
+		})
+
+		// Revoke the session of the request token, or all sessions of the same user if the all query parameter is
+		// set, and delete the session cookies. Tokens that do not belong to a session are accepted, but not revoked.
+		r.Post("/api/users/token/logout", func(w http.ResponseWriter, r *http.Request) {
+			if token := getRequestToken(r); token != "" {
+				err := s.revokeSession(r.Context(), db, token, r.URL.Query().Get("all") != "")
+				if errors.Is(err, database.ErrGlobalOperationNotPermitted) {
+					s.writeJSONErrorResponse(w, r, err, http.StatusForbidden)
+					return
+				} else if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to revoke session", "err", err)
+					s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+					return
+				}
+			}
+
+			clearSessionCookies(w)
+			w.WriteHeader(http.StatusOK)
 		})
 
 		// Given a user token, return 200 or 401 (depending on whether token is valid).
@@ -1130,10 +1720,13 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 				return
 			}
 
-			user, err := db.Authenticate(r.Context(), token)
-			if err != nil {
+			user, err := s.authenticate(r.Context(), db, token)
+			if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to check token", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -1145,12 +1738,9 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 		// These endpoints do not have user token validation.
 		s.traceInstaller.Install(r, "admin")
 
-		r.Post("/api/internal/recommendations", func(w http.ResponseWriter, r *http.Request) {
-			if r.Header.Get("X-Is-Internal") == "" {
-				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
-				return
-			}
-
+		// Only services can record recommendations. Callers are authenticated before their idempotency key is
+		// reserved, so that others cannot reserve keys.
+		r.With(s.requireInternal, s.idempotent(db)).Post("/api/internal/recommendations", func(w http.ResponseWriter, r *http.Request) {
 			var latestRecommendation model.Pizza
 			if s.decodeJSONBody(w, r, &latestRecommendation) != nil {
 				return
@@ -1158,63 +1748,158 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 
 			if err := db.RecordRecommendation(r.Context(), &latestRecommendation); err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to save recommendation", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
 			s.writeJSONResponse(w, r, latestRecommendation, http.StatusCreated)
 		})
 
+		// Idempotency keys are stored by the Catalog service on behalf of services without a database. As keys hold
+		// the responses of other users, only services, which know the internal token, can use them.
+		r.With(s.requireInternal).Post("/api/internal/idempotency-keys/{action:reserve|complete|release}", func(w http.ResponseWriter, r *http.Request) {
+			var key model.IdempotencyKey
+			if s.decodeJSONBody(w, r, &key) != nil {
+				return
+			}
+
+			var err error
+			var existing *model.IdempotencyKey
+			switch chi.URLParam(r, "action") {
+			case "reserve":
+				existing, err = db.ReserveIdempotencyKey(r.Context(), &key)
+			case "complete":
+				err = db.CompleteIdempotencyKey(r.Context(), &key)
+			case "release":
+				err = db.ReleaseIdempotencyKey(r.Context(), &key)
+			}
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to store idempotency key", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			s.writeJSONResponse(w, r, map[string]*model.IdempotencyKey{"existing": existing}, http.StatusOK)
+		})
+
 		r.Get("/api/internal/recommendations/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
 			idParam, err := strconv.Atoi(chi.URLParam(r, "id"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "id")
 				return
 			}
 
 			recommendation, err := db.GetRecommendation(r.Context(), idParam)
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch recommendation from db", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
 			if recommendation == nil {
-				w.WriteHeader(http.StatusNotFound)
+				s.writeJSONErrorResponse(w, r, errors.New("recommendation not found"), http.StatusNotFound)
 				return
 			}
 
 			s.writeJSONResponse(w, r, recommendation, http.StatusOK)
 		})
 
-		r.Get("/api/internal/recommendations", func(w http.ResponseWriter, r *http.Request) {
+		// Recent recommendations are shown to admins and kitchen staff.
+		r.With(s.adminSessionMiddleware(db), s.requireRole(model.RoleKitchen)).Get("/api/internal/recommendations", func(w http.ResponseWriter, r *http.Request) {
 			s.log.DebugContext(r.Context(), "Recommendations requested")
-			token := ""
-			if tokenCookie, err := r.Cookie("admin_token"); err == nil {
-				token = tokenCookie.Value
-			}
-
-			if token == "" {
-				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
-				return
-			}
 
 			history, err := db.GetHistory(r.Context(), 15)
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch history from db", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
 			s.writeJSONResponse(w, r, map[string][]model.Pizza{"pizzas": history}, http.StatusOK)
 		})
 
+		// Audit log of logins, e.g. to spot credential stuffing.
+		r.With(s.adminSessionMiddleware(db), s.requireRole(model.RoleAdmin)).Get("/api/admin/login-attempts", func(w http.ResponseWriter, r *http.Request) {
+			limit := 100
+			if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 1000 {
+				limit = l
+			}
+
+			attempts, err := db.GetLoginAttempts(r.Context(), r.URL.Query().Get("username"), r.URL.Query().Get("ip"), limit)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to fetch login attempts from db", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			s.writeJSONResponse(w, r, map[string][]model.LoginAttempt{"attempts": attempts}, http.StatusOK)
+		})
+
+		// Moderation of reviews. Ratings whose review contains a banned word are pending until an admin approves or
+		// rejects them.
+		r.Group(func(r chi.Router) {
+			r.Use(s.adminSessionMiddleware(db))
+			r.Use(s.requireRole(model.RoleAdmin))
+
+			r.Get("/api/admin/ratings", func(w http.ResponseWriter, r *http.Request) {
+				status := r.URL.Query().Get("status")
+				switch status {
+				case "":
+					status = model.RatingPending
+				case model.RatingPending, model.RatingApproved, model.RatingRejected:
+				default:
+					s.writeJSONErrorResponse(w, r, fmt.Errorf("unknown rating status %q", status), http.StatusBadRequest)
+					return
+				}
+
+				limit := 100
+				if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 1000 {
+					limit = l
+				}
+
+				ratings, err := db.GetRatingsByStatus(r.Context(), status, limit)
+				if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to fetch ratings from db", "err", err)
+					s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+					return
+				}
+
+				s.writeJSONResponse(w, r, map[string][]*model.Rating{"ratings": ratings}, http.StatusOK)
+			})
+
+			moderateRating := func(status string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+					if err != nil {
+						s.writeInvalidParamResponse(w, r, "id")
+						return
+					}
+
+					rating, err := db.SetRatingStatus(r.Context(), id, status)
+					if errors.Is(err, database.ErrRatingNotFound) {
+						s.writeJSONErrorResponse(w, r, err, http.StatusNotFound)
+						return
+					} else if err != nil {
+						s.log.ErrorContext(r.Context(), "Failed to moderate rating", "err", err)
+						s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+						return
+					}
+
+					s.log.InfoContext(r.Context(), "Rating moderated", "rating", id, "status", status, "moderator", contextUser(r.Context()).ID)
+					s.writeJSONResponse(w, r, rating, http.StatusOK)
+				}
+			}
+
+			r.Post("/api/admin/ratings/{id:\\d+}/approve", moderateRating(model.RatingApproved))
+			r.Post("/api/admin/ratings/{id:\\d+}/reject", moderateRating(model.RatingRejected))
+		})
+
 		r.HandleFunc("/api/admin/login", func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet {
 				// Allow using GET for admin login, in order not to break existing examples.
 				s.log.DebugContext(r.Context(), "Admin login with GET is deprecated")
 			} else if r.Method != http.MethodPost {
-				w.WriteHeader(http.StatusMethodNotAllowed)
+				s.writeJSONErrorResponse(w, r, fmt.Errorf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
 				return
 			}
 
@@ -1223,26 +1908,48 @@ func (s *Server) AddCatalogHandler(db *database.Catalog) {
 			password := r.URL.Query().Get("password")
 
 			if user == "" || password == "" {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeJSONErrorResponse(w, r, errors.New("user and password query parameters are required"), http.StatusBadRequest)
 				return
 			}
 
-			if user != "admin" || password != "admin" {
+			u, err := s.loginUser(r, db, user, password)
+			var lockout *database.LockoutError
+			if errors.As(err, &lockout) {
+				s.writeLockoutResponse(w, r, lockout)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to login admin", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			if u == nil {
 				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
 				return
 			}
 
-			guid := xid.New()
-			token := guid.String()
+			// The admin page is meant for staff only.
+			if !u.HasRole(model.RoleKitchen) {
+				s.writeJSONErrorResponse(w, r, errForbidden, http.StatusForbidden)
+				return
+			}
+
+			session, err := db.CreateSession(r.Context(), u)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to create admin session", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
 
 			http.SetCookie(w, &http.Cookie{
-				Name:     "admin_token",
-				Value:    token,
+				Name:     adminTokenCookie,
+				Value:    session.Token,
+				Expires:  session.ExpiresAt,
 				SameSite: http.SameSiteStrictMode,
 				Path:     "/", // Required for /admin to be able to use a cookie returned by /api.
 			})
 
-			s.writeJSONResponse(w, r, map[string]string{"token": token}, http.StatusOK)
+			s.writeJSONResponse(w, r, map[string]string{"token": session.Token}, http.StatusOK)
 		})
 	})
 }
@@ -1254,10 +1961,10 @@ func (s *Server) AddCopyHandler(db *database.Copy) {
 
 		r.Use(errorinjector.InjectErrorHeadersMiddleware)
 
-		// if env var is set, apply delay to all endpoints of this service
+		// Apply the delay of the service to all of its endpoints.
 		r.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				util.DelayIfEnvSet("QUICKPIZZA_DELAY_COPY")
+				time.Sleep(s.faults.CopyDelay)
 				next.ServeHTTP(w, r)
 			})
 		})
@@ -1265,12 +1972,13 @@ func (s *Server) AddCopyHandler(db *database.Copy) {
 		r.Get("/api/quotes", func(w http.ResponseWriter, r *http.Request) {
 			s.log.DebugContext(r.Context(), "Quotes requested")
 
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_COPY_API_QUOTES")
+			time.Sleep(s.faults.CopyQuotesDelay)
 
 			quotes, err := db.GetQuotes(r.Context())
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch quotes from db", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
 			}
 
 			s.writeJSONResponse(w, r, map[string][]string{"quotes": quotes}, http.StatusOK)
@@ -1279,12 +1987,13 @@ func (s *Server) AddCopyHandler(db *database.Copy) {
 		r.Get("/api/names", func(w http.ResponseWriter, r *http.Request) {
 			s.log.DebugContext(r.Context(), "Names requested")
 
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_COPY_API_NAMES")
+			time.Sleep(s.faults.CopyNamesDelay)
 
 			names, err := db.GetClassicalNames(r.Context())
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch names from db", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
 			}
 
 			s.writeJSONResponse(w, r, map[string][]string{"names": names}, http.StatusOK)
@@ -1293,16 +2002,31 @@ func (s *Server) AddCopyHandler(db *database.Copy) {
 		r.Get("/api/adjectives", func(w http.ResponseWriter, r *http.Request) {
 			s.log.DebugContext(r.Context(), "Adjectives requested")
 
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_COPY_API_ADJECTIVES")
+			time.Sleep(s.faults.CopyAdjectivesDelay)
 
 			adjs, err := db.GetAdjectives(r.Context())
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch adjectives from db", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
 			}
 
 			s.writeJSONResponse(w, r, map[string][]string{"adjectives": adjs}, http.StatusOK)
 		})
+
+		// Banned words are used by the Catalog service to moderate reviews. They are not exposed through the gateway.
+		r.Get("/api/banned-words", func(w http.ResponseWriter, r *http.Request) {
+			s.log.DebugContext(r.Context(), "Banned words requested")
+
+			words, err := db.GetBannedWords(r.Context())
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to fetch banned words from db", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			s.writeJSONResponse(w, r, map[string][]string{"banned_words": words}, http.StatusOK)
+		})
 	})
 }
 
@@ -1316,224 +2040,69 @@ func (s *Server) AddRecommendations(catalogClient CatalogClient, copyClient Copy
 		r.Use(LogUser)
 		r.Use(errorinjector.InjectErrorHeadersMiddleware)
 
-		// if env var is set, apply delay to all endpoints of this service
+		// Apply the delay of the service to all of its endpoints.
 		r.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				util.DelayIfEnvSet("QUICKPIZZA_DELAY_RECOMMENDATIONS")
+				time.Sleep(s.faults.RecommendationsDelay)
 				next.ServeHTTP(w, r)
 			})
 		})
 
-		r.Get("/api/pizza/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
+		r.With(conditionalGet).Get("/api/pizza/{id:\\d+}", func(w http.ResponseWriter, r *http.Request) {
 
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_RECOMMENDATIONS_API_PIZZA_GET")
+			time.Sleep(s.faults.RecommendationsGetDelay)
 			id, err := strconv.Atoi(chi.URLParam(r, "id"))
 			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
+				s.writeInvalidParamResponse(w, r, "id")
 				return
 			}
 
 			pizza, err := catalogClient.GetRecommendation(id)
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Failed to fetch recommendation from catalog", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
 			if pizza == nil {
-				w.WriteHeader(http.StatusNotFound)
+				s.writeJSONErrorResponse(w, r, errors.New("pizza not found"), http.StatusNotFound)
 				return
 			}
 
 			s.writeJSONResponse(w, r, pizza, http.StatusOK)
 		})
 
-		r.Post("/api/pizza", func(w http.ResponseWriter, r *http.Request) {
+		r.With(s.idempotent(catalogIdempotencyStore{catalogClient})).Post("/api/pizza", func(w http.ResponseWriter, r *http.Request) {
 
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_RECOMMENDATIONS_API_PIZZA_POST")
+			time.Sleep(s.faults.RecommendationsPostDelay)
 
-			if util.FailRandomlyIfEnvSet("QUICKPIZZA_FAIL_RATE_RECOMMENDATIONS_API_PIZZA_POST") {
+			if util.FailRandomly(s.faults.RecommendationsPostFailRate) {
 				s.log.ErrorContext(r.Context(), "Simulated random failure: Pizza service temporarily unavailable")
-				s.writeJSONErrorResponse(w, r, errors.New("Pizza service temporarily unavailable"), http.StatusServiceUnavailable)
+				s.writeJSONErrorResponse(w, r, withProblemCode("service_unavailable", errors.New("Pizza service temporarily unavailable")), http.StatusServiceUnavailable)
 				return
 			}
-			// Add request context to catalog and copy clients. This context contains a reference to the tracer used
-			// by the server (if any), which allows clients to both generate traces for outgoing client-type traces
-			// without explicitly configuring a tracer, and to link said client traces with the server trace that is
-			// generated in this request.
+			// Add request context to the catalog client, as recommendation.Generate does for the clients it queries.
+			// This context contains a reference to the tracer used by the server (if any), which allows clients to both
+			// generate traces for outgoing client-type traces without explicitly configuring a tracer, and to link
+			// said client traces with the server trace that is generated in this request.
 			catalogClient := catalogClient.WithRequestContext(r.Context())
-			copyClient := copyClient.WithRequestContext(r.Context())
-
-			tracer := trace.SpanFromContext(r.Context()).TracerProvider().Tracer("")
 
 			s.log.DebugContext(r.Context(), "Received pizza recommendation request")
-			var restrictions Restrictions
+			var restrictions recommendation.Restrictions
 			if s.decodeJSONBody(w, r, &restrictions) != nil {
 				return
 			}
 
-			restrictions = restrictions.WithDefaults()
-
-			if len(restrictions.CustomName) > model.MaxPizzaNameLength {
-				restrictions.CustomName = restrictions.CustomName[:model.MaxPizzaNameLength]
-			}
-
-			oils, err := catalogClient.Ingredients("olive_oil")
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting ingredients", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			// Retrieve list of ingredients from Catalog.
-			var validOliveOils []model.Ingredient
-			for _, oliveOil := range oils {
-				if !slices.Contains(restrictions.ExcludedIngredients, oliveOil.Name) && (!restrictions.MustBeVegetarian || oliveOil.Vegetarian) {
-					validOliveOils = append(validOliveOils, oliveOil)
-				}
-			}
-
-			tomatoes, err := catalogClient.Ingredients("tomato")
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting ingredients", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			var validTomatoes []model.Ingredient
-			for _, tomato := range tomatoes {
-				if !slices.Contains(restrictions.ExcludedIngredients, tomato.Name) && (!restrictions.MustBeVegetarian || tomato.Vegetarian) {
-					validTomatoes = append(validTomatoes, tomato)
-				}
-			}
-
-			mozzarellas, err := catalogClient.Ingredients("mozzarella")
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting ingredients", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			var validMozzarellas []model.Ingredient
-			for _, mozzarella := range mozzarellas {
-				if !slices.Contains(restrictions.ExcludedIngredients, mozzarella.Name) && (!restrictions.MustBeVegetarian || mozzarella.Vegetarian) {
-					validMozzarellas = append(validMozzarellas, mozzarella)
-				}
-			}
-
-			toppings, err := catalogClient.Ingredients("topping")
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting ingredients", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			var validToppings []model.Ingredient
-			for _, topping := range toppings {
-				if !slices.Contains(restrictions.ExcludedIngredients, topping.Name) && (!restrictions.MustBeVegetarian || topping.Vegetarian) {
-					validToppings = append(validToppings, topping)
-				}
-			}
-
-			tools, err := catalogClient.Tools()
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting tools", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			var validTools []string
-			for _, tool := range tools {
-				if !slices.Contains(restrictions.ExcludedTools, tool) {
-					validTools = append(validTools, tool)
-				}
-			}
-
-			doughs, err := catalogClient.Doughs()
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting doughs", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+			p, err := recommendation.Generate(r.Context(), recommendationCatalog{catalogClient}, recommendationCopy{copyClient}, restrictions)
+			if errors.Is(err, recommendation.ErrNoMatch) {
+				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
 				return
-			}
-
-			// Retrieve adjectives and names from Copy.
-			adjectives, err := copyClient.Adjectives()
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting adjectives", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Generating pizza", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
-			names, err := copyClient.Names()
-			if err != nil {
-				s.log.ErrorContext(r.Context(), "Requesting names", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			pizzaCtx, pizzaSpan := tracer.Start(r.Context(), "pizza-generation")
-			var p model.Pizza
-			for range 10 {
-				randomName := restrictions.CustomName
-
-				if randomName == "" {
-					_, nameSpan := tracer.Start(pizzaCtx, "name-generation")
-
-					for {
-						randomName = fmt.Sprintf("%s %s", adjectives[rand.Intn(len(adjectives))], names[rand.Intn(len(names))])
-						if strings.HasPrefix(randomName, "A") || strings.HasPrefix(randomName, "E") || strings.HasPrefix(randomName, "I") || strings.HasPrefix(randomName, "O") || strings.HasPrefix(randomName, "U") {
-							randomName = fmt.Sprintf("An %s", randomName)
-						} else {
-							if rand.Intn(100) < 50 {
-								randomName = fmt.Sprintf("The %s", randomName)
-							} else {
-								randomName = fmt.Sprintf("A %s", randomName)
-							}
-						}
-
-						// Measure how funny the name is. It fails if the name is too funny or too unfunny
-						if rand.Intn(100) < 50 {
-							time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
-							break
-						}
-					}
-					nameSpan.End()
-				}
-
-				p = model.Pizza{
-					Name:        randomName,
-					Dough:       doughs[rand.Intn(len(doughs))],
-					Ingredients: []model.Ingredient{validOliveOils[rand.Intn(len(validOliveOils))], validTomatoes[rand.Intn(len(validTomatoes))], validMozzarellas[rand.Intn(len(validMozzarellas))]},
-					Tool:        validTools[rand.Intn(len(validTools))],
-				}
-
-				// Compute how many extra toppings we are allowed to add. If any, randomize that number.
-				extraToppings := restrictions.MaxNumberOfToppings - restrictions.MinNumberOfToppings
-				if extraToppings > 0 {
-					extraToppings = rand.Intn(extraToppings + 1)
-				}
-
-				for range extraToppings + restrictions.MinNumberOfToppings {
-					p.Ingredients = append(p.Ingredients, validToppings[rand.Intn(len(validToppings))])
-				}
-
-				uniqueIngredients := make(map[string]model.Ingredient)
-				for _, ingredient := range p.Ingredients {
-					uniqueIngredients[ingredient.Name] = ingredient
-				}
-				p.Ingredients = make([]model.Ingredient, 0)
-				for _, ingredient := range uniqueIngredients {
-					p.Ingredients = append(p.Ingredients, ingredient)
-				}
-
-				if p.CalculateCalories() > restrictions.MaxCaloriesPerSlice {
-					continue
-				}
-
-				break
-			}
-			pizzaSpan.End()
-
 			pizzaRecommendation := PizzaRecommendation{
 				Pizza:      p,
 				Calories:   p.CalculateCalories(),
@@ -1543,7 +2112,7 @@ func (s *Server) AddRecommendations(catalogClient CatalogClient, copyClient Copy
 			result, err := catalogClient.RecordRecommendation(p)
 			if err != nil {
 				s.log.ErrorContext(r.Context(), "Storing recommendation in catalog", "err", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
 				return
 			}
 
@@ -1576,7 +2145,7 @@ func FaviconHandler() http.Handler {
 }
 
 // From: https://www.liip.ch/en/blog/embed-sveltekit-into-a-go-binary
-func SvelteKitHandler() http.Handler {
+func SvelteKitHandler(faults Faults) http.Handler {
 	fsys, err := fs.Sub(web.EmbeddedFiles, "build")
 	if err != nil {
 		log.Fatal(err)
@@ -1588,10 +2157,10 @@ func SvelteKitHandler() http.Handler {
 
 		// Delay CSS resources
 		if strings.HasSuffix(strings.ToLower(path), ".css") {
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_FRONTEND_CSS_ASSETS")
+			time.Sleep(faults.FrontendCSSDelay)
 		}
 		if strings.HasSuffix(strings.ToLower(path), ".png") {
-			util.DelayIfEnvSet("QUICKPIZZA_DELAY_FRONTEND_PNG_ASSETS")
+			time.Sleep(faults.FrontendPNGDelay)
 		}
 
 		// try if file exists at path, if not append .html (SvelteKit adapter-static specific)
@@ -1626,7 +2195,7 @@ func ViteProxyHandler() http.Handler {
 // (they are defined outside router.Group() blocks with traceInstaller.Install()).
 func isInternalRoute(pattern string) bool {
 	switch pattern {
-	case "/metrics", "/ready", "/healthz", "/ws":
+	case "/metrics", "/ready", "/started", "/healthz", "/ws":
 		return true
 	}
 	return strings.HasPrefix(pattern, "/debug/pprof/")
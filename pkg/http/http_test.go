@@ -0,0 +1,29 @@
+package http
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/quickpizza/pkg/database"
+)
+
+// newTestServer returns a server with the given options, without tracing or profiling.
+func newTestServer(t *testing.T, opts ...ServerOption) *Server {
+	t.Helper()
+
+	return NewServer(true, &OTelInstaller{}, opts...)
+}
+
+// newTestCatalog returns a Catalog backed by a SQLite database of its own, which is deleted when the test ends.
+func newTestCatalog(t *testing.T) *database.Catalog {
+	t.Helper()
+
+	config := database.DefaultCatalogConfig()
+	config.DevStaffPasswords = true
+	db, err := database.NewCatalog(database.Options{ConnString: filepath.Join(t.TempDir(), "catalog.db")}, config)
+	if err != nil {
+		t.Fatalf("creating catalog: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
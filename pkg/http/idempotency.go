@@ -0,0 +1,223 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/util"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+var idempotentRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "quickpizza",
+	Subsystem: "server",
+	Name:      "idempotent_requests_total",
+	Help:      "Requests with an Idempotency-Key header, by outcome: processed, replayed, mismatch or in_progress",
+}, []string{"outcome"})
+
+// idempotencyStore keeps track of requests sent with an Idempotency-Key header. It is implemented by
+// database.Catalog, and by catalogIdempotencyStore for services that do not have a database of their own.
+type idempotencyStore interface {
+	// ReserveIdempotencyKey stores key, or returns the existing one if the user already used it.
+	ReserveIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, error)
+	// CompleteIdempotencyKey stores the response to the request of a reserved key.
+	CompleteIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+	// ReleaseIdempotencyKey deletes a reserved key that was not completed.
+	ReleaseIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error
+}
+
+// catalogIdempotencyStore is an idempotencyStore backed by the database of the Catalog service.
+type catalogIdempotencyStore struct {
+	catalogClient CatalogClient
+}
+
+func (s catalogIdempotencyStore) ReserveIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	return s.catalogClient.WithRequestContext(ctx).ReserveIdempotencyKey(key)
+}
+
+func (s catalogIdempotencyStore) CompleteIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	return s.catalogClient.WithRequestContext(ctx).CompleteIdempotencyKey(key)
+}
+
+func (s catalogIdempotencyStore) ReleaseIdempotencyKey(ctx context.Context, key *model.IdempotencyKey) error {
+	return s.catalogClient.WithRequestContext(ctx).ReleaseIdempotencyKey(key)
+}
+
+// idempotent makes requests with an Idempotency-Key header safe to retry. The response to the first request with a
+// key is stored, and replayed for further requests with the same key, method, path and body. Reusing a key for a
+// different request fails with 422, and retrying while the first request is still being processed fails with 409.
+// Failures with a 5xx status are not stored, so that they can be retried.
+//
+// Keys are scoped to the token of the request, as anonymous requests and those with unknown tokens share the same
+// user. Requests without a token, other than those of QuickPizza services, are processed as if they had no key.
+func (s *Server) idempotent(store idempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keyHeader := r.Header.Get(idempotencyKeyHeader)
+			if keyHeader == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(keyHeader) > model.MaxIdempotencyKeyLength {
+				s.writeJSONErrorResponse(w, r, fmt.Errorf("%s must be at most %d characters long", idempotencyKeyHeader, model.MaxIdempotencyKeyLength), http.StatusBadRequest)
+				return
+			}
+
+			scope, ok := s.idempotencyScope(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+			if err != nil {
+				s.writeJSONErrorResponse(w, r, err, http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key := &model.IdempotencyKey{
+				Key:         scopedIdempotencyKey(scope, keyHeader),
+				Fingerprint: requestFingerprint(r, body),
+			}
+			if user := contextUser(r.Context()); user != nil {
+				key.UserID = user.ID
+			}
+
+			existing, err := store.ReserveIdempotencyKey(r.Context(), key)
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to reserve idempotency key", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			if existing != nil {
+				s.replayIdempotentResponse(w, r, key, existing)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			buf := &bytes.Buffer{}
+			ww.Tee(buf)
+
+			// Release the key if the handler panics, so that the request can be retried instead of failing with 409
+			// until the key expires.
+			defer func() {
+				if p := recover(); p != nil {
+					if err := store.ReleaseIdempotencyKey(context.WithoutCancel(r.Context()), key); err != nil {
+						s.log.ErrorContext(r.Context(), "Failed to release idempotency key", "err", err)
+					}
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+			idempotentRequests.WithLabelValues("processed").Inc()
+
+			// The response is already sent, so keep going even if the client went away.
+			ctx := context.WithoutCancel(r.Context())
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status >= http.StatusInternalServerError {
+				if err := store.ReleaseIdempotencyKey(ctx, key); err != nil {
+					s.log.ErrorContext(ctx, "Failed to release idempotency key", "err", err)
+				}
+				return
+			}
+
+			key.StatusCode = status
+			key.ContentType = ww.Header().Get("Content-Type")
+			key.Location = ww.Header().Get("Location")
+			key.ETag = ww.Header().Get("ETag")
+			key.Body = buf.Bytes()
+			if err := store.CompleteIdempotencyKey(ctx, key); err != nil {
+				s.log.ErrorContext(ctx, "Failed to complete idempotency key", "err", err)
+				// Let the request be retried, rather than leaving the key in progress until it expires.
+				key.StatusCode = 0
+				_ = store.ReleaseIdempotencyKey(ctx, key)
+			}
+		})
+	}
+}
+
+// replayIdempotentResponse responds to a request whose idempotency key was already used.
+func (s *Server) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, key, existing *model.IdempotencyKey) {
+	switch {
+	case existing.Fingerprint != key.Fingerprint:
+		idempotentRequests.WithLabelValues("mismatch").Inc()
+		s.writeJSONErrorResponse(w, r, withProblemCode("idempotency_key_reused", fmt.Errorf("%s was already used for a different request", idempotencyKeyHeader)), http.StatusUnprocessableEntity)
+	case !existing.IsComplete():
+		idempotentRequests.WithLabelValues("in_progress").Inc()
+		w.Header().Set("Retry-After", "1")
+		s.writeJSONErrorResponse(w, r, withProblemCode("idempotency_key_pending", errors.New("a request with the same "+idempotencyKeyHeader+" is still being processed")), http.StatusConflict)
+	default:
+		idempotentRequests.WithLabelValues("replayed").Inc()
+		s.log.DebugContext(r.Context(), "Replaying idempotent response", "key", key.Key, "status", existing.StatusCode)
+		if existing.ContentType != "" {
+			w.Header().Set("Content-Type", existing.ContentType)
+		}
+		if existing.Location != "" {
+			w.Header().Set("Location", existing.Location)
+		}
+		if existing.ETag != "" {
+			w.Header().Set("ETag", existing.ETag)
+		}
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(existing.StatusCode)
+		_, _ = w.Write(existing.Body)
+	}
+}
+
+// idempotencyScope returns what the keys of r are scoped to: a hash of its token, or a scope shared by QuickPizza
+// services if it has none. It returns false for other requests without a token, which cannot be told apart.
+func (s *Server) idempotencyScope(r *http.Request) (string, bool) {
+	if token := getRequestToken(r); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(sum[:]), true
+	}
+	if s.isInternalRequest(r) {
+		return "internal", true
+	}
+	return "", false
+}
+
+// scopedIdempotencyKey returns the key stored for the Idempotency-Key header of a request in the given scope.
+func scopedIdempotencyKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies a request by its method, path and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newIdempotencyKey returns a random key for requests made by services, so that they are not applied twice when
+// retried.
+func newIdempotencyKey() string {
+	return util.GenerateAlphaNumToken(32)
+}
@@ -0,0 +1,230 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// idempotentRequest is a request sent to the idempotent middleware, and its expected outcome.
+type idempotentRequest struct {
+	token string
+	// internal sends the internal token of the server instead of a user token.
+	internal bool
+	key      string
+	path     string
+	// body is the request body. The test handler fails with 500 if it is "fail", and panics if it is "panic".
+	body         string
+	wantStatus   int
+	wantReplayed bool
+	// wantCall is the number of the handler call expected to have produced the response, starting at 1.
+	wantCall int
+}
+
+func TestIdempotent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		internalToken = "internal-token"
+		token1        = "abcdef0123456789"
+		token2        = "0123456789abcdef"
+	)
+
+	for _, tc := range []struct {
+		name     string
+		requests []idempotentRequest
+	}{
+		{
+			name: "replays the response to the same request",
+			requests: []idempotentRequest{
+				{token: token1, key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 1},
+				{token: token1, key: "k1", body: "a", wantStatus: http.StatusCreated, wantReplayed: true, wantCall: 1},
+				{token: token1, key: "k2", body: "a", wantStatus: http.StatusCreated, wantCall: 2},
+			},
+		},
+		{
+			name: "requests without a key are always processed",
+			requests: []idempotentRequest{
+				{token: token1, body: "a", wantStatus: http.StatusCreated, wantCall: 1},
+				{token: token1, body: "a", wantStatus: http.StatusCreated, wantCall: 2},
+			},
+		},
+		{
+			name: "keys are scoped to the token",
+			requests: []idempotentRequest{
+				{token: token1, key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 1},
+				{token: token2, key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 2},
+				{internal: true, key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 3},
+				{internal: true, key: "k1", body: "a", wantStatus: http.StatusCreated, wantReplayed: true, wantCall: 3},
+			},
+		},
+		{
+			name: "keys are ignored without a token",
+			requests: []idempotentRequest{
+				{key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 1},
+				{key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 2},
+			},
+		},
+		{
+			name: "reusing a key for a different request fails",
+			requests: []idempotentRequest{
+				{token: token1, key: "k1", body: "a", wantStatus: http.StatusCreated, wantCall: 1},
+				{token: token1, key: "k1", body: "b", wantStatus: http.StatusUnprocessableEntity},
+				{token: token1, key: "k1", path: "/api/other", body: "a", wantStatus: http.StatusUnprocessableEntity},
+			},
+		},
+		{
+			name: "server errors are not stored",
+			requests: []idempotentRequest{
+				{token: token1, key: "k1", body: "fail", wantStatus: http.StatusInternalServerError, wantCall: 1},
+				{token: token1, key: "k1", body: "fail", wantStatus: http.StatusInternalServerError, wantCall: 2},
+			},
+		},
+		{
+			name: "keys are released when the handler panics",
+			requests: []idempotentRequest{
+				{token: token1, key: "k1", body: "panic"},
+				{token: token1, key: "k1", body: "panic"},
+			},
+		},
+		{
+			name: "key is too long",
+			requests: []idempotentRequest{
+				{token: token1, key: strings.Repeat("k", model.MaxIdempotencyKeyLength+1), body: "a", wantStatus: http.StatusBadRequest},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newTestServer(t, WithInternalToken(internalToken))
+			store := newTestCatalog(t)
+
+			calls := 0
+			handler := s.idempotent(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body := make([]byte, 16)
+				n, _ := r.Body.Read(body)
+				switch string(body[:n]) {
+				case "fail":
+					http.Error(w, "failed", http.StatusInternalServerError)
+				case "panic":
+					panic("handler panicked")
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Location", "/api/things/"+strconv.Itoa(calls))
+					w.Header().Set("ETag", strconv.Quote(strconv.Itoa(calls)))
+					w.WriteHeader(http.StatusCreated)
+					_, _ = fmt.Fprintf(w, `{"call":%d}`, calls)
+				}
+			}))
+
+			for i, req := range tc.requests {
+				path := req.path
+				if path == "" {
+					path = "/api/things"
+				}
+				r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(idempotencyKeyHeader, req.key)
+				}
+				if req.token != "" {
+					r.Header.Set("Authorization", "Token "+req.token)
+				}
+				if req.internal {
+					r.Header.Set(internalTokenHeader, internalToken)
+				}
+				w := httptest.NewRecorder()
+
+				if req.body == "panic" {
+					callsBefore := calls
+					func() {
+						defer func() {
+							if recover() == nil {
+								t.Fatalf("request %d: expected the panic to be propagated", i)
+							}
+						}()
+						handler.ServeHTTP(w, r)
+					}()
+					if calls != callsBefore+1 {
+						t.Fatalf("request %d: expected the handler to be called", i)
+					}
+					continue
+				}
+
+				handler.ServeHTTP(w, r)
+
+				if w.Code != req.wantStatus {
+					t.Fatalf("request %d: expected status %d, got %d: %s", i, req.wantStatus, w.Code, w.Body)
+				}
+				if replayed := w.Header().Get(idempotentReplayedHeader) == "true"; replayed != req.wantReplayed {
+					t.Fatalf("request %d: expected replayed to be %t, got %t", i, req.wantReplayed, replayed)
+				}
+				if req.wantStatus != http.StatusCreated {
+					continue
+				}
+				if want := fmt.Sprintf(`{"call":%d}`, req.wantCall); w.Body.String() != want {
+					t.Fatalf("request %d: expected body %s, got %s", i, want, w.Body)
+				}
+				if want := "/api/things/" + strconv.Itoa(req.wantCall); w.Header().Get("Location") != want {
+					t.Fatalf("request %d: expected Location %q, got %q", i, want, w.Header().Get("Location"))
+				}
+				if want := strconv.Quote(strconv.Itoa(req.wantCall)); w.Header().Get("ETag") != want {
+					t.Fatalf("request %d: expected ETag %s, got %s", i, want, w.Header().Get("ETag"))
+				}
+				if w.Header().Get("Content-Type") != "application/json" {
+					t.Fatalf("request %d: expected a JSON response, got %q", i, w.Header().Get("Content-Type"))
+				}
+			}
+
+			if last := tc.requests[len(tc.requests)-1]; last.wantCall > 0 && calls != last.wantCall {
+				t.Fatalf("expected %d handler calls, got %d", last.wantCall, calls)
+			}
+		})
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	t.Parallel()
+
+	const token = "abcdef0123456789"
+
+	s := newTestServer(t)
+	store := newTestCatalog(t)
+	handler := s.idempotent(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected handler call")
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/api/things", strings.NewReader("a"))
+	r.Header.Set(idempotencyKeyHeader, "k1")
+	r.Header.Set("Authorization", "Token "+token)
+
+	// Reserve the key as a concurrent request with the same key would.
+	scope, ok := s.idempotencyScope(r)
+	if !ok {
+		t.Fatal("expected the request to have an idempotency scope")
+	}
+	_, err := store.ReserveIdempotencyKey(context.Background(), &model.IdempotencyKey{
+		Key:         scopedIdempotencyKey(scope, "k1"),
+		Fingerprint: requestFingerprint(r, []byte("a")),
+	})
+	if err != nil {
+		t.Fatalf("reserving key: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// internalTokenHeader carries the token that authenticates requests made between QuickPizza services. Unlike
+// X-Is-Internal, which any client can set, it cannot be forged without knowing the token.
+const internalTokenHeader = "X-Internal-Token"
+
+// WithInternalToken sets the token that requests made by other QuickPizza services carry. Those requests are not rate
+// limited, and are the only ones allowed to use the internal endpoints that require it. Every instance must use the
+// same token.
+func WithInternalToken(token string) ServerOption {
+	return func(s *Server) {
+		s.internalToken = token
+	}
+}
+
+// WithTrustedProxies makes the server take the address of clients from the X-Forwarded-For header of the requests
+// forwarded by proxies in the given ranges, e.g. the public API, instead of from the address of the connection.
+func WithTrustedProxies(proxies []netip.Prefix) ServerOption {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// ParseTrustedProxies parses a list of IP addresses and CIDR ranges, e.g. "10.0.0.0/8" or "192.168.1.10".
+func ParseTrustedProxies(v []string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, entry := range v {
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP address or a CIDR range", entry)
+			}
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: expected an IP address or a CIDR range", entry)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// isInternalRequest returns whether r was made by another QuickPizza service, i.e. carries the internal token.
+func (s *Server) isInternalRequest(r *http.Request) bool {
+	token := r.Header.Get(internalTokenHeader)
+	return s.internalToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.internalToken)) == 1
+}
+
+// requireInternal rejects requests that were not made by another QuickPizza service with 401.
+func (s *Server) requireInternal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isInternalRequest(r) {
+			s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP address of the client that made r. If r comes from a trusted proxy, it is the last address
+// of X-Forwarded-For that is not a trusted proxy, as earlier ones are set by the client and can be forged.
+func (s *Server) clientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	if !s.isTrustedProxy(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		ip = addr
+		if !s.isTrustedProxy(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// isTrustedProxy returns whether ip is in one of the ranges of s.trustedProxies.
+func (s *Server) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP address r was received from.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
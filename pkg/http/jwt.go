@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/jwt"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var authDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "quickpizza",
+	Subsystem: "server",
+	Name:      "auth_duration_seconds",
+	Help:      "The time it takes to authenticate a request, either remotely against the Catalog service or locally",
+}, []string{"mode"})
+
+// WithJWT enables JWT authentication. If signer is not nil, tokens handed out on login are JWTs signed by it, and its
+// key set is served at /.well-known/jwks.json; this only makes sense for the server that runs the Catalog service.
+// If verifier is not nil, JWTs are verified locally by the Recommendations service and the gateway, instead of being
+// sent to the Catalog service. Opaque tokens keep working as before.
+func WithJWT(signer *jwt.Signer, verifier *jwt.Verifier) ServerOption {
+	return func(s *Server) {
+		s.jwtSigner = signer
+		s.jwtVerifier = verifier
+	}
+}
+
+// addJWKS publishes the key set of signer at /.well-known/jwks.json, along with the ones of any other signer added
+// before.
+func (s *Server) addJWKS(signer *jwt.Signer) {
+	if slices.Contains(s.jwksSigners, signer) {
+		return
+	}
+
+	s.jwksSigners = append(s.jwksSigners, signer)
+	if len(s.jwksSigners) > 1 {
+		return
+	}
+
+	s.router.Get("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		set := jwt.KeySet{Keys: []jwt.Key{}}
+		for _, signer := range s.jwksSigners {
+			set.Keys = append(set.Keys, signer.JWKS().Keys...)
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		s.writeJSONResponse(w, r, set, http.StatusOK)
+	})
+}
+
+// signSessionJWT returns a JWT for session. Its ID is the one of the session, so that the Catalog service can reject
+// it once the session is revoked.
+func (s *Server) signSessionJWT(session *model.Session) (string, error) {
+	claims := jwt.Claims{
+		Subject:   strconv.FormatInt(session.UserID, 10),
+		Roles:     userRoles(session.User),
+		ExpiresAt: session.ExpiresAt.Unix(),
+		ID:        strconv.FormatInt(session.ID, 10),
+	}
+	if session.User != nil {
+		claims.Username = session.User.Username
+	}
+
+	return s.jwtSigner.Sign(claims)
+}
+
+// userRoles returns the roles of user, as listed in its tokens.
+func userRoles(user *model.User) []string {
+	if user == nil || user.Role == "" {
+		return []string{model.RoleUser}
+	}
+	return []string{user.Role}
+}
+
+// verifyJWT verifies token locally.
+func (s *Server) verifyJWT(ctx context.Context, token string) (*jwt.Claims, error) {
+	if s.jwtVerifier == nil {
+		return nil, jwt.ErrInvalidToken
+	}
+	return s.jwtVerifier.Verify(ctx, token)
+}
+
+// verifyJWTUser verifies token locally, and returns the user described by its claims. As the database is not
+// involved, tokens of revoked sessions are accepted until they expire.
+func (s *Server) verifyJWTUser(ctx context.Context, token string) (*model.User, error) {
+	claims, err := s.verifyJWT(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, jwt.ErrInvalidToken
+	}
+
+	user := &model.User{ID: id, Username: claims.Username}
+	if len(claims.Roles) > 0 {
+		user.Role = claims.Roles[0]
+	}
+	return user, nil
+}
+
+// authenticate returns the user token belongs to. JWTs are verified and then checked against the session they
+// reference, while any other token is looked up in the database.
+func (s *Server) authenticate(ctx context.Context, db *database.Catalog, token string) (*model.User, error) {
+	if !jwt.LooksLikeJWT(token) {
+		return db.Authenticate(ctx, token)
+	}
+
+	claims, err := s.verifyJWT(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := strconv.ParseInt(claims.ID, 10, 64)
+	if err != nil {
+		return nil, jwt.ErrInvalidToken
+	}
+
+	return db.AuthenticateSession(ctx, sessionID)
+}
+
+// revokeSession revokes the session token belongs to, or all sessions of the same user.
+func (s *Server) revokeSession(ctx context.Context, db *database.Catalog, token string, all bool) error {
+	if !jwt.LooksLikeJWT(token) {
+		return db.RevokeSession(ctx, token, all)
+	}
+
+	claims, err := s.verifyJWT(ctx, token)
+	if err != nil {
+		// There is nothing to revoke.
+		return nil
+	}
+
+	sessionID, err := strconv.ParseInt(claims.ID, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return db.RevokeSessionByID(ctx, sessionID, all)
+}
+
+// jwtGatewayMiddleware rejects requests carrying an invalid or expired JWT, except for the endpoints used to obtain
+// new tokens.
+func (s *Server) jwtGatewayMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := getRequestToken(r)
+		if !jwt.LooksLikeJWT(token) || isTokenEndpoint(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, err := s.verifyJWT(r.Context(), token); err != nil {
+			s.writeInvalidTokenResponse(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isTokenEndpoint returns whether path is one of the endpoints used to sign up, log in or out, or obtain tokens.
+func isTokenEndpoint(path string) bool {
+	return path == "/api/users" ||
+		path == "/api/csrf-token" ||
+		strings.HasPrefix(path, "/api/users/token/") ||
+		strings.HasPrefix(path, "/.well-known/") ||
+		strings.HasPrefix(path, "/oauth2/")
+}
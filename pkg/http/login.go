@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/password"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var loginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "quickpizza",
+	Subsystem: "server",
+	Name:      "login_attempts_total",
+	Help:      "The total number of login attempts, by result: success, failure or locked (rejected because of too many failures)",
+}, []string{"result"})
+
+// WithPasswordPolicy sets the policy new passwords must comply with. password.DefaultPolicy is used otherwise.
+func WithPasswordPolicy(policy password.Policy) ServerOption {
+	return func(s *Server) {
+		s.passwordPolicy = policy
+	}
+}
+
+// loginUser checks the credentials of the user with db, on behalf of the client of r, and records the result in the
+// login_attempts_total metric. It returns nil if the credentials are wrong, and a *database.LockoutError if there were
+// too many failed attempts.
+func (s *Server) loginUser(r *http.Request, db *database.Catalog, username, passwordText string) (*model.User, error) {
+	user, err := db.LoginUser(r.Context(), username, passwordText, s.clientIP(r))
+
+	var lockout *database.LockoutError
+	switch {
+	case errors.As(err, &lockout):
+		loginAttempts.WithLabelValues(model.LoginLocked).Inc()
+		s.log.WarnContext(r.Context(), "Login locked out", "scope", lockout.Scope, "username", username, "ip", s.clientIP(r))
+	case err != nil:
+		// Nothing to record, the attempt could not be checked.
+	case user == nil:
+		loginAttempts.WithLabelValues(model.LoginFailed).Inc()
+	default:
+		loginAttempts.WithLabelValues(model.LoginSucceeded).Inc()
+	}
+
+	return user, err
+}
+
+// writeLockoutResponse rejects a login attempt because of too many failures, telling the client when to retry.
+func (s *Server) writeLockoutResponse(w http.ResponseWriter, r *http.Request, lockout *database.LockoutError) {
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(lockout.RetryAfter)))
+	s.writeJSONErrorResponse(w, r, withProblemCode("account_locked", lockout), http.StatusTooManyRequests)
+}
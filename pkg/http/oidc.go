@@ -0,0 +1,581 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/jwt"
+	"github.com/grafana/quickpizza/pkg/util"
+	"github.com/grafana/quickpizza/pkg/web"
+)
+
+const (
+	// authorizationCodeTTL is how long authorization codes can be exchanged for tokens.
+	authorizationCodeTTL = time.Minute
+	// clientTokenTTL is how long access tokens issued with the client credentials grant are valid for.
+	clientTokenTTL = time.Hour
+
+	authorizationCodeLength = 32
+	oidcClientSecretLength  = 32
+)
+
+// OIDCClient is an OAuth 2.0 client registered with the built-in OpenID Connect provider. Clients without a secret
+// are public clients, which must use PKCE. Redirect URIs starting with "/" are relative to the issuer.
+type OIDCClient struct {
+	ID           string   `json:"id"`
+	Secret       string   `json:"secret,omitempty"`
+	RedirectURIs []string `json:"redirect_uris"`
+}
+
+// DefaultOIDCClients returns the clients registered when none are configured: a public client for browser-based
+// flows, whose tokens are delivered to the demo callback page, and a confidential client for machine-to-machine flows.
+// The secret of the confidential client is random, so that every instance has a different one.
+func DefaultOIDCClients() []OIDCClient {
+	return []OIDCClient{
+		{ID: "quickpizza-demo", RedirectURIs: []string{"/oauth2/callback"}},
+		{ID: "quickpizza-service", Secret: util.GenerateAlphaNumToken(oidcClientSecretLength), RedirectURIs: []string{"/oauth2/callback"}},
+	}
+}
+
+// OIDCConfig configures the built-in OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the URL of the provider. If empty, it is derived from the scheme and host of every request.
+	Issuer string
+	// Signer signs ID tokens and client credentials access tokens. It must use RS256, so that clients can verify the
+	// tokens with the published key set.
+	Signer  *jwt.Signer
+	Clients []OIDCClient
+}
+
+// authorizationCode holds what is needed to exchange an authorization code for tokens.
+type authorizationCode struct {
+	clientID      string
+	redirectURI   string
+	userID        int64
+	scope         string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type oidcProvider struct {
+	config   OIDCConfig
+	clients  map[string]OIDCClient
+	verifier *jwt.Verifier
+	// fromTrustedProxy returns whether a request was forwarded by a trusted proxy, see WithTrustedProxies.
+	fromTrustedProxy func(r *http.Request) bool
+
+	mu    sync.Mutex
+	codes map[string]authorizationCode
+}
+
+// oauthError is an OAuth 2.0 error response (RFC 6749, section 5.2).
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// AddOIDCProvider enables a minimal OpenID Connect provider, backed by the users of the Catalog service. It supports
+// the authorization code grant with PKCE, the client credentials grant and the refresh token grant, along with the
+// discovery, JWKS and userinfo endpoints. Access and refresh tokens are the same ones handed out by
+// /api/users/token/login, so they can be used with the rest of the API. It returns an error if config cannot be used.
+func (s *Server) AddOIDCProvider(db *database.Catalog, config OIDCConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	verifier, err := jwt.NewVerifier(config.Signer.Algorithm(), "", config.Signer.KeySource())
+	if err != nil {
+		return fmt.Errorf("creating verifier: %w", err)
+	}
+
+	p := &oidcProvider{
+		config:   config,
+		clients:  map[string]OIDCClient{},
+		verifier: verifier,
+		fromTrustedProxy: func(r *http.Request) bool {
+			return s.isTrustedProxy(remoteIP(r))
+		},
+		codes: map[string]authorizationCode{},
+	}
+	for _, c := range config.Clients {
+		p.clients[c.ID] = c
+	}
+
+	authorizeTemplate := template.Must(template.ParseFS(web.OAuth2, "oauth2/authorize.html"))
+	callbackTemplate := template.Must(template.ParseFS(web.OAuth2, "oauth2/callback.html"))
+
+	s.addJWKS(config.Signer)
+
+	s.router.Group(func(r chi.Router) {
+		s.traceInstaller.Install(r, "oidc")
+
+		r.Get("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+			issuer := p.issuer(r)
+			s.writeJSONResponse(w, r, map[string]any{
+				"issuer":                                issuer,
+				"authorization_endpoint":                issuer + "/oauth2/authorize",
+				"token_endpoint":                        issuer + "/oauth2/token",
+				"userinfo_endpoint":                     issuer + "/oauth2/userinfo",
+				"jwks_uri":                              issuer + "/.well-known/jwks.json",
+				"response_types_supported":              []string{"code"},
+				"grant_types_supported":                 []string{"authorization_code", "client_credentials", "refresh_token"},
+				"subject_types_supported":               []string{"public"},
+				"id_token_signing_alg_values_supported": []string{config.Signer.Algorithm()},
+				"scopes_supported":                      []string{"openid", "profile"},
+				"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+				"code_challenge_methods_supported":      []string{"S256"},
+				"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "preferred_username"},
+			}, http.StatusOK)
+		})
+
+		// renderLogin shows the login form, carrying over the parameters of the authorization request.
+		renderLogin := func(w http.ResponseWriter, r *http.Request, params url.Values, username, loginError string, status int) {
+			hidden := map[string]string{}
+			for _, name := range []string{
+				"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "code_challenge", "code_challenge_method",
+			} {
+				if v := params.Get(name); v != "" {
+					hidden[name] = v
+				}
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(status)
+			err := authorizeTemplate.Execute(w, map[string]any{
+				"ClientID": params.Get("client_id"),
+				"Username": username,
+				"Error":    loginError,
+				"Params":   hidden,
+			})
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Rendering authorization page", "err", err)
+			}
+		}
+
+		// validateAuthorizationRequest checks the authorization request in params. Errors about the client or the
+		// redirect URI are reported to the user, as redirecting would not be safe; any other error is reported to the
+		// client through the redirect URI.
+		validateAuthorizationRequest := func(w http.ResponseWriter, r *http.Request, params url.Values) bool {
+			client, ok := p.clients[params.Get("client_id")]
+			if !ok {
+				http.Error(w, "unknown client_id", http.StatusBadRequest)
+				return false
+			}
+
+			redirectURI := params.Get("redirect_uri")
+			if !p.validRedirectURI(r, client, redirectURI) {
+				http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+				return false
+			}
+
+			var oerr *oauthError
+			switch {
+			case params.Get("response_type") != "code":
+				oerr = &oauthError{"unsupported_response_type", "only the code response type is supported"}
+			case client.Secret == "" && params.Get("code_challenge") == "":
+				oerr = &oauthError{"invalid_request", "public clients must use PKCE"}
+			// The plain method, the default, would send the verifier itself in the authorization request.
+			case params.Get("code_challenge") != "" && params.Get("code_challenge_method") != "S256",
+				!slices.Contains([]string{"", "S256"}, params.Get("code_challenge_method")):
+				oerr = &oauthError{"invalid_request", "code_challenge_method must be S256"}
+			}
+			if oerr != nil {
+				redirectWithParams(w, r, redirectURI, url.Values{
+					"error":             {oerr.Error},
+					"error_description": {oerr.Description},
+					"state":             {params.Get("state")},
+				})
+				return false
+			}
+
+			return true
+		}
+
+		r.Get("/oauth2/authorize", func(w http.ResponseWriter, r *http.Request) {
+			params := r.URL.Query()
+			if !validateAuthorizationRequest(w, r, params) {
+				return
+			}
+
+			renderLogin(w, r, params, "", "", http.StatusOK)
+		})
+
+		r.Post("/oauth2/authorize", func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, "invalid form", http.StatusBadRequest)
+				return
+			}
+
+			params := r.PostForm
+			if !validateAuthorizationRequest(w, r, params) {
+				return
+			}
+
+			username := params.Get("username")
+			user, err := s.loginUser(r, db, username, params.Get("password"))
+			var lockout *database.LockoutError
+			if errors.As(err, &lockout) {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(lockout.RetryAfter)))
+				renderLogin(w, r, params, username, "Too many failed attempts. Please try again later.", http.StatusTooManyRequests)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to login user", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			if user == nil {
+				renderLogin(w, r, params, username, "Invalid username or password.", http.StatusUnauthorized)
+				return
+			}
+
+			code := util.GenerateAlphaNumToken(authorizationCodeLength)
+			p.storeCode(code, authorizationCode{
+				clientID:      params.Get("client_id"),
+				redirectURI:   params.Get("redirect_uri"),
+				userID:        user.ID,
+				scope:         params.Get("scope"),
+				nonce:         params.Get("nonce"),
+				codeChallenge: params.Get("code_challenge"),
+				expiresAt:     time.Now().Add(authorizationCodeTTL),
+			})
+
+			redirectWithParams(w, r, params.Get("redirect_uri"), url.Values{
+				"code":  {code},
+				"state": {params.Get("state")},
+			})
+		})
+
+		r.Post("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-store")
+
+			if err := r.ParseForm(); err != nil {
+				s.writeJSONResponse(w, r, oauthError{"invalid_request", "invalid form"}, http.StatusBadRequest)
+				return
+			}
+
+			client, ok := p.authenticateClient(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="quickpizza"`)
+				s.writeJSONResponse(w, r, oauthError{"invalid_client", "client authentication failed"}, http.StatusUnauthorized)
+				return
+			}
+
+			switch r.PostForm.Get("grant_type") {
+			case "authorization_code":
+				code, ok := p.takeCode(r.PostForm.Get("code"))
+				if !ok || code.clientID != client.ID || code.redirectURI != r.PostForm.Get("redirect_uri") {
+					s.writeJSONResponse(w, r, oauthError{"invalid_grant", "invalid or expired authorization code"}, http.StatusBadRequest)
+					return
+				}
+
+				if !verifyCodeChallenge(code, r.PostForm.Get("code_verifier")) {
+					s.writeJSONResponse(w, r, oauthError{"invalid_grant", "invalid code_verifier"}, http.StatusBadRequest)
+					return
+				}
+
+				user, err := db.GetUser(r.Context(), code.userID)
+				if err != nil {
+					s.writeJSONResponse(w, r, oauthError{"invalid_grant", "user does not exist anymore"}, http.StatusBadRequest)
+					return
+				}
+
+				session, err := db.CreateSession(r.Context(), user)
+				if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to create session", "err", err)
+					s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+					return
+				}
+
+				resp, err := s.newTokenResponse(session)
+				if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to sign token", "err", err)
+					s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+					return
+				}
+
+				body := map[string]any{
+					"access_token":  resp.Token,
+					"token_type":    "Bearer",
+					"expires_in":    resp.ExpiresIn,
+					"refresh_token": resp.RefreshToken,
+					"scope":         code.scope,
+				}
+
+				if slices.Contains(strings.Fields(code.scope), "openid") {
+					idToken, err := config.Signer.Sign(jwt.Claims{
+						Issuer:            p.issuer(r),
+						Subject:           strconv.FormatInt(user.ID, 10),
+						Audience:          client.ID,
+						ExpiresAt:         session.ExpiresAt.Unix(),
+						Nonce:             code.nonce,
+						PreferredUsername: user.Username,
+					})
+					if err != nil {
+						s.log.ErrorContext(r.Context(), "Failed to sign ID token", "err", err)
+						s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+						return
+					}
+					body["id_token"] = idToken
+				}
+
+				s.writeJSONResponse(w, r, body, http.StatusOK)
+
+			case "client_credentials":
+				if client.Secret == "" {
+					s.writeJSONResponse(w, r, oauthError{"unauthorized_client", "public clients cannot use client credentials"}, http.StatusBadRequest)
+					return
+				}
+
+				scope := r.PostForm.Get("scope")
+				token, err := config.Signer.Sign(jwt.Claims{
+					Issuer:    p.issuer(r),
+					Subject:   client.ID,
+					ClientID:  client.ID,
+					ExpiresAt: time.Now().Add(clientTokenTTL).Unix(),
+					ID:        util.GenerateAlphaNumToken(16),
+					Scope:     scope,
+				})
+				if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to sign access token", "err", err)
+					s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+					return
+				}
+
+				s.writeJSONResponse(w, r, map[string]any{
+					"access_token": token,
+					"token_type":   "Bearer",
+					"expires_in":   int(clientTokenTTL.Seconds()),
+					"scope":        scope,
+				}, http.StatusOK)
+
+			case "refresh_token":
+				session, err := db.RefreshSession(r.Context(), r.PostForm.Get("refresh_token"))
+				if isSessionError(err) {
+					s.writeJSONResponse(w, r, oauthError{"invalid_grant", err.Error()}, http.StatusBadRequest)
+					return
+				} else if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to refresh session", "err", err)
+					s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+					return
+				}
+
+				resp, err := s.newTokenResponse(session)
+				if err != nil {
+					s.log.ErrorContext(r.Context(), "Failed to sign token", "err", err)
+					s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+					return
+				}
+
+				s.writeJSONResponse(w, r, map[string]any{
+					"access_token":  resp.Token,
+					"token_type":    "Bearer",
+					"expires_in":    resp.ExpiresIn,
+					"refresh_token": resp.RefreshToken,
+				}, http.StatusOK)
+
+			default:
+				s.writeJSONResponse(w, r, oauthError{"unsupported_grant_type", ""}, http.StatusBadRequest)
+			}
+		})
+
+		userinfo := func(w http.ResponseWriter, r *http.Request) {
+			token := getRequestToken(r)
+			if token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			// Tokens issued with the client credentials grant represent a client, not a user.
+			if jwt.LooksLikeJWT(token) {
+				if claims, err := p.verifier.Verify(r.Context(), token); err == nil && claims.ClientID != "" {
+					s.writeJSONResponse(w, r, map[string]string{"sub": claims.Subject, "client_id": claims.ClientID}, http.StatusOK)
+					return
+				}
+			}
+
+			user, err := s.authenticate(r.Context(), db, token)
+			if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
+				s.log.ErrorContext(r.Context(), "Failed to check token", "err", err)
+				s.writeJSONErrorResponse(w, r, err, errorStatus(err, http.StatusInternalServerError))
+				return
+			}
+
+			s.writeJSONResponse(w, r, map[string]string{
+				"sub":                strconv.FormatInt(user.ID, 10),
+				"preferred_username": user.Username,
+			}, http.StatusOK)
+		}
+		r.Get("/oauth2/userinfo", userinfo)
+		r.Post("/oauth2/userinfo", userinfo)
+
+		// Demo client page, where the authorization code or error is shown.
+		r.Get("/oauth2/callback", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			err := callbackTemplate.Execute(w, map[string]string{
+				"Code":             q.Get("code"),
+				"State":            q.Get("state"),
+				"Error":            q.Get("error"),
+				"ErrorDescription": q.Get("error_description"),
+			})
+			if err != nil {
+				s.log.ErrorContext(r.Context(), "Rendering callback page", "err", err)
+			}
+		})
+	})
+
+	return nil
+}
+
+// issuer returns the issuer URL, which is derived from r if it is not configured. The X-Forwarded-Proto and
+// X-Forwarded-Host headers are only honored in requests from trusted proxies, as clients could otherwise pick the
+// issuer, and thus the URLs that relative redirect URIs are checked against.
+func (p *oidcProvider) issuer(r *http.Request) string {
+	if p.config.Issuer != "" {
+		return strings.TrimSuffix(p.config.Issuer, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if p.fromTrustedProxy(r) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+
+	return scheme + "://" + host
+}
+
+// validRedirectURI returns whether uri is registered for client.
+func (p *oidcProvider) validRedirectURI(r *http.Request, client OIDCClient, uri string) bool {
+	for _, registered := range client.RedirectURIs {
+		if strings.HasPrefix(registered, "/") {
+			registered = p.issuer(r) + registered
+		}
+		if uri == registered {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateClient returns the client that made r, authenticated with HTTP Basic auth or form parameters. Public
+// clients only need to send their ID.
+func (p *oidcProvider) authenticateClient(r *http.Request) (OIDCClient, bool) {
+	id, secret, basic := r.BasicAuth()
+	if basic {
+		// Credentials are form-encoded before being put in the header.
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	client, ok := p.clients[id]
+	if !ok {
+		return OIDCClient{}, false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		return OIDCClient{}, false
+	}
+
+	return client, true
+}
+
+func (p *oidcProvider) storeCode(code string, data authorizationCode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for c, d := range p.codes {
+		if now.After(d.expiresAt) {
+			delete(p.codes, c)
+		}
+	}
+
+	p.codes[code] = data
+}
+
+// takeCode returns the data of code, if it exists and has not expired. Codes can only be used once.
+func (p *oidcProvider) takeCode(code string) (authorizationCode, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data, ok := p.codes[code]
+	delete(p.codes, code)
+	if !ok || time.Now().After(data.expiresAt) {
+		return authorizationCode{}, false
+	}
+	return data, true
+}
+
+// verifyCodeChallenge checks verifier against the PKCE challenge of code (RFC 7636), if it has one. Challenges always
+// use the S256 method.
+func verifyCodeChallenge(code authorizationCode, verifier string) bool {
+	if code.codeChallenge == "" {
+		return true
+	}
+
+	digest := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(code.codeChallenge)) == 1
+}
+
+// redirectWithParams redirects to uri, adding the non-empty params to its query.
+func redirectWithParams(w http.ResponseWriter, r *http.Request, uri string, params url.Values) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	q := u.Query()
+	for name, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			q.Set(name, values[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// Validate returns an error if the config cannot be used.
+func (c OIDCConfig) Validate() error {
+	if c.Signer == nil || c.Signer.Algorithm() != jwt.RS256 {
+		return errors.New("the OpenID Connect provider requires an RS256 signer")
+	}
+	if len(c.Clients) == 0 {
+		return errors.New("no OpenID Connect clients configured")
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/quickpizza/pkg/jwt"
+)
+
+// Example from RFC 7636, Appendix B.
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		challenge string
+		verifier  string
+		want      bool
+	}{
+		{name: "matching verifier", challenge: testCodeChallenge, verifier: testCodeVerifier, want: true},
+		{name: "wrong verifier", challenge: testCodeChallenge, verifier: testCodeVerifier + "x", want: false},
+		{name: "missing verifier", challenge: testCodeChallenge, verifier: "", want: false},
+		{name: "plain verifier", challenge: testCodeChallenge, verifier: testCodeChallenge, want: false},
+		{name: "no challenge", challenge: "", verifier: "", want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := verifyCodeChallenge(authorizationCode{codeChallenge: tc.challenge}, tc.verifier); got != tc.want {
+				t.Fatalf("expected %t, got %t", tc.want, got)
+			}
+		})
+	}
+}
+
+// newOIDCTestServer returns a server running the OpenID Connect provider with the default clients.
+func newOIDCTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	key, err := jwt.GenerateRSAKey()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := jwt.NewRS256Signer(key, "quickpizza")
+	if err != nil {
+		t.Fatalf("creating signer: %v", err)
+	}
+
+	s := newTestServer(t)
+	if err := s.AddOIDCProvider(newTestCatalog(t), OIDCConfig{Signer: signer, Clients: DefaultOIDCClients()}); err != nil {
+		t.Fatalf("adding OpenID Connect provider: %v", err)
+	}
+	return s
+}
+
+func TestOIDCAuthorizationRequiresS256(t *testing.T) {
+	t.Parallel()
+
+	s := newOIDCTestServer(t)
+
+	for _, tc := range []struct {
+		name      string
+		challenge string
+		method    string
+		wantError string
+	}{
+		{name: "S256", challenge: testCodeChallenge, method: "S256"},
+		{name: "no challenge", wantError: "invalid_request"},
+		{name: "default method", challenge: testCodeChallenge, wantError: "invalid_request"},
+		{name: "plain method", challenge: testCodeVerifier, method: "plain", wantError: "invalid_request"},
+		{name: "method without challenge", method: "S256", wantError: "invalid_request"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			params := url.Values{
+				"client_id":     {"quickpizza-demo"},
+				"redirect_uri":  {"http://example.com/oauth2/callback"},
+				"response_type": {"code"},
+				"state":         {"xyz"},
+			}
+			if tc.challenge != "" {
+				params.Set("code_challenge", tc.challenge)
+			}
+			if tc.method != "" {
+				params.Set("code_challenge_method", tc.method)
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/oauth2/authorize?"+params.Encode(), nil))
+
+			if tc.wantError == "" {
+				if w.Code != http.StatusOK {
+					t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+				}
+				return
+			}
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil || w.Code != http.StatusFound {
+				t.Fatalf("expected a redirect, got status %d with Location %q", w.Code, w.Header().Get("Location"))
+			}
+			if got := location.Query().Get("error"); got != tc.wantError {
+				t.Fatalf("expected error %q, got %q", tc.wantError, got)
+			}
+			if got := location.Query().Get("state"); got != "xyz" {
+				t.Fatalf("expected state %q, got %q", "xyz", got)
+			}
+		})
+	}
+}
+
+func TestOIDCCodeExchangeChecksVerifier(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		verifier   string
+		wantStatus int
+	}{
+		{name: "matching verifier", verifier: testCodeVerifier, wantStatus: http.StatusOK},
+		{name: "wrong verifier", verifier: strings.Repeat("a", 43), wantStatus: http.StatusBadRequest},
+		{name: "missing verifier", wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newOIDCTestServer(t)
+			const redirectURI = "http://example.com/oauth2/callback"
+
+			// Log in to get an authorization code.
+			form := url.Values{
+				"client_id":             {"quickpizza-demo"},
+				"redirect_uri":          {redirectURI},
+				"response_type":         {"code"},
+				"code_challenge":        {testCodeChallenge},
+				"code_challenge_method": {"S256"},
+				"username":              {"default"},
+				"password":              {"12345678"},
+			}
+			r := httptest.NewRequest(http.MethodPost, "/oauth2/authorize", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			location, err := url.Parse(w.Header().Get("Location"))
+			if err != nil || location.Query().Get("code") == "" {
+				t.Fatalf("expected a redirect with a code, got status %d with Location %q: %s", w.Code, w.Header().Get("Location"), w.Body)
+			}
+
+			// Exchange the code.
+			form = url.Values{
+				"grant_type":   {"authorization_code"},
+				"client_id":    {"quickpizza-demo"},
+				"redirect_uri": {redirectURI},
+				"code":         {location.Query().Get("code")},
+			}
+			if tc.verifier != "" {
+				form.Set("code_verifier", tc.verifier)
+			}
+			r = httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w = httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, w.Code, w.Body)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if tc.wantStatus == http.StatusOK && body["access_token"] == nil {
+				t.Fatalf("expected an access token, got %v", body)
+			}
+			if tc.wantStatus != http.StatusOK && body["error"] != "invalid_grant" {
+				t.Fatalf("expected an invalid_grant error, got %v", body)
+			}
+		})
+	}
+}
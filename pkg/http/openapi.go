@@ -0,0 +1,304 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/grafana/quickpizza/pkg/model"
+	"github.com/grafana/quickpizza/pkg/openapi"
+	"github.com/grafana/quickpizza/pkg/web"
+)
+
+// maxValidatedBodyBytes is the size of the largest request bodies validated against the OpenAPI document. Larger
+// bodies are left to their handler, which rejects them if they are too large.
+const maxValidatedBodyBytes = 1 << 20
+
+// undocumentedRoutes lists the routes the OpenAPI document does not describe on purpose: the ones only used between
+// QuickPizza services, and the page of the demo OAuth 2.0 client.
+var undocumentedRoutes = []string{
+	"GET /api/banned-words",
+	"POST /api/internal/idempotency-keys/{}",
+	"POST /api/internal/recommendations",
+	"GET /api/internal/recommendations/{}",
+	"POST /api/users/token/authenticate",
+	"GET /oauth2/callback",
+}
+
+// WithOpenAPIValidation makes the server reject requests that do not match doc with 400, before they reach their
+// handler. The OAuth 2.0 endpoints are not validated, as they report invalid requests the way the protocol requires.
+func WithOpenAPIValidation(doc *openapi.Document) ServerOption {
+	return func(s *Server) {
+		s.openAPI = doc
+		s.validateRequests = true
+	}
+}
+
+// WithOpenAPIResponseValidation makes the server check that responses match doc, which is meant for development and
+// tests: responses that do not are logged, and replaced with 500 errors, so that they cannot go unnoticed. Error
+// responses with a status the operation does not document must be problem details.
+func WithOpenAPIResponseValidation(doc *openapi.Document) ServerOption {
+	return func(s *Server) {
+		s.openAPI = doc
+		s.validateResponses = true
+	}
+}
+
+// openAPIMiddleware validates requests, and responses if enabled, against the operation of the OpenAPI document they
+// match. Requests the document does not describe are passed through.
+func (s *Server) openAPIMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, params := s.openAPI.FindOperation(r.Method, r.URL.Path)
+		if op == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if s.validateRequests && !strings.HasPrefix(op.Path, "/oauth2/") {
+			var body []byte
+			if op.RequestBody != nil && r.Body != nil {
+				var err error
+				body, err = io.ReadAll(io.LimitReader(r.Body, maxValidatedBodyBytes+1))
+				if err != nil {
+					s.writeJSONErrorResponse(w, r, fmt.Errorf("reading request body: %w", err), http.StatusBadRequest)
+					return
+				}
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				if len(body) > maxValidatedBodyBytes {
+					body = nil
+				}
+			}
+
+			if errs := op.ValidateRequest(r, params, body); len(errs) > 0 {
+				s.writeJSONErrorResponse(w, r, openAPIValidationError(errs), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if !s.validateResponses || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+
+		if errs := s.validateResponse(op, bw.status, w.Header(), bw.body.Bytes()); len(errs) > 0 {
+			s.log.ErrorContext(r.Context(), "Response does not match the OpenAPI document",
+				"operation", op.OperationID, "status", bw.status, "errors", errs.Error())
+			for _, name := range []string{"Content-Type", "Content-Length", "ETag", "Location"} {
+				w.Header().Del(name)
+			}
+			err := fmt.Errorf("the %d response to %s does not match the OpenAPI document: %w", bw.status, op.OperationID, errs)
+			s.writeJSONErrorResponse(w, r, withProblemCode("invalid_response", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(bw.status)
+		_, _ = w.Write(bw.body.Bytes())
+	})
+}
+
+// validateResponse validates a response to op. Errors that can happen with any request, such as 401 or 429, are not
+// documented for every operation, so responses with undocumented error statuses only need to be problem details.
+func (s *Server) validateResponse(op *openapi.Operation, status int, header http.Header, body []byte) openapi.Errors {
+	if op.Response(status) != nil || status < http.StatusBadRequest {
+		return op.ValidateResponse(status, header, body)
+	}
+
+	problem := &openapi.Response{Content: map[string]*openapi.MediaType{
+		problemContentType: {Schema: s.openAPI.Components.Schemas["Problem"]},
+	}}
+	return problem.Validate(header, body)
+}
+
+// openAPIValidationError reports errs as invalid fields of the request.
+func openAPIValidationError(errs openapi.Errors) model.ValidationError {
+	fields := make(model.ValidationError, len(errs))
+	for i, err := range errs {
+		fields[i] = model.FieldError{Field: err.Field, Detail: err.Detail}
+		if err.Field == "" {
+			fields[i].Field = err.In
+		}
+	}
+	return fields
+}
+
+// readCloser reads the body of a request, which was partially read already, and closes the original one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// routeParam matches the parameters of chi route patterns and OpenAPI path templates, e.g. {id:\d+} and {id}.
+var routeParam = regexp.MustCompile(`\{[^/]*\}`)
+
+// servedRoutes returns the methods served by the routes of the server, keyed by path with parameters replaced by {}, or
+// nil methods if a route serves all of them. Catch-all routes, other than the frontend, are returned separately as the
+// prefixes of the paths they serve, e.g. /api/ for the gateway.
+func (s *Server) servedRoutes() (map[string][]string, []string, error) {
+	routes := map[string][]string{}
+	var prefixes []string
+	err := chi.Walk(s.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if prefix, ok := strings.CutSuffix(route, "*"); ok {
+			if prefix != "/" && !slices.Contains(prefixes, prefix) {
+				prefixes = append(prefixes, prefix)
+			}
+			return nil
+		}
+		path := routeParam.ReplaceAllString(route, "{}")
+		if methods, ok := routes[path]; !ok || methods != nil {
+			routes[path] = append(methods, method)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for path, methods := range routes {
+		if len(methods) >= len(openapi.Methods) {
+			routes[path] = nil
+		}
+	}
+	return routes, prefixes, nil
+}
+
+// CheckOpenAPIRoutes compares the API routes of the server with the paths of its OpenAPI document. It fails if a
+// route, other than the internal ones, is not documented. If complete is set, which requires all services to be
+// enabled, it also fails if a documented operation is not served.
+func (s *Server) CheckOpenAPIRoutes(complete bool) error {
+	if s.openAPI == nil {
+		return errors.New("the server has no OpenAPI document")
+	}
+
+	routes, _, err := s.servedRoutes()
+	if err != nil {
+		return err
+	}
+
+	documented := map[string]*openapi.PathItem{}
+	for path, item := range s.openAPI.Paths {
+		documented[routeParam.ReplaceAllString(path, "{}")] = item
+	}
+
+	var errs []error
+	for path, methods := range routes {
+		item, ok := documented[path]
+		if !ok && !isAPIRoute(path) {
+			continue
+		}
+		if methods == nil && !ok {
+			errs = append(errs, fmt.Errorf("route %s is not documented", path))
+		}
+		for _, method := range methods {
+			if (!ok || item.Operation(method) == nil) && !slices.Contains(undocumentedRoutes, method+" "+path) {
+				errs = append(errs, fmt.Errorf("route %s %s is not documented", method, path))
+			}
+		}
+	}
+
+	if complete {
+		for _, op := range s.openAPI.Operations() {
+			path := routeParam.ReplaceAllString(op.Path, "{}")
+			methods, ok := routes[path]
+			if !ok || (methods != nil && !slices.Contains(methods, op.Method)) {
+				errs = append(errs, fmt.Errorf("documented operation %s %s is not served", op.Method, op.Path))
+			}
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+func isAPIRoute(path string) bool {
+	for _, prefix := range []string{"/api/", "/.well-known/", "/oauth2/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddOpenAPI serves the OpenAPI document at /api/openapi.yaml and /api/openapi.json, and an explorer of the API at
+// /docs. The document only lists the operations this instance serves, including the ones its gateway proxies to other
+// services, so it is built on the first request, once all services are enabled.
+func (s *Server) AddOpenAPI(spec []byte) {
+	var (
+		once     sync.Once
+		yamlSpec []byte
+		jsonSpec []byte
+		err      error
+	)
+	build := func() {
+		yamlSpec, err = s.servedOpenAPI(spec)
+		if err == nil {
+			jsonSpec, err = openapi.ToJSON(yamlSpec)
+		}
+		if err != nil {
+			s.log.Error("Building OpenAPI document", "err", err)
+		}
+	}
+
+	serve := func(contentType string, data *[]byte) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			once.Do(build)
+			if err != nil {
+				s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", contentType)
+			_, _ = w.Write(*data)
+		}
+	}
+
+	s.router.Get("/api/openapi.yaml", serve("application/yaml", &yamlSpec))
+	s.router.Get("/api/openapi.json", serve("application/json", &jsonSpec))
+	s.router.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := web.Explorer.ReadFile("explorer/index.html")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(data)
+	})
+}
+
+// servedOpenAPI removes the operations the server does not serve from spec. The document is returned unchanged if the
+// server serves all of them.
+func (s *Server) servedOpenAPI(spec []byte) ([]byte, error) {
+	routes, prefixes, err := s.servedRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	all := true
+	served := func(method, path string) bool {
+		methods, ok := routes[routeParam.ReplaceAllString(path, "{}")]
+		if ok && (methods == nil || slices.Contains(methods, method)) {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		all = false
+		return false
+	}
+
+	filtered, err := openapi.Filter(spec, served)
+	if err != nil || all {
+		return spec, err
+	}
+	return filtered, nil
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grafana/quickpizza"
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/jwt"
+	"github.com/grafana/quickpizza/pkg/openapi"
+)
+
+// newCompleteServer returns a server running every service that has routes in the OpenAPI document, as it does when
+// all services run in the same instance.
+func newCompleteServer(t *testing.T) *Server {
+	t.Helper()
+
+	spec, err := openapi.Load(quickpizza.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("loading OpenAPI document: %v", err)
+	}
+
+	s := newTestServer(t, WithOpenAPIValidation(spec), WithOpenAPIResponseValidation(spec))
+	s.AddLivenessProbes()
+	s.AddPrometheusHandler()
+	s.AddOpenAPI(quickpizza.OpenAPISpec)
+	s.AddHTTPTesting()
+	s.AddTestK6IO()
+	s.AddConfigHandler(map[string]string{})
+	s.AddFrontend(false)
+	s.AddWebSocket()
+
+	catalog := newTestCatalog(t)
+	cp, err := database.NewCopy(database.Options{ConnString: filepath.Join(t.TempDir(), "copy.db")})
+	if err != nil {
+		t.Fatalf("creating copy database: %v", err)
+	}
+	t.Cleanup(func() { _ = cp.Close() })
+
+	s.AddCatalogHandler(catalog, NewCopyClient("http://localhost:3333"))
+	s.AddCopyHandler(cp)
+	s.AddRecommendations(NewCatalogClient("http://localhost:3333"), NewCopyClient("http://localhost:3333"))
+
+	key, err := jwt.GenerateRSAKey()
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	signer, err := jwt.NewRS256Signer(key, "quickpizza")
+	if err != nil {
+		t.Fatalf("creating signer: %v", err)
+	}
+	if err := s.AddOIDCProvider(catalog, OIDCConfig{Signer: signer, Clients: DefaultOIDCClients()}); err != nil {
+		t.Fatalf("adding OpenID Connect provider: %v", err)
+	}
+
+	return s
+}
+
+// TestRoutesMatchOpenAPIDocument fails if a route is added without documenting it in quickpizza-openapi.yaml, or an
+// operation is documented without a route serving it.
+func TestRoutesMatchOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	s := newCompleteServer(t)
+	if err := s.CheckOpenAPIRoutes(true); err != nil {
+		t.Fatalf("routes do not match the OpenAPI document:\n%v", err)
+	}
+}
+
+func TestCheckOpenAPIRoutes(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		addRoute func(s *Server)
+		complete bool
+		want     string
+	}{
+		{
+			name:     "undocumented route",
+			addRoute: func(s *Server) { s.router.Get("/api/secret", http.NotFound) },
+			want:     "route GET /api/secret is not documented",
+		},
+		{
+			name:     "undocumented method",
+			addRoute: func(s *Server) { s.router.Delete("/api/tools", http.NotFound) },
+			want:     "route DELETE /api/tools is not documented",
+		},
+		{
+			name:     "undocumented route with parameter",
+			addRoute: func(s *Server) { s.router.Get("/api/tools/{id:\\d+}", http.NotFound) },
+			want:     "route GET /api/tools/{} is not documented",
+		},
+		{
+			name:     "undocumented catch-all route",
+			addRoute: func(s *Server) { s.router.HandleFunc("/api/anything", http.NotFound) },
+			want:     "route /api/anything is not documented",
+		},
+		{
+			name:     "missing operation",
+			addRoute: func(s *Server) {},
+			complete: true,
+			want:     "documented operation GET /api/tools is not served",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := openapi.Load(quickpizza.OpenAPISpec)
+			if err != nil {
+				t.Fatalf("loading OpenAPI document: %v", err)
+			}
+
+			s := newTestServer(t, WithOpenAPIValidation(spec))
+			tc.addRoute(s)
+
+			err = s.CheckOpenAPIRoutes(tc.complete)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestCheckOpenAPIRoutesIgnoresInternalRoutes(t *testing.T) {
+	t.Parallel()
+
+	spec, err := openapi.Load(quickpizza.OpenAPISpec)
+	if err != nil {
+		t.Fatalf("loading OpenAPI document: %v", err)
+	}
+
+	s := newTestServer(t, WithOpenAPIValidation(spec))
+	s.router.Get("/api/banned-words", http.NotFound)
+	s.router.Get("/not-api", http.NotFound)
+	if err := s.CheckOpenAPIRoutes(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
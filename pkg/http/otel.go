@@ -2,11 +2,12 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -91,9 +92,13 @@ type OTelInstaller struct {
 	insecure  bool
 	installed bool
 	endpoint  *url.URL
+	config    OTelConfig
+
+	mu        sync.Mutex
+	shutdowns []func(context.Context) error
 }
 
-func createTraceProvider(ctx context.Context, endpoint *url.URL, otlpProtocol string, resource *resource.Resource) (trace.TracerProvider, error) {
+func createTraceProvider(ctx context.Context, endpoint *url.URL, otlpProtocol string, resource *resource.Resource) (*sdktrace.TracerProvider, error) {
 	var trace_client otlptrace.Client
 
 	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
@@ -189,15 +194,27 @@ func createMetricProvider(ctx context.Context, endpoint *url.URL, otlpProtocol s
 	return p, nil
 }
 
+// OTelConfig configures where traces and metrics are exported to, and the resource they describe.
+type OTelConfig struct {
+	// Endpoint is the URL of the OTLP collector, e.g. http://alloy:4318.
+	Endpoint string
+	// Protocol is the OTLP protocol used to export, either "http/protobuf" or "grpc".
+	Protocol string
+
+	ServiceName       string
+	ServiceNamespace  string
+	ServiceInstanceID string
+}
+
 // NewOTelInstaller creates a new OTelInstaller.
 // Call Install to set up traces and metrics.
-func NewOTelInstaller(ctx context.Context, endpointUrl string) (*OTelInstaller, error) {
-	u, err := url.Parse(endpointUrl)
+func NewOTelInstaller(ctx context.Context, config OTelConfig) (*OTelInstaller, error) {
+	u, err := url.Parse(config.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("parsing endpoint url: %w", err)
 	}
 
-	return &OTelInstaller{endpoint: u}, nil
+	return &OTelInstaller{endpoint: u, config: config}, nil
 }
 
 // Insecure instructs the OTelInstaller to trust incoming trace IDs.
@@ -205,46 +222,25 @@ func (t *OTelInstaller) Insecure() {
 	t.insecure = true
 }
 
-// Install OTel,
-// - sets global OTel tracer and meter providers when called first time
-// - enable runtime metrics only once
-// - enable HTTP tracing and metrics on the supplied chi.Router
-// extraOpts take precedence over the default opts
-func (t *OTelInstaller) Install(r chi.Router, serviceComponent string, extraOpts ...otelhttp.Option) error {
-
+// providers creates the tracer and meter providers of serviceComponent. The first time, it also sets them as the
+// global providers and starts the runtime instrumentation.
+func (t *OTelInstaller) providers(serviceComponent string) (trace.TracerProvider, *sdkmetric.MeterProvider, error) {
 	// TODO: can leverage default OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES env vars
-	serviceName, ok := os.LookupEnv("QUICKPIZZA_OTEL_SERVICE_NAME")
-	if !ok {
-		serviceName = "quickpizza"
-	}
-	serviceNamespace, ok := os.LookupEnv("QUICKPIZZA_OTEL_SERVICE_NAMESPACE")
-	if !ok {
-		serviceNamespace = "quickpizza"
-	}
-	serviceInstanceID, ok := os.LookupEnv("QUICKPIZZA_OTEL_SERVICE_INSTANCE_ID")
-	if !ok {
-		serviceInstanceID = "local"
-	}
 
 	// We discard the error here as it cannot possibly take place with the parameters we use.
 	res, _ := resource.Merge(
 		resource.Default(),
 		resource.NewWithAttributes(
 			semconv.SchemaURL,
-			semconv.ServiceName(serviceName),
+			semconv.ServiceName(t.config.ServiceName),
 			attribute.KeyValue{Key: "service.component", Value: attribute.StringValue(serviceComponent)},
-			attribute.KeyValue{Key: "service.namespace", Value: attribute.StringValue(serviceNamespace)},
-			attribute.KeyValue{Key: "service.instance.id", Value: attribute.StringValue(serviceInstanceID)},
+			attribute.KeyValue{Key: "service.namespace", Value: attribute.StringValue(t.config.ServiceNamespace)},
+			attribute.KeyValue{Key: "service.instance.id", Value: attribute.StringValue(t.config.ServiceInstanceID)},
 		),
 	)
 
-	protocol, ok := os.LookupEnv("OTEL_EXPORTER_OTLP_PROTOCOL")
-	if !ok {
-		protocol = "http/protobuf"
-	}
-
 	ctx := context.Background()
-	var tp trace.TracerProvider
+	var tp *sdktrace.TracerProvider
 	var mp *sdkmetric.MeterProvider
 	var err error
 
@@ -254,17 +250,21 @@ func (t *OTelInstaller) Install(r chi.Router, serviceComponent string, extraOpts
 		mp = sdkmetric.NewMeterProvider()
 	} else {
 		// Create providers that export to the configured endpoint
-		tp, err = createTraceProvider(ctx, t.endpoint, protocol, res)
+		tp, err = createTraceProvider(ctx, t.endpoint, t.config.Protocol, res)
 		if err != nil {
-			return fmt.Errorf("creating trace provider: %w", err)
+			return nil, nil, fmt.Errorf("creating trace provider: %w", err)
 		}
 
-		mp, err = createMetricProvider(ctx, t.endpoint, protocol, res)
+		mp, err = createMetricProvider(ctx, t.endpoint, t.config.Protocol, res)
 		if err != nil {
-			return fmt.Errorf("creating metric provider: %w", err)
+			return nil, nil, fmt.Errorf("creating metric provider: %w", err)
 		}
 	}
 
+	t.mu.Lock()
+	t.shutdowns = append(t.shutdowns, tp.Shutdown, mp.Shutdown)
+	t.mu.Unlock()
+
 	if !t.installed {
 		// Set global providers only once
 		// TODO: it's not great since we set first component to be called to be global.
@@ -277,12 +277,59 @@ func (t *OTelInstaller) Install(r chi.Router, serviceComponent string, extraOpts
 			runtime.WithMeterProvider(mp),
 			runtime.WithMinimumReadMemStatsInterval(time.Second))
 		if err != nil {
-			return fmt.Errorf("starting runtime instrumentation: %w", err)
+			return nil, nil, fmt.Errorf("starting runtime instrumentation: %w", err)
 		}
 
 		t.installed = true
 	}
 
+	return tp, mp, nil
+}
+
+// TracerProvider returns the tracer provider of serviceComponent, for servers other than the HTTP one, e.g. gRPC. Like
+// Install, it sets the global providers the first time.
+func (t *OTelInstaller) TracerProvider(serviceComponent string) (trace.TracerProvider, error) {
+	tp, _, err := t.providers(serviceComponent)
+	if err != nil {
+		return nil, err
+	}
+
+	return otelpyroscope.NewTracerProvider(tp), nil
+}
+
+// Shutdown flushes the spans and metrics that were not exported yet, and stops the providers created so far. Spans
+// and metrics recorded afterwards are dropped.
+func (t *OTelInstaller) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	shutdowns := t.shutdowns
+	t.shutdowns = nil
+	t.mu.Unlock()
+
+	var errs []error
+	for _, shutdown := range shutdowns {
+		if err := shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TrustsClientTraceID returns whether incoming trace IDs are trusted, see Insecure.
+func (t *OTelInstaller) TrustsClientTraceID() bool {
+	return t.insecure
+}
+
+// Install OTel,
+// - sets global OTel tracer and meter providers when called first time
+// - enable runtime metrics only once
+// - enable HTTP tracing and metrics on the supplied chi.Router
+// extraOpts take precedence over the default opts
+func (t *OTelInstaller) Install(r chi.Router, serviceComponent string, extraOpts ...otelhttp.Option) error {
+	tp, mp, err := t.providers(serviceComponent)
+	if err != nil {
+		return err
+	}
+
 	defaultOpts := []otelhttp.Option{
 		otelhttp.WithTracerProvider(otelpyroscope.NewTracerProvider(tp)),
 		otelhttp.WithMeterProvider(mp),
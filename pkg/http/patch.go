@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/quickpizza/pkg/jsonpatch"
+)
+
+const maxPatchBytes = 1 << 20
+
+// acceptPatch lists the media types of PATCH requests, for the Accept-Patch header. Plain JSON bodies are applied as
+// merge patches.
+var acceptPatch = strings.Join([]string{jsonpatch.MergePatchMediaType, jsonpatch.PatchMediaType, "application/json"}, ", ")
+
+var errReadOnlyField = withProblemCode("read_only_field", errors.New("the patch changes a read-only field"))
+
+// applyPatch applies the patch in the body of a PATCH request to v, a pointer to the current representation of the
+// resource, and replaces v with the result. The patch is a JSON Patch or a JSON Merge Patch document, depending on the
+// Content-Type of the request. It returns false, after writing the error response, if the patch cannot be applied:
+//   - 400 if the patch is malformed, or the result is not a valid representation of the resource.
+//   - 409 if the patch refers to a location that does not exist, or one of its test operations fails.
+//   - 415 if the patch has an unsupported media type.
+func (s *Server) applyPatch(w http.ResponseWriter, r *http.Request, v any) bool {
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, _ = mime.ParseMediaType(ct)
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case jsonpatch.PatchMediaType:
+		apply = jsonpatch.Apply
+	case jsonpatch.MergePatchMediaType, "application/json":
+		apply = jsonpatch.MergePatch
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		s.writeJSONErrorResponse(w, r, fmt.Errorf("unsupported patch media type %q", mediaType), http.StatusUnsupportedMediaType)
+		return false
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		s.writeJSONErrorResponse(w, r, fmt.Errorf("patch must be at most %d bytes long", maxPatchBytes), http.StatusRequestEntityTooLarge)
+		return false
+	}
+
+	doc, err := json.Marshal(v)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Failed to encode resource to patch", "err", err)
+		s.writeJSONErrorResponse(w, r, err, http.StatusInternalServerError)
+		return false
+	}
+
+	patched, err := apply(doc, patch)
+	if errors.Is(err, jsonpatch.ErrPathNotFound) || errors.Is(err, jsonpatch.ErrTestFailed) {
+		s.writeJSONErrorResponse(w, r, withProblemCode("patch_conflict", err), http.StatusConflict)
+		return false
+	} else if err != nil {
+		s.writeJSONErrorResponse(w, r, withProblemCode("invalid_patch", err), http.StatusBadRequest)
+		return false
+	}
+
+	// Fields removed by the patch must not keep their current value.
+	reflect.ValueOf(v).Elem().SetZero()
+
+	return s.decodeJSON(w, r, bytes.NewReader(patched), v)
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout is how long probe checks can take by default before they fail.
+const DefaultCheckTimeout = time.Second
+
+// Check checks a dependency of the server, e.g. that its database can be reached. It returns an error if the
+// dependency is not usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// ProbeConfig configures the checks run by the /ready and /started probes.
+type ProbeConfig struct {
+	// Timeout bounds every check. Checks that take longer fail.
+	Timeout time.Duration
+	// Disabled lists checks that are not run, by name, e.g. "gateway:ws", or by service, e.g. "gateway".
+	Disabled []string
+}
+
+// WithProbeConfig configures the checks run by the /ready and /started probes, instead of running all of them with
+// DefaultCheckTimeout.
+func WithProbeConfig(config ProbeConfig) ServerOption {
+	return func(s *Server) {
+		s.probeConfig = config
+	}
+}
+
+// AddReadinessCheck makes /ready fail while check fails. Checks are named after the service they belong to and the
+// dependency they check, e.g. "recommendations:catalog".
+func (s *Server) AddReadinessCheck(name string, check Check) {
+	s.readinessChecks = s.addCheck(s.readinessChecks, name, check)
+}
+
+// AddStartupCheck makes /started fail while check fails, e.g. until the migrations of a database are applied.
+func (s *Server) AddStartupCheck(name string, check Check) {
+	s.startupChecks = s.addCheck(s.startupChecks, name, check)
+}
+
+func (s *Server) addCheck(checks []namedCheck, name string, check Check) []namedCheck {
+	service, _, _ := strings.Cut(name, ":")
+	if slices.Contains(s.probeConfig.Disabled, name) || slices.Contains(s.probeConfig.Disabled, service) {
+		s.log.Info("Probe check disabled", "check", name)
+		return checks
+	}
+	return append(checks, namedCheck{name: name, check: check})
+}
+
+// probeClient makes the requests of EndpointCheck. Like probes, they are not traced.
+var probeClient = &http.Client{}
+
+// EndpointCheck returns a check that the QuickPizza service at endpoint, e.g. http://catalog:3333, is reachable and
+// alive. It does not check whether the service is ready, so that one unready service does not make all of the ones
+// that depend on it unready too.
+func EndpointCheck(endpoint string) Check {
+	return func(ctx context.Context) error {
+		if endpoint == "" {
+			return errors.New("the endpoint is not configured")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/healthz", nil)
+		if err != nil {
+			return fmt.Errorf("building request: %w", err)
+		}
+
+		resp, err := probeClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// CheckResult is the result of a check, in the response of a probe.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ProbeResponse is the response of a probe. Its status is "ok" if all checks passed, "failing" if some failed, and
+// "shutting_down" if the server is shutting down, in which case checks are not run.
+type ProbeResponse struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// serveProbe runs checks concurrently, and responds with their results, with status 200 if all of them passed, and 503
+// otherwise.
+func (s *Server) serveProbe(w http.ResponseWriter, r *http.Request, checks []namedCheck) {
+	if s.draining.Load() {
+		s.writeJSONResponse(w, r, ProbeResponse{Status: "shutting_down", Checks: []CheckResult{}}, http.StatusServiceUnavailable)
+		return
+	}
+
+	timeout := s.probeConfig.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.check(ctx)
+			results[i] = CheckResult{
+				Name:      c.name,
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = "failing"
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := ProbeResponse{Status: "ok", Checks: results}
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Error != "" {
+			s.log.WarnContext(r.Context(), "Probe check failed", "path", r.URL.Path, "check", result.Name, "err", result.Error)
+			resp.Status = "failing"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	s.writeJSONResponse(w, r, resp, status)
+}
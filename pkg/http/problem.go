@@ -0,0 +1,233 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// errInvalidBody is returned by decodeJSONBody once it has reported why the request body is invalid.
+var errInvalidBody = errors.New("invalid request body")
+
+const (
+	problemContentType = "application/problem+json"
+	// problemTypeBase is the prefix of the type URI of problems with a code.
+	problemTypeBase = "https://quickpizza.grafana.com/problems/"
+)
+
+// problemTitles lists the codes of the problems that are more specific than their status, which clients can rely on,
+// unlike error messages. Problems without one of these codes have the about:blank type, and a code derived from their
+// status, e.g. not_found.
+var problemTitles = map[string]string{
+	"validation_failed":        "The request has invalid fields",
+	"malformed_body":           "The request body is not valid JSON",
+	"rating_exists":            "The pizza was already rated",
+	"version_mismatch":         "The resource was changed by another request",
+	"if_match_required":        "The If-Match header is required",
+	"idempotency_key_reused":   "The Idempotency-Key was used for a different request",
+	"idempotency_key_pending":  "A request with the same Idempotency-Key is in progress",
+	"invalid_patch":            "The patch document is malformed",
+	"patch_conflict":           "The patch cannot be applied to the resource",
+	"read_only_field":          "The patch changes a read-only field",
+	"invalid_token":            "The token is invalid",
+	"token_expired":            "The token expired",
+	"rate_limited":             "Too many requests",
+	"account_locked":           "Too many failed logins",
+	"deadline_exceeded":        "The request ran out of time",
+	"upstream_unavailable":     "An upstream service is unavailable",
+	"service_unavailable":      "The service is temporarily unavailable",
+	"default_user_not_allowed": "The default user cannot do this",
+	"invalid_response":         "The response does not match the OpenAPI document",
+}
+
+// Problem is an error response, as described by RFC 9457.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code identifies the problem, and does not change across releases.
+	Code    string                `json:"code"`
+	TraceID string                `json:"trace_id,omitempty"`
+	Errors  model.ValidationError `json:"errors,omitempty"`
+}
+
+// problemError gives an error one of the codes in problemTitles.
+type problemError struct {
+	code string
+	err  error
+}
+
+func (e *problemError) Error() string {
+	return e.err.Error()
+}
+
+func (e *problemError) Unwrap() error {
+	return e.err
+}
+
+// withProblemCode makes the problem reported for err have the given code, which must be one of problemTitles.
+// Unlike other errors, its message is reported even with a 5xx status.
+func withProblemCode(code string, err error) error {
+	return &problemError{code: code, err: err}
+}
+
+// newProblem describes err, reported with the given status, for the response to r.
+func newProblem(r *http.Request, err error, status int) *Problem {
+	p := &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: r.URL.Path,
+		Code:     strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+	}
+	if p.Code == "" {
+		p.Code = strconv.Itoa(status)
+	}
+
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+
+	setCode := func(code string) {
+		p.Type = problemTypeBase + code
+		p.Title = problemTitles[code]
+		p.Code = code
+	}
+
+	var pe *problemError
+	var ve model.ValidationError
+	switch {
+	case errors.As(err, &pe):
+		setCode(pe.code)
+		p.Detail = err.Error()
+	case errors.As(err, &ve):
+		setCode("validation_failed")
+		p.Detail = err.Error()
+		p.Errors = ve
+	case errors.Is(err, database.ErrGlobalOperationNotPermitted):
+		setCode("default_user_not_allowed")
+		p.Detail = err.Error()
+	case isDeadlineError(err):
+		setCode("deadline_exceeded")
+		p.Detail = errDeadlineExhausted.Error()
+	case err != nil && status < http.StatusInternalServerError:
+		// Server errors may come from anywhere, e.g. the database, so their details are only logged.
+		p.Detail = err.Error()
+	}
+
+	return p
+}
+
+// writeJSONErrorResponse reports err as a problem, with the given status.
+func (s *Server) writeJSONErrorResponse(w http.ResponseWriter, r *http.Request, err error, status int) {
+	s.writeProblem(w, r, newProblem(r, err, status))
+}
+
+func (s *Server) writeProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		s.log.ErrorContext(r.Context(), "Failed to encode problem", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		s.log.ErrorContext(r.Context(), "Failed to write response", "err", err)
+	}
+}
+
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.writeJSONErrorResponse(w, r, fmt.Errorf("no route for %s", r.URL.Path), http.StatusNotFound)
+}
+
+// apiNotFound reports unknown API routes as problems, instead of letting the frontend serve them.
+func (s *Server) apiNotFound(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			s.notFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeInvalidParamResponse reports that the URL parameter with the given name is invalid.
+func (s *Server) writeInvalidParamResponse(w http.ResponseWriter, r *http.Request, name string) {
+	s.writeJSONErrorResponse(w, r, model.ValidationError{{
+		Field:  name,
+		Detail: fmt.Sprintf("invalid %s %q", name, chi.URLParam(r, name)),
+	}}, http.StatusBadRequest)
+}
+
+// decodeJSON decodes the JSON document in body into v. It returns false, after writing the error response, if the
+// document is invalid, describing the problem without the internals of the JSON decoder.
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, body io.Reader, v any) bool {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		s.writeJSONErrorResponse(w, r, fmt.Errorf("request body must be at most %d bytes long", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, io.EOF):
+		s.writeJSONErrorResponse(w, r, withProblemCode("malformed_body", errors.New("request body is empty")), http.StatusBadRequest)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		s.writeJSONErrorResponse(w, r, withProblemCode("malformed_body", errors.New("request body is not valid JSON, it ends too early")), http.StatusBadRequest)
+	case errors.As(err, &syntaxErr):
+		s.writeJSONErrorResponse(w, r, withProblemCode("malformed_body", fmt.Errorf("request body is not valid JSON, at offset %d", syntaxErr.Offset)), http.StatusBadRequest)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		s.writeJSONErrorResponse(w, r, model.ValidationError{{
+			Field:  typeErr.Field,
+			Detail: fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+		}}, http.StatusBadRequest)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		s.writeJSONErrorResponse(w, r, model.ValidationError{{
+			Field:  field,
+			Detail: fmt.Sprintf("unknown field %q", field),
+		}}, http.StatusBadRequest)
+	default:
+		s.writeJSONErrorResponse(w, r, withProblemCode("malformed_body", errors.New("request body is not valid JSON, or does not have the expected structure")), http.StatusBadRequest)
+	}
+	return false
+}
+
+// jsonTypeName describes the JSON values Go values of type t are decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
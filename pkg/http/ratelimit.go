@@ -0,0 +1,192 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grafana/quickpizza/pkg/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Keys that can be used to group requests into rate limiting buckets.
+const (
+	// RateLimitByToken limits requests per user token. Requests without a token are limited per IP.
+	RateLimitByToken = "token"
+	// RateLimitByIP limits requests per client IP address.
+	RateLimitByIP = "ip"
+	// RateLimitByRoute limits requests per route pattern, regardless of who makes them.
+	RateLimitByRoute = "route"
+)
+
+var errRateLimited = withProblemCode("rate_limited", errors.New("rate limit exceeded"))
+
+var rateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "quickpizza",
+	Subsystem: "server",
+	Name:      "rate_limited_requests_total",
+	Help:      "The total number of HTTP requests rejected by the rate limiter",
+}, []string{"method", "path"})
+
+// RateLimitConfig configures the server-side rate limiter.
+type RateLimitConfig struct {
+	// Rate is the rate applied to all routes that do not have an override.
+	Rate ratelimit.Rate
+	// Key is what requests are grouped by, one of RateLimitByToken, RateLimitByIP or RateLimitByRoute.
+	Key string
+	// Routes overrides Rate for specific routes. Keys are chi route patterns such as "/api/pizza", optionally
+	// prefixed by a method, e.g. "POST /api/pizza". Method-specific overrides take precedence.
+	Routes map[string]ratelimit.Rate
+}
+
+// Validate returns an error if the config is not usable.
+func (c RateLimitConfig) Validate() error {
+	switch c.Key {
+	case RateLimitByToken, RateLimitByIP, RateLimitByRoute:
+	default:
+		return fmt.Errorf("unknown rate limit key %q", c.Key)
+	}
+
+	if c.Rate.Limit <= 0 || c.Rate.Period <= 0 {
+		return fmt.Errorf("invalid rate limit %v", c.Rate)
+	}
+
+	return nil
+}
+
+// rateLimiter holds the token buckets for the default rate and for every route override.
+type rateLimiter struct {
+	key    string
+	global *ratelimit.Limiter
+	routes map[string]*ratelimit.Limiter
+}
+
+// WithRateLimit enables a token bucket rate limiter for all routes, except for infrastructure ones (metrics, probes,
+// profiling) and for requests made between QuickPizza services, which carry the internal token. Requests are grouped
+// by the address of the client, see WithTrustedProxies, unless config.Key says otherwise. Rejected requests get a 429
+// response with a Retry-After header. All limited responses carry RateLimit-* headers describing the state of their
+// bucket.
+func WithRateLimit(config RateLimitConfig) ServerOption {
+	return func(s *Server) {
+		rl := &rateLimiter{
+			key:    config.Key,
+			global: ratelimit.New(config.Rate),
+			routes: map[string]*ratelimit.Limiter{},
+		}
+		for route, rate := range config.Routes {
+			rl.routes[route] = ratelimit.New(rate)
+		}
+		s.rateLimiter = rl
+	}
+}
+
+// limiterFor returns the limiter that applies to the given method and route pattern, along with a name that
+// identifies it.
+func (rl *rateLimiter) limiterFor(method, pattern string) (*ratelimit.Limiter, string) {
+	if l, ok := rl.routes[method+" "+pattern]; ok {
+		return l, method + " " + pattern
+	}
+	if l, ok := rl.routes[pattern]; ok {
+		return l, pattern
+	}
+	return rl.global, ""
+}
+
+// bucketKey returns the key of the bucket r, made from clientIP, should take a token from.
+func (rl *rateLimiter) bucketKey(r *http.Request, pattern, clientIP string) string {
+	switch rl.key {
+	case RateLimitByRoute:
+		return "route:" + r.Method + " " + pattern
+	case RateLimitByToken:
+		if token := getRequestToken(r); token != "" {
+			return "token:" + token
+		}
+	}
+
+	return "ip:" + clientIP
+}
+
+// rateLimitMiddleware applies s.rateLimiter to incoming requests. As it runs before routing, it looks up the route
+// pattern of the request itself.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isInternalRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pattern := s.router.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+		if pattern == "" {
+			pattern = r.URL.Path
+		}
+		if isInternalRoute(pattern) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limiter, name := s.rateLimiter.limiterFor(r.Method, pattern)
+		key := s.rateLimiter.bucketKey(r, pattern, s.clientIP(r))
+		if name != "" {
+			// Route overrides get buckets of their own, so they do not consume the budget of the default rate.
+			key = name + "|" + key
+		}
+
+		res := limiter.Allow(key)
+		rate := limiter.Rate()
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", rate.Limit, ceilSeconds(rate.Period), rate.Burst))
+
+		if !res.Allowed {
+			rateLimitedRequests.WithLabelValues(r.Method, pattern).Inc()
+			s.log.DebugContext(r.Context(), "Rate limiting request", "pattern", pattern, "retryAfter", res.RetryAfter)
+
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			s.writeJSONErrorResponse(w, r, errRateLimited, http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds rounds d up to a whole number of seconds, as required by Retry-After and RateLimit-* headers.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ParseRateLimitRoutes parses per-route rate overrides in the form "<route>=<rate>", separated by commas, e.g.
+// "POST /api/pizza=2/s:5,/api/ratings=30/m". Rates use the format accepted by ratelimit.ParseRate.
+func ParseRateLimitRoutes(v string) (map[string]ratelimit.Rate, error) {
+	routes := map[string]ratelimit.Rate{}
+
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, rateStr, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid route rate %q: expected <route>=<rate>", entry)
+		}
+
+		rate, err := ratelimit.ParseRate(rateStr)
+		if err != nil {
+			return nil, err
+		}
+
+		routes[strings.TrimSpace(route)] = rate
+	}
+
+	return routes, nil
+}
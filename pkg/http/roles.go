@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/jwt"
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// adminTokenCookie holds the token of the admin session, set by /api/admin/login.
+const adminTokenCookie = "admin_token"
+
+var errForbidden = errors.New("insufficient permissions")
+
+// requireRole only lets requests through if the user set in the context by a previous middleware has any of roles.
+// Requests without a user are rejected with 401, and requests from users without any of roles with 403.
+func (s *Server) requireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := contextUser(r.Context())
+			if user == nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			if !user.HasRole(roles...) {
+				s.log.DebugContext(r.Context(), "Permission denied", "user", user.ID, "role", user.Role, "required", roles)
+				s.writeJSONErrorResponse(w, r, errForbidden, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// adminSessionMiddleware authenticates requests using the token of the admin session cookie, or the request token if
+// the cookie is not set. Unlike AuthMiddleware, unknown tokens are rejected instead of falling back to the default
+// user. It is meant to be followed by requireRole.
+func (s *Server) adminSessionMiddleware(db *database.Catalog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := getRequestToken(r)
+			if cookie, err := r.Cookie(adminTokenCookie); err == nil {
+				token = cookie.Value
+			}
+			if token == "" {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			user, err := s.authenticateStrict(r.Context(), db, token)
+			if isDeadlineError(err) {
+				s.writeJSONErrorResponse(w, r, errDeadlineExhausted, http.StatusGatewayTimeout)
+				return
+			} else if isSessionError(err) {
+				s.writeInvalidTokenResponse(w, r, err)
+				return
+			} else if err != nil {
+				s.writeJSONErrorResponse(w, r, authError, http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateStrict is like authenticate, but unknown opaque tokens are rejected with database.ErrTokenNotFound.
+func (s *Server) authenticateStrict(ctx context.Context, db *database.Catalog, token string) (*model.User, error) {
+	if jwt.LooksLikeJWT(token) {
+		// JWTs are always checked against their session.
+		return s.authenticate(ctx, db, token)
+	}
+	return db.AuthenticateStrict(ctx, token)
+}
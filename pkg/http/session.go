@@ -0,0 +1,100 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/jwt"
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// refreshPath is the only path the refresh token cookie is sent to.
+const refreshPath = "/api/users/token/refresh"
+
+// tokenResponse is returned by the endpoints that create sessions.
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the number of seconds the token is valid for.
+	ExpiresIn int `json:"expires_in"`
+}
+
+// newTokenResponse returns the tokens of session. With JWT authentication, the token is a JWT that references the
+// session, instead of the session token itself.
+func (s *Server) newTokenResponse(session *model.Session) (tokenResponse, error) {
+	token := session.Token
+	if s.jwtSigner != nil {
+		var err error
+		token, err = s.signSessionJWT(session)
+		if err != nil {
+			return tokenResponse{}, err
+		}
+	}
+
+	return tokenResponse{
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int(time.Until(session.ExpiresAt).Round(time.Second).Seconds()),
+	}, nil
+}
+
+// isSessionError returns whether err means that a token belongs to a session that cannot be used anymore.
+func isSessionError(err error) bool {
+	return errors.Is(err, database.ErrTokenExpired) ||
+		errors.Is(err, database.ErrTokenRevoked) ||
+		errors.Is(err, database.ErrTokenNotFound) ||
+		errors.Is(err, jwt.ErrTokenExpired) ||
+		errors.Is(err, jwt.ErrInvalidToken)
+}
+
+// writeInvalidTokenResponse responds with 401 and a WWW-Authenticate header explaining why the token was rejected,
+// so that clients can tell an expired token, which they should refresh, from other authentication failures.
+func (s *Server) writeInvalidTokenResponse(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, err.Error()))
+	if errors.Is(err, database.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenExpired) {
+		err = withProblemCode("token_expired", err)
+	} else {
+		err = withProblemCode("invalid_token", err)
+	}
+	s.writeJSONErrorResponse(w, r, err, http.StatusUnauthorized)
+}
+
+// setSessionCookies sets the cookies holding token and the refresh token of session. They expire together with the
+// tokens.
+func (s *Server) setSessionCookies(w http.ResponseWriter, session *model.Session, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     qpUserTokenCookie,
+		Value:    token,
+		SameSite: http.SameSiteStrictMode,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     qpRefreshTokenCookie,
+		Value:    session.RefreshToken,
+		SameSite: http.SameSiteStrictMode,
+		Path:     refreshPath,
+		Expires:  session.RefreshExpiresAt,
+		HttpOnly: true,
+	})
+}
+
+// clearSessionCookies deletes the cookies set by setSessionCookies.
+func clearSessionCookies(w http.ResponseWriter) {
+	for _, cookie := range []struct{ name, path string }{
+		{qpUserTokenCookie, "/"},
+		{qpRefreshTokenCookie, refreshPath},
+	} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     cookie.name,
+			Value:    "",
+			SameSite: http.SameSiteStrictMode,
+			Path:     cookie.path,
+			Expires:  time.Unix(0, 0),
+		})
+	}
+}
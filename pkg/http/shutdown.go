@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/olahol/melody"
+)
+
+// WithShutdownDelay makes Shutdown keep serving requests for delay after /ready starts failing, so that load
+// balancers and Kubernetes have time to notice and stop sending new requests before the listener is closed.
+func WithShutdownDelay(delay time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownDelay = delay
+	}
+}
+
+// ListenAndServe serves the server on addr until Shutdown is called, like http.ListenAndServe. It returns nil once the
+// server is shut down.
+func (s *Server) ListenAndServe(addr string) error {
+	s.httpServer.Addr = addr
+	err := s.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// RegisterOnShutdown registers a function called by Shutdown once the listener is closed, to close connections that
+// were hijacked from the server, e.g. WebSockets, which Shutdown does not wait for.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// Shutdown gracefully stops the server:
+//
+//   - /ready fails, and responses stop keeping connections alive, so that clients reconnect to other instances.
+//   - After the shutdown delay (see WithShutdownDelay), the listener is closed, and in-flight requests are waited for.
+//   - WebSocket sessions are closed with a "going away" close frame, so that clients know they can reconnect.
+//
+// If ctx is done before in-flight requests complete, their connections are closed, and the error of ctx is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	s.httpServer.SetKeepAlivesEnabled(false)
+
+	if s.shutdownDelay > 0 {
+		s.log.InfoContext(ctx, "Waiting for load balancers to stop sending requests", "delay", s.shutdownDelay)
+		select {
+		case <-time.After(s.shutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.httpServer.Shutdown(ctx)
+	}()
+
+	s.closeWebSockets(ctx)
+	for _, f := range s.onShutdown {
+		f()
+	}
+
+	err := <-errc
+	if err != nil {
+		_ = s.httpServer.Close()
+	}
+	return err
+}
+
+// closeWebSockets closes the sessions of /ws with a close frame, and waits until their handlers returned or ctx is
+// done. New sessions are refused from then on.
+func (s *Server) closeWebSockets(ctx context.Context) {
+	s.webSocketsMu.Lock()
+	s.webSocketsClosed = true
+	s.webSocketsMu.Unlock()
+
+	_ = s.melody.CloseWithMsg(melody.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+
+	done := make(chan struct{})
+	go func() {
+		s.webSockets.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// handleWebSocket runs the session of a /ws request, unless the server is shutting down, in which case it returns
+// false.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) (bool, error) {
+	s.webSocketsMu.Lock()
+	if s.webSocketsClosed {
+		s.webSocketsMu.Unlock()
+		return false, nil
+	}
+	s.webSockets.Add(1)
+	s.webSocketsMu.Unlock()
+	defer s.webSockets.Done()
+
+	return true, s.melody.HandleRequest(w, r)
+}
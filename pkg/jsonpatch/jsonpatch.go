@@ -0,0 +1,385 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch (RFC 7396) documents to JSON documents.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of patch documents.
+const (
+	PatchMediaType      = "application/json-patch+json"
+	MergePatchMediaType = "application/merge-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned if a patch document is malformed.
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrPathNotFound is returned if an operation refers to a location that does not exist in the document.
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is returned if the value of a test operation does not match the document.
+	ErrTestFailed = errors.New("test failed")
+)
+
+// OperationError describes why an operation of a JSON Patch document could not be applied.
+type OperationError struct {
+	// Index of the operation in the patch document, starting at 0.
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s %q): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+type operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value any
+	// HasValue is false if the operation has no value member, which is not the same as a null value.
+	HasValue bool
+}
+
+// Apply applies a JSON Patch document to doc, and returns the patched document. Operations are applied in order,
+// and the whole patch fails if any of them does. Errors wrap ErrInvalidPatch if the patch is malformed, and
+// ErrPathNotFound or ErrTestFailed if it cannot be applied to doc.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	ops, err := decodeOperations(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		v, err = op.apply(v)
+		if err != nil {
+			return nil, &OperationError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+func decodeOperations(patch []byte) ([]operation, error) {
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &raw); err != nil {
+		return nil, fmt.Errorf("%w: must be an array of operations", ErrInvalidPatch)
+	}
+
+	ops := make([]operation, len(raw))
+	for i, members := range raw {
+		op := &ops[i]
+		for name, dst := range map[string]*string{"op": &op.Op, "path": &op.Path, "from": &op.From} {
+			if m, ok := members[name]; ok {
+				if err := json.Unmarshal(m, dst); err != nil {
+					return nil, &OperationError{Index: i, Op: op.Op, Err: fmt.Errorf("%w: %q must be a string", ErrInvalidPatch, name)}
+				}
+			}
+		}
+
+		invalid := func(format string, args ...any) error {
+			return &OperationError{Index: i, Op: op.Op, Path: op.Path, Err: fmt.Errorf("%w: "+format, append([]any{ErrInvalidPatch}, args...)...)}
+		}
+
+		if _, ok := members["path"]; !ok {
+			return nil, invalid("missing path")
+		}
+
+		if m, ok := members["value"]; ok {
+			if err := json.Unmarshal(m, &op.Value); err != nil {
+				return nil, invalid("invalid value: %v", err)
+			}
+			op.HasValue = true
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if !op.HasValue {
+				return nil, invalid("missing value")
+			}
+		case "remove":
+		case "move", "copy":
+			if _, ok := members["from"]; !ok {
+				return nil, invalid("missing from")
+			}
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, invalid("invalid from: %v", err)
+			}
+		case "":
+			return nil, invalid("missing op")
+		default:
+			return nil, invalid("unknown op %q", op.Op)
+		}
+
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, invalid("invalid path: %v", err)
+		}
+	}
+
+	return ops, nil
+}
+
+func (op *operation) apply(doc any) (any, error) {
+	path, _ := parsePointer(op.Path)
+	from, _ := parsePointer(op.From)
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, op.Value)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		if len(path) == 0 {
+			return op.Value, nil
+		}
+		doc, _, err := remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, op.Value)
+	case "move":
+		if len(path) > len(from) && isPrefix(from, path) {
+			return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	case "test":
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// MergePatch applies a JSON Merge Patch document to doc, and returns the patched document. Members of patch replace
+// the members of doc with the same name, recursively for objects, and members set to null are removed. Errors wrap
+// ErrInvalidPatch if patch is not valid JSON.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(v, p))
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+
+	return t
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens. The empty pointer refers to the
+// whole document.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, tokens []string) bool {
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses the reference token of an element of an array of length n. If end is true, "-" refers to the
+// position after the last element.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+
+	// Leading zeros and signs are not allowed.
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, ErrPathNotFound
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n || (i == n && !end) {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+func get(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case map[string]any:
+			var ok bool
+			if doc, ok = v[token]; !ok {
+				return nil, ErrPathNotFound
+			}
+		case []any:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return doc, nil
+}
+
+// update calls fn with the parent of the location tokens refer to, along with the last token, and replaces the
+// parent with the value it returns. It returns the updated document.
+func update(doc any, tokens []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := get(doc, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = update(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := doc.(type) {
+	case map[string]any:
+		v[tokens[0]] = child
+	case []any:
+		i, _ := arrayIndex(tokens[0], len(v), false)
+		v[i] = child
+	}
+	return doc, nil
+}
+
+func add(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return update(doc, tokens, func(parent any, token string) (any, error) {
+		switch v := parent.(type) {
+		case map[string]any:
+			v[token] = value
+			return v, nil
+		case []any:
+			i, err := arrayIndex(token, len(v), true)
+			if err != nil {
+				return nil, err
+			}
+			return append(v[:i], append([]any{value}, v[i:]...)...), nil
+		default:
+			return nil, ErrPathNotFound
+		}
+	})
+}
+
+func remove(doc any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	var removed any
+	doc, err := update(doc, tokens, func(parent any, token string) (any, error) {
+		switch v := parent.(type) {
+		case map[string]any:
+			var ok bool
+			if removed, ok = v[token]; !ok {
+				return nil, ErrPathNotFound
+			}
+			delete(v, token)
+			return v, nil
+		case []any:
+			i, err := arrayIndex(token, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = v[i]
+			return append(v[:i:i], v[i+1:]...), nil
+		default:
+			return nil, ErrPathNotFound
+		}
+	})
+	return doc, removed, err
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, value := range v {
+			c[name] = deepCopy(value)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, value := range v {
+			c[i] = deepCopy(value)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,312 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "add member",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"add","path":"/review","value":"Great."}]`,
+			want:  `{"review":"Great.","stars":5}`,
+		},
+		{
+			name:  "add null member",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"add","path":"/review","value":null}]`,
+			want:  `{"review":null,"stars":5}`,
+		},
+		{
+			name:  "add to end of array",
+			doc:   `{"tags":["a","b"]}`,
+			patch: `[{"op":"add","path":"/tags/-","value":"c"}]`,
+			want:  `{"tags":["a","b","c"]}`,
+		},
+		{
+			name:  "insert into array",
+			doc:   `{"tags":["a","c"]}`,
+			patch: `[{"op":"add","path":"/tags/1","value":"b"}]`,
+			want:  `{"tags":["a","b","c"]}`,
+		},
+		{
+			name:  "replace whole document",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"replace","path":"","value":{"stars":1}}]`,
+			want:  `{"stars":1}`,
+		},
+		{
+			name:  "replace member",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"replace","path":"/stars","value":3}]`,
+			want:  `{"stars":3}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"tags":["a","b","c"]}`,
+			patch: `[{"op":"remove","path":"/tags/1"}]`,
+			want:  `{"tags":["a","c"]}`,
+		},
+		{
+			name:  "move member",
+			doc:   `{"a":{"b":1},"c":{}}`,
+			patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`,
+			want:  `{"a":{},"c":{"d":1}}`,
+		},
+		{
+			name:  "copy is deep",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"stars":5,"tags":["a"]}`,
+			patch: `[{"op":"test","path":"/tags","value":["a"]},{"op":"replace","path":"/stars","value":1}]`,
+			want:  `{"stars":1,"tags":["a"]}`,
+		},
+		{
+			name:  "escaped pointer",
+			doc:   `{"a/b":1,"c~d":2}`,
+			patch: `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/c~0d","value":3}]`,
+			want:  `{"c~d":3}`,
+		},
+		{
+			name:  "test fails",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"test","path":"/stars","value":4}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "remove missing member",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"remove","path":"/review"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "replace missing member",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"replace","path":"/review","value":"x"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "array index out of range",
+			doc:   `{"tags":["a"]}`,
+			patch: `[{"op":"add","path":"/tags/2","value":"b"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "array index with leading zero",
+			doc:   `{"tags":["a","b"]}`,
+			patch: `[{"op":"remove","path":"/tags/01"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "remove end of array",
+			doc:   `{"tags":["a"]}`,
+			patch: `[{"op":"remove","path":"/tags/-"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "move into own child",
+			doc:   `{"a":{"b":{}}}`,
+			patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "remove whole document",
+			doc:   `{"stars":5}`,
+			patch: `[{"op":"remove","path":""}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "not an array",
+			doc:   `{}`,
+			patch: `{"op":"add","path":"/a","value":1}`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing op",
+			doc:   `{}`,
+			patch: `[{"path":"/a","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "unknown op",
+			doc:   `{}`,
+			patch: `[{"op":"append","path":"/a","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing path",
+			doc:   `{}`,
+			patch: `[{"op":"add","value":1}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing value",
+			doc:   `{}`,
+			patch: `[{"op":"add","path":"/a"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing from",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"copy","path":"/b"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "path without leading slash",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":"a"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "path is not a string",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"remove","path":1}]`,
+			err:   ErrInvalidPatch,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Apply([]byte(tc.doc), []byte(tc.patch))
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSONEqual(t, got, tc.want)
+		})
+	}
+}
+
+func TestApplyReportsFailedOperation(t *testing.T) {
+	t.Parallel()
+
+	_, err := Apply([]byte(`{"stars":5}`), []byte(`[{"op":"test","path":"/stars","value":5},{"op":"remove","path":"/review"}]`))
+
+	var opErr *OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("expected an OperationError, got %v", err)
+	}
+	if opErr.Index != 1 || opErr.Op != "remove" || opErr.Path != "/review" {
+		t.Fatalf("unexpected operation in error: %+v", opErr)
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	t.Parallel()
+
+	doc := []byte(`{"stars":5}`)
+	_, err := Apply(doc, []byte(`[{"op":"replace","path":"/stars","value":1},{"op":"test","path":"/stars","value":5}]`))
+	if !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("expected error %v, got %v", ErrTestFailed, err)
+	}
+	if string(doc) != `{"stars":5}` {
+		t.Fatalf("document was changed: %s", doc)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "replace member",
+			doc:   `{"stars":5,"review":"Great."}`,
+			patch: `{"stars":3}`,
+			want:  `{"stars":3,"review":"Great."}`,
+		},
+		{
+			name:  "remove member",
+			doc:   `{"stars":5,"review":"Great."}`,
+			patch: `{"review":null}`,
+			want:  `{"stars":5}`,
+		},
+		{
+			name:  "merge nested objects",
+			doc:   `{"a":{"b":1,"c":2}}`,
+			patch: `{"a":{"c":3,"d":4}}`,
+			want:  `{"a":{"b":1,"c":3,"d":4}}`,
+		},
+		{
+			name:  "replace arrays",
+			doc:   `{"tags":["a","b"]}`,
+			patch: `{"tags":["c"]}`,
+			want:  `{"tags":["c"]}`,
+		},
+		{
+			name:  "replace scalar with object",
+			doc:   `{"a":1}`,
+			patch: `{"a":{"b":null,"c":1}}`,
+			want:  `{"a":{"c":1}}`,
+		},
+		{
+			name:  "non-object patch replaces document",
+			doc:   `{"a":1}`,
+			patch: `[1,2]`,
+			want:  `[1,2]`,
+		},
+		{
+			name:  "invalid patch",
+			doc:   `{"a":1}`,
+			patch: `{"a":`,
+			err:   ErrInvalidPatch,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected error %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertJSONEqual(t, got, tc.want)
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
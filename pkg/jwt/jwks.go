@@ -0,0 +1,176 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Key is a JSON Web Key. Only RSA public keys are supported.
+type Key struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// KeySet is a JSON Web Key Set, as served by JWKS endpoints.
+type KeySet struct {
+	Keys []Key `json:"keys"`
+}
+
+func publicKey(key *rsa.PublicKey, kid string) Key {
+	return Key{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: RS256,
+		Kid: kid,
+		N:   encode(key.N.Bytes()),
+		E:   encode(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// rsaPublicKey returns the RSA public key described by k.
+func (k Key) rsaPublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	n, err := decode(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decode(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// thumbprint returns the JWK thumbprint (RFC 7638) of key, which is used as its key ID.
+func thumbprint(key *rsa.PublicKey) string {
+	k := publicKey(key, "")
+	// Members must be in lexicographic order, without whitespace.
+	digest := sha256.Sum256([]byte(fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)))
+	return encode(digest[:])
+}
+
+// JWKSCache is a KeySource that fetches RSA public keys from a JWKS endpoint. Keys are cached, and fetched again
+// once they get stale or when a token signed with an unknown key shows up, e.g. after the signer rotated its key.
+type JWKSCache struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// minRefetchInterval limits how often the key set is fetched because of unknown key IDs or failed attempts, so that
+// tokens with bogus key IDs cannot be used to flood the JWKS endpoint.
+const minRefetchInterval = 5 * time.Second
+
+// NewJWKSCache returns a JWKSCache for the key set at url, which is considered stale after ttl.
+func NewJWKSCache(url string, client *http.Client, ttl time.Duration) *JWKSCache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &JWKSCache{url: url, client: client, ttl: ttl}
+}
+
+func (c *JWKSCache) VerificationKey(ctx context.Context, alg, kid string) (any, error) {
+	if alg != RS256 {
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, found := c.keys[kid]
+	if found && time.Since(c.fetchedAt) < c.ttl {
+		return key, nil
+	}
+
+	if time.Since(c.attemptedAt) < minRefetchInterval {
+		if found {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	c.attemptedAt = time.Now()
+	if err := c.fetch(ctx); err != nil {
+		// Keep using stale keys if the endpoint is unavailable.
+		if found {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, found = c.keys[kid]; !found {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+func (c *JWKSCache) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching key set: unexpected status code %d", resp.StatusCode)
+	}
+
+	var set KeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Alg != "" && k.Alg != RS256 {
+			continue
+		}
+		pub, err := k.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return errors.New("key set has no usable keys")
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+func decode(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	return b, nil
+}
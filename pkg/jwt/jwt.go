@@ -0,0 +1,270 @@
+// Package jwt implements signing and verification of JSON Web Tokens (RFC 7519) using HS256 or RS256, along with
+// publishing and fetching of the corresponding JSON Web Key Sets (RFC 7517).
+package jwt
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// leeway is the clock skew tolerated when checking the expiry of a token.
+const leeway = 5 * time.Second
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims are the claims QuickPizza puts in its tokens.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Username  string   `json:"username,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  string   `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	ID        string   `json:"jti,omitempty"`
+
+	// OpenID Connect and OAuth 2.0 claims.
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Nonce             string `json:"nonce,omitempty"`
+	ClientID          string `json:"client_id,omitempty"`
+	Scope             string `json:"scope,omitempty"`
+}
+
+// HasRole returns whether role is among the roles of the claims.
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// LooksLikeJWT returns whether token has the shape of a JWT in compact serialization, i.e. three dot-separated
+// segments. It does not check whether the token is valid.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && strings.HasPrefix(token, "eyJ")
+}
+
+// Signer issues signed tokens.
+type Signer struct {
+	alg    string
+	kid    string
+	issuer string
+	secret []byte
+	key    *rsa.PrivateKey
+}
+
+// NewHS256Signer returns a Signer that signs tokens with a shared secret. Verifiers need the same secret.
+func NewHS256Signer(secret []byte, issuer string) (*Signer, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("HS256 secret must be at least 32 bytes long")
+	}
+	return &Signer{alg: HS256, issuer: issuer, secret: secret}, nil
+}
+
+// NewRS256Signer returns a Signer that signs tokens with an RSA private key. Verifiers can get the public key from
+// the key set returned by JWKS.
+func NewRS256Signer(key *rsa.PrivateKey, issuer string) (*Signer, error) {
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("RS256 key must be at least 2048 bits long")
+	}
+	return &Signer{alg: RS256, issuer: issuer, key: key, kid: thumbprint(&key.PublicKey)}, nil
+}
+
+// Algorithm returns the signing algorithm, HS256 or RS256.
+func (s *Signer) Algorithm() string {
+	return s.alg
+}
+
+// Issuer returns the issuer set in the tokens of the signer.
+func (s *Signer) Issuer() string {
+	return s.issuer
+}
+
+// Sign returns a signed token for claims. The issuer and issue time are set if they are empty.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	if claims.Issuer == "" {
+		claims.Issuer = s.issuer
+	}
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
+
+	h, err := json.Marshal(header{Alg: s.alg, Typ: "JWT", Kid: s.kid})
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encode(h) + "." + encode(c)
+
+	var sig []byte
+	switch s.alg {
+	case HS256:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case RS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		sig, err = rsa.SignPKCS1v15(nil, s.key, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return signingInput + "." + encode(sig), nil
+}
+
+// JWKS returns the key set that can be used to verify the tokens of the signer. Secrets are never published, so the
+// key set of an HS256 signer is empty.
+func (s *Signer) JWKS() KeySet {
+	if s.alg != RS256 {
+		return KeySet{Keys: []Key{}}
+	}
+	return KeySet{Keys: []Key{publicKey(&s.key.PublicKey, s.kid)}}
+}
+
+// KeySource returns a KeySource with the key needed to verify the tokens of the signer.
+func (s *Signer) KeySource() KeySource {
+	if s.alg == RS256 {
+		return StaticKey{Key: &s.key.PublicKey}
+	}
+	return StaticKey{Key: s.secret}
+}
+
+// KeySource provides the keys used to verify tokens.
+type KeySource interface {
+	// VerificationKey returns the key with the given ID for alg: a []byte secret for HS256 or an *rsa.PublicKey for
+	// RS256.
+	VerificationKey(ctx context.Context, alg, kid string) (any, error)
+}
+
+// StaticKey is a KeySource that always returns the same key, regardless of its ID.
+type StaticKey struct {
+	Key any
+}
+
+func (k StaticKey) VerificationKey(_ context.Context, _, _ string) (any, error) {
+	return k.Key, nil
+}
+
+// Verifier checks the signature, issuer and expiry of tokens.
+type Verifier struct {
+	alg    string
+	issuer string
+	keys   KeySource
+}
+
+// NewVerifier returns a verifier that accepts tokens signed with alg using keys from source. If issuer is not empty,
+// tokens issued by anyone else are rejected.
+func NewVerifier(alg, issuer string, source KeySource) (*Verifier, error) {
+	if alg != HS256 && alg != RS256 {
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	return &Verifier{alg: alg, issuer: issuer, keys: source}, nil
+}
+
+// Verify returns the claims of token, if it is valid. It returns ErrTokenExpired if the token is valid but expired,
+// and ErrInvalidToken if it is not valid at all.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeJSON(parts[0], &h); err != nil {
+		return nil, ErrInvalidToken
+	}
+	// Only the configured algorithm is accepted, so that e.g. an RS256 public key cannot be used as an HS256 secret.
+	if h.Alg != v.alg {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.keys.VerificationKey(ctx, h.Alg, h.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	switch k := key.(type) {
+	case []byte:
+		if v.alg != HS256 {
+			return nil, ErrInvalidToken
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return nil, ErrInvalidToken
+		}
+	case *rsa.PublicKey:
+		if v.alg != RS256 {
+			return nil, ErrInvalidToken
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeJSON(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Add(-leeway).Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &claims, nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJSON(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
@@ -0,0 +1,171 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestSigners(t *testing.T) (hs256, rs256, otherRS256 *Signer) {
+	t.Helper()
+
+	hs256, err := NewHS256Signer([]byte(testSecret), "quickpizza")
+	if err != nil {
+		t.Fatalf("creating HS256 signer: %v", err)
+	}
+
+	signers := make([]*Signer, 2)
+	for i := range signers {
+		key, err := GenerateRSAKey()
+		if err != nil {
+			t.Fatalf("generating key: %v", err)
+		}
+		signers[i], err = NewRS256Signer(key, "quickpizza")
+		if err != nil {
+			t.Fatalf("creating RS256 signer: %v", err)
+		}
+	}
+
+	return hs256, signers[0], signers[1]
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	hs256, rs256, otherRS256 := newTestSigners(t)
+	otherHS256, err := NewHS256Signer([]byte(strings.Repeat("x", 32)), "quickpizza")
+	if err != nil {
+		t.Fatalf("creating HS256 signer: %v", err)
+	}
+	otherIssuer, err := NewHS256Signer([]byte(testSecret), "someone-else")
+	if err != nil {
+		t.Fatalf("creating HS256 signer: %v", err)
+	}
+
+	valid := Claims{Subject: "1", Username: "default", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	expired := Claims{Subject: "1", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	withinLeeway := Claims{Subject: "1", ExpiresAt: time.Now().Add(-time.Second).Unix()}
+
+	sign := func(s *Signer, c Claims) string {
+		token, err := s.Sign(c)
+		if err != nil {
+			t.Fatalf("signing: %v", err)
+		}
+		return token
+	}
+	// withHeader replaces the header of token, keeping its signature.
+	withHeader := func(token string, h header) string {
+		b, _ := json.Marshal(h)
+		parts := strings.Split(token, ".")
+		return encode(b) + "." + parts[1] + "." + parts[2]
+	}
+
+	for _, tc := range []struct {
+		name     string
+		verifier *Signer
+		token    string
+		want     error
+	}{
+		{name: "valid HS256", verifier: hs256, token: sign(hs256, valid)},
+		{name: "valid RS256", verifier: rs256, token: sign(rs256, valid)},
+		{name: "expired", verifier: hs256, token: sign(hs256, expired), want: ErrTokenExpired},
+		{name: "expired within leeway", verifier: hs256, token: sign(hs256, withinLeeway)},
+		{name: "wrong HS256 secret", verifier: hs256, token: sign(otherHS256, valid), want: ErrInvalidToken},
+		{name: "wrong RS256 key", verifier: rs256, token: sign(otherRS256, valid), want: ErrInvalidToken},
+		{name: "wrong issuer", verifier: hs256, token: sign(otherIssuer, valid), want: ErrInvalidToken},
+		{name: "HS256 token for RS256 verifier", verifier: rs256, token: sign(hs256, valid), want: ErrInvalidToken},
+		{name: "RS256 token for HS256 verifier", verifier: hs256, token: sign(rs256, valid), want: ErrInvalidToken},
+		{name: "none algorithm", verifier: hs256, token: withHeader(sign(hs256, valid), header{Alg: "none"}), want: ErrInvalidToken},
+		{name: "tampered claims", verifier: hs256, token: strings.Replace(sign(hs256, valid), ".", ".e30", 1), want: ErrInvalidToken},
+		{name: "missing signature", verifier: hs256, token: strings.TrimRight(sign(hs256, valid), "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"), want: ErrInvalidToken},
+		{name: "not a JWT", verifier: hs256, token: "abcdef0123456789", want: ErrInvalidToken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewVerifier(tc.verifier.Algorithm(), tc.verifier.Issuer(), tc.verifier.KeySource())
+			if err != nil {
+				t.Fatalf("creating verifier: %v", err)
+			}
+
+			claims, err := v.Verify(context.Background(), tc.token)
+			if tc.want != nil {
+				if !errors.Is(err, tc.want) {
+					t.Fatalf("expected %v, got %v", tc.want, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claims.Subject != "1" || claims.Issuer != "quickpizza" || claims.IssuedAt == 0 {
+				t.Fatalf("unexpected claims: %+v", claims)
+			}
+		})
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewHS256Signer([]byte("short"), "quickpizza"); err == nil {
+		t.Fatal("expected an error for a short HS256 secret")
+	}
+	if _, err := NewVerifier("none", "", StaticKey{}); err == nil {
+		t.Fatal("expected an error for an unsupported algorithm")
+	}
+}
+
+func TestJWKSCache(t *testing.T) {
+	t.Parallel()
+
+	_, rs256, otherRS256 := newTestSigners(t)
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_ = json.NewEncoder(w).Encode(rs256.JWKS())
+	}))
+	t.Cleanup(srv.Close)
+
+	v, err := NewVerifier(RS256, "quickpizza", NewJWKSCache(srv.URL, srv.Client(), time.Hour))
+	if err != nil {
+		t.Fatalf("creating verifier: %v", err)
+	}
+
+	valid := Claims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	for range 2 {
+		token, _ := rs256.Sign(valid)
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected the key set to be fetched once, got %d requests", n)
+	}
+
+	token, _ := otherRS256.Sign(valid)
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected %v for a token signed with an unknown key, got %v", ErrInvalidToken, err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected unknown keys not to be fetched again right away, got %d requests", n)
+	}
+}
+
+func TestHS256KeySetIsEmpty(t *testing.T) {
+	t.Parallel()
+
+	hs256, _, _ := newTestSigners(t)
+	if keys := hs256.JWKS().Keys; len(keys) != 0 {
+		t.Fatalf("expected no published keys, got %v", keys)
+	}
+}
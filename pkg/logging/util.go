@@ -2,19 +2,7 @@ package logging
 
 import (
 	"log/slog"
-	"os"
-	"strings"
 )
 
-func GetLogLevel() slog.Level {
-	switch strings.ToLower(os.Getenv("QUICKPIZZA_LOG_LEVEL")) {
-	case "debug":
-		return slog.LevelDebug
-	case "warn":
-		return slog.LevelWarn
-	case "error":
-		return slog.LevelError
-	default:
-		return slog.LevelInfo
-	}
-}
+// Level is the minimum level of the logs of QuickPizza, set from its configuration. It is info by default.
+var Level = new(slog.LevelVar)
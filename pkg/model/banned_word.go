@@ -0,0 +1,11 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+)
+
+// BannedWord is a word that reviews must not contain to be published without moderation.
+type BannedWord struct {
+	bun.BaseModel
+	Name string `json:"name" bun:",pk"`
+}
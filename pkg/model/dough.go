@@ -6,7 +6,7 @@ import (
 
 type Dough struct {
 	bun.BaseModel
-	ID               int64  `bun:",pk"`
+	ID               int64  `json:"id" bun:",pk"`
 	Name             string `json:"name"`
 	CaloriesPerSlice int    `json:"caloriesPerSlice"`
 }
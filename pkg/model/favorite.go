@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Favorite is a pizza saved by a user. Favorite pizzas are kept when old pizzas are pruned from the database.
+type Favorite struct {
+	bun.BaseModel
+	ID        int64     `json:"-" bun:",pk,autoincrement"`
+	UserID    int64     `json:"-" bun:",notnull,unique:favorites_user_id_pizza_id_key"`
+	User      *User     `json:"-" bun:"rel:belongs-to,join:user_id=id"`
+	PizzaID   int64     `json:"pizza_id" bun:",notnull,unique:favorites_user_id_pizza_id_key"`
+	Pizza     *Pizza    `json:"pizza,omitempty" bun:"-"`
+	CreatedAt time.Time `json:"created_at" bun:",notnull"`
+}
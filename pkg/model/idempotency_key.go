@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MaxIdempotencyKeyLength is the maximum length of the Idempotency-Key header.
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyKey is the outcome of a request sent with an Idempotency-Key header, which is replayed when the request is
+// retried with the same key. Key is a hash of the header and the token of the request, so that clients sharing a user,
+// e.g. the default one, do not get the responses to each other's requests; anonymous requests have a UserID of 0.
+type IdempotencyKey struct {
+	bun.BaseModel
+	ID     int64  `json:"-" bun:",pk,autoincrement"`
+	Key    string `json:"key" bun:",notnull,unique:idempotency_keys_user_id_key_key"`
+	UserID int64  `json:"user_id" bun:",notnull,unique:idempotency_keys_user_id_key_key"`
+	// Fingerprint identifies the request, so that the key cannot be reused for a different one.
+	Fingerprint string `json:"fingerprint" bun:",notnull"`
+	// StatusCode is 0 while the first request with the key is still being processed.
+	StatusCode  int       `json:"status_code" bun:",notnull,default:0"`
+	ContentType string    `json:"content_type,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	ETag        string    `json:"etag,omitempty" bun:"etag"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at" bun:",nullzero,notnull,default:current_timestamp"`
+	ExpiresAt   time.Time `json:"expires_at" bun:",notnull"`
+}
+
+// IsComplete returns whether the response to the request is known.
+func (k *IdempotencyKey) IsComplete() bool {
+	return k.StatusCode != 0
+}
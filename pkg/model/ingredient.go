@@ -6,7 +6,7 @@ import (
 
 type Ingredient struct {
 	bun.BaseModel    `bun:"table:ingredients,alias:i"`
-	ID               int64  `bun:",pk"`
+	ID               int64  `json:"id" bun:",pk"`
 	Name             string `json:"name"`
 	CaloriesPerSlice int    `json:"caloriesPerSlice"`
 	Vegetarian       bool   `json:"vegetarian"`
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Results of login attempts.
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+	LoginLocked    = "locked"
+)
+
+// LoginAttempt is an entry of the audit log of logins. Attempts made while the account or the client was locked out
+// are recorded too, but do not count as failures.
+type LoginAttempt struct {
+	bun.BaseModel
+	ID        int64     `json:"id" bun:",pk,autoincrement"`
+	Username  string    `json:"username" bun:",notnull"`
+	IP        string    `json:"ip" bun:"ip,notnull"`
+	Result    string    `json:"result" bun:",notnull"`
+	CreatedAt time.Time `json:"created_at" bun:",notnull"`
+}
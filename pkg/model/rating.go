@@ -1,15 +1,32 @@
 package model
 
 import (
-	"errors"
+	"fmt"
+	"unicode/utf8"
 
 	"github.com/uptrace/bun"
 )
 
+// MaxReviewLength is the maximum length of reviews, in characters.
+const MaxReviewLength = 2000
+
+// Moderation states of ratings. Ratings are approved right away unless their review needs to be checked by an admin,
+// who either approves or rejects them.
+const (
+	RatingPending  = "pending"
+	RatingApproved = "approved"
+	RatingRejected = "rejected"
+)
+
+// Rating is the opinion of a user about a pizza. Its Version is incremented every time it changes, so that concurrent
+// updates can be detected.
 type Rating struct {
 	bun.BaseModel
 	ID      int64  `json:"id" bun:",pk,autoincrement"`
 	Stars   int    `json:"stars"`
+	Review  string `json:"review,omitempty" bun:",notnull,default:''"`
+	Status  string `json:"status" bun:",nullzero,notnull,default:'approved'"`
+	Version int64  `json:"version" bun:",nullzero,notnull,default:1"`
 	UserID  int64  `json:"-"`
 	User    *User  `json:"-" bun:"rel:belongs-to,join:user_id=id"`
 	PizzaID int64  `json:"pizza_id"`
@@ -17,9 +34,16 @@ type Rating struct {
 }
 
 func (r *Rating) Validate() error {
+	var errs ValidationError
 	if r.Stars < 1 || r.Stars > 5 {
-		return errors.New("number of stars must be between 1 and 5 (inclusive)")
+		errs = append(errs, FieldError{"stars", "number of stars must be between 1 and 5 (inclusive)"})
+	}
+
+	if !utf8.ValidString(r.Review) {
+		errs = append(errs, FieldError{"review", "review must be valid UTF-8"})
+	} else if utf8.RuneCountInString(r.Review) > MaxReviewLength {
+		errs = append(errs, FieldError{"review", fmt.Sprintf("review must be at most %d characters long", MaxReviewLength)})
 	}
 
-	return nil
+	return errs.errOrNil()
 }
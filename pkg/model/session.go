@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const RefreshTokenLength = 32
+
+// Session is a login of a user. Its token authenticates requests until it expires, and its refresh token can be
+// exchanged for a new session until it expires too. A user can have any number of sessions at the same time.
+type Session struct {
+	bun.BaseModel
+	ID               int64     `bun:",pk,autoincrement"`
+	UserID           int64     `bun:",notnull"`
+	User             *User     `bun:"rel:belongs-to,join:user_id=id"`
+	Token            string    `bun:",unique,notnull"`
+	RefreshToken     string    `bun:",unique,notnull"`
+	CreatedAt        time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	ExpiresAt        time.Time `bun:",notnull"`
+	RefreshExpiresAt time.Time `bun:",notnull"`
+	RevokedAt        time.Time `bun:",nullzero"`
+}
+
+func (s *Session) IsRevoked() bool {
+	return !s.RevokedAt.IsZero()
+}
@@ -1,7 +1,7 @@
 package model
 
 import (
-	"errors"
+	"slices"
 
 	"github.com/uptrace/bun"
 )
@@ -13,18 +13,42 @@ const (
 
 const GlobalUsername = "default"
 
+// Roles of users. Admins are allowed to do anything other roles can.
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleKitchen = "kitchen"
+)
+
 func (u *User) Validate() error {
+	var errs ValidationError
+	if err := validateUsername(u.Username); err != "" {
+		errs = append(errs, FieldError{"username", err})
+	}
+	if u.Password == "" {
+		errs = append(errs, FieldError{"password", "password is empty"})
+	}
+	return errs.errOrNil()
+}
+
+// ValidateUsername returns an error if username cannot be used by a new user, or by a user changing theirs.
+func ValidateUsername(username string) error {
+	if err := validateUsername(username); err != "" {
+		return ValidationError{{"username", err}}
+	}
+	return nil
+}
+
+func validateUsername(username string) string {
 	switch {
-	case u.Username == "":
-		return errors.New("username field is empty")
-	case len(u.Username) > MaxUserNameLength:
-		return errors.New("username field is too long")
-	case u.Username == GlobalUsername:
-		return errors.New("username field is invalid")
-	case u.Password == "":
-		return errors.New("password is empty")
+	case username == "":
+		return "username field is empty"
+	case len(username) > MaxUserNameLength:
+		return "username field is too long"
+	case username == GlobalUsername:
+		return "username field is invalid"
 	default:
-		return nil
+		return ""
 	}
 }
 
@@ -35,9 +59,21 @@ type User struct {
 	Token             string `json:"token,omitempty" bun:",unique"`
 	Password          string `json:"password,omitempty" bun:"-"` // Only used for JSON
 	PasswordHash      string `json:"-"`
-	PasswordPlaintext string `json:"-"` // Only used for users created via testdata.yaml
+	PasswordPlaintext string `json:"-"` // Only used for users created via testdata.yaml, hashed on migration
+	Role              string `json:"role" bun:",nullzero,notnull,default:'user'"`
 }
 
 func (u *User) IsGlobal() bool {
 	return u.Username == GlobalUsername
 }
+
+// HasRole returns whether the user has any of roles. Admins have every role, and users without a role are regular
+// users.
+func (u *User) HasRole(roles ...string) bool {
+	role := u.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	return role == RoleAdmin || slices.Contains(roles, role)
+}
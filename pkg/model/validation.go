@@ -0,0 +1,28 @@
+package model
+
+import "strings"
+
+// FieldError describes why the value of a field is invalid.
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// ValidationError lists every invalid field of a request, so that clients can report them all at once.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	details := make([]string, len(e))
+	for i, fe := range e {
+		details[i] = fe.Detail
+	}
+	return strings.Join(details, "; ")
+}
+
+// errOrNil returns e, or nil if it has no fields, so that a nil ValidationError is not returned as a non-nil error.
+func (e ValidationError) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
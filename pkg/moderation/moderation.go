@@ -0,0 +1,91 @@
+// Package moderation decides whether reviews of ratings can be published right away. It is shared by the HTTP and gRPC
+// APIs, so that both moderate reviews the same way.
+package moderation
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// bannedWordsTTL is how long banned words are cached for, before they are fetched again.
+const bannedWordsTTL = time.Minute
+
+// Moderator decides whether reviews can be published right away, based on the banned words of the Copy service.
+type Moderator struct {
+	fetch func(ctx context.Context) ([]string, error)
+	log   *slog.Logger
+
+	mu        sync.Mutex
+	words     map[string]bool
+	fetchedAt time.Time
+}
+
+// New returns a Moderator that gets the banned words with fetch, e.g. from the Copy service.
+func New(fetch func(ctx context.Context) ([]string, error), log *slog.Logger) *Moderator {
+	return &Moderator{
+		fetch: fetch,
+		log:   log,
+	}
+}
+
+// Moderate returns the state a rating with the given review starts in. Ratings without a review are approved, and so
+// are the ones whose review has no banned words. The others, and all reviews while banned words cannot be fetched, are
+// left pending for an admin to check.
+func (m *Moderator) Moderate(ctx context.Context, review string) string {
+	if strings.TrimSpace(review) == "" {
+		return model.RatingApproved
+	}
+
+	words, err := m.bannedWords(ctx)
+	if err != nil {
+		m.log.WarnContext(ctx, "Failed to fetch banned words, leaving review pending", "err", err)
+		return model.RatingPending
+	}
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(review), isNotWordRune) {
+		if words[word] {
+			m.log.DebugContext(ctx, "Review contains a banned word", "word", word)
+			return model.RatingPending
+		}
+	}
+
+	return model.RatingApproved
+}
+
+// bannedWords returns the cached banned words, fetching them first if they are missing or too old. If they cannot be
+// fetched, the previous ones are used.
+func (m *Moderator) bannedWords(ctx context.Context) (map[string]bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.words != nil && time.Since(m.fetchedAt) < bannedWordsTTL {
+		return m.words, nil
+	}
+
+	list, err := m.fetch(ctx)
+	if err != nil {
+		if m.words != nil {
+			m.log.WarnContext(ctx, "Failed to refresh banned words, using previous ones", "err", err)
+			return m.words, nil
+		}
+		return nil, err
+	}
+
+	m.words = make(map[string]bool, len(list))
+	for _, word := range list {
+		m.words[strings.ToLower(word)] = true
+	}
+	m.fetchedAt = time.Now()
+
+	return m.words, nil
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
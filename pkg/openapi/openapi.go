@@ -0,0 +1,394 @@
+// Package openapi loads the subset of OpenAPI 3.0 documents QuickPizza uses to describe its API, and validates
+// requests and responses against them.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Methods lists the methods of the operations of a path item, in the order they are reported.
+var Methods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+
+	routes []*route
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+	Headers       map[string]*Header      `yaml:"headers"`
+}
+
+// PathItem describes the operations of a path.
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+}
+
+// Operation returns the operation of the path item for the given method, or nil if there is none.
+func (p *PathItem) Operation(method string) *Operation {
+	switch method {
+	case http.MethodGet:
+		return p.Get
+	case http.MethodPut:
+		return p.Put
+	case http.MethodPost:
+		return p.Post
+	case http.MethodDelete:
+		return p.Delete
+	case http.MethodOptions:
+		return p.Options
+	case http.MethodHead:
+		return p.Head
+	case http.MethodPatch:
+		return p.Patch
+	default:
+		return nil
+	}
+}
+
+// Operation describes a request to a path with a given method, and its responses.
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+
+	// Method and Path are set when the document is loaded.
+	Method string `yaml:"-"`
+	Path   string `yaml:"-"`
+}
+
+// Response returns the description of the response with the given status, falling back to the range of the status,
+// e.g. 4XX, and then to the default response. It returns nil if the status is not documented.
+func (op *Operation) Response(status int) *Response {
+	for _, key := range []string{fmt.Sprint(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if resp, ok := op.Responses[key]; ok {
+			return resp
+		}
+	}
+	return nil
+}
+
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+type Response struct {
+	Ref     string                `yaml:"$ref"`
+	Headers map[string]*Header    `yaml:"headers"`
+	Content map[string]*MediaType `yaml:"content"`
+}
+
+type Header struct {
+	Ref    string  `yaml:"$ref"`
+	Schema *Schema `yaml:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Load parses an OpenAPI document in YAML or JSON, and resolves its references. It fails if a reference does not
+// point to one of the components of the document.
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI document: %w", err)
+	}
+
+	if err := doc.resolve(); err != nil {
+		return nil, fmt.Errorf("resolving OpenAPI document: %w", err)
+	}
+
+	for path, item := range doc.Paths {
+		for _, method := range Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			op.Method = method
+			op.Path = path
+			// Parameters of the path item apply to all of its operations, unless they are overridden.
+			for _, p := range item.Parameters {
+				if !slices.ContainsFunc(op.Parameters, func(o *Parameter) bool { return o.Name == p.Name && o.In == p.In }) {
+					op.Parameters = append(op.Parameters, p)
+				}
+			}
+		}
+		doc.routes = append(doc.routes, newRoute(path, item))
+	}
+
+	// Paths without templates take precedence over templated ones, e.g. /api/users/me over /api/users/{id}.
+	sort.Slice(doc.routes, func(i, j int) bool {
+		a, b := doc.routes[i], doc.routes[j]
+		if a.params != b.params {
+			return a.params < b.params
+		}
+		return a.path < b.path
+	})
+
+	return &doc, nil
+}
+
+// Operations returns the operations of the document, sorted by path and method.
+func (d *Document) Operations() []*Operation {
+	var ops []*Operation
+	for _, path := range d.sortedPaths() {
+		for _, method := range Methods {
+			if op := d.Paths[path].Operation(method); op != nil {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+func (d *Document) sortedPaths() []string {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// FindPath returns the path item matching the path of a request, along with the values of the parameters in its
+// template. It returns a nil path item if no path of the document matches.
+func (d *Document) FindPath(path string) (*PathItem, map[string]string) {
+	segments := strings.Split(path, "/")
+	for _, rt := range d.routes {
+		if params, ok := rt.match(segments); ok {
+			return rt.item, params
+		}
+	}
+	return nil, nil
+}
+
+// FindOperation returns the operation matching a request, along with the values of the parameters in the template of
+// its path. It returns a nil operation if the document does not describe the request.
+func (d *Document) FindOperation(method, path string) (*Operation, map[string]string) {
+	item, params := d.FindPath(path)
+	if item == nil {
+		return nil, nil
+	}
+	op := item.Operation(method)
+	if op == nil {
+		return nil, nil
+	}
+	return op, params
+}
+
+// route matches request paths against the template of a path of the document.
+type route struct {
+	path     string
+	item     *PathItem
+	segments []string
+	params   int
+}
+
+func newRoute(path string, item *PathItem) *route {
+	rt := &route{path: path, item: item, segments: strings.Split(path, "/")}
+	for _, s := range rt.segments {
+		if isTemplate(s) {
+			rt.params++
+		}
+	}
+	return rt
+}
+
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, s := range rt.segments {
+		switch {
+		case isTemplate(s):
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[s[1:len(s)-1]] = segments[i]
+		case s != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func isTemplate(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// resolve replaces the references of the document with the components they point to.
+func (d *Document) resolve() error {
+	r := &resolver{doc: d, schemas: map[*Schema]bool{}}
+
+	for _, s := range d.Components.Schemas {
+		r.schema(s)
+	}
+	for _, p := range d.Components.Parameters {
+		r.parameter(p)
+	}
+	for _, h := range d.Components.Headers {
+		r.header(h)
+	}
+	for _, b := range d.Components.RequestBodies {
+		r.requestBody(b)
+	}
+	for _, resp := range d.Components.Responses {
+		r.response(resp)
+	}
+
+	for _, item := range d.Paths {
+		for i, p := range item.Parameters {
+			item.Parameters[i] = r.parameter(p)
+		}
+		for _, method := range Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			for i, p := range op.Parameters {
+				op.Parameters[i] = r.parameter(p)
+			}
+			op.RequestBody = r.requestBody(op.RequestBody)
+			for status, resp := range op.Responses {
+				op.Responses[status] = r.response(resp)
+			}
+		}
+	}
+
+	return r.err
+}
+
+type resolver struct {
+	doc *Document
+	// schemas holds the schemas already resolved, so that recursive schemas are only visited once.
+	schemas map[*Schema]bool
+	err     error
+}
+
+// component returns the name of the component of the given kind a reference points to.
+func (r *resolver) component(ref, kind string) string {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok && r.err == nil {
+		r.err = fmt.Errorf("unsupported reference %q", ref)
+	}
+	return name
+}
+
+func lookup[T any](r *resolver, ref, kind string, components map[string]*T) *T {
+	c, ok := components[r.component(ref, kind)]
+	if !ok && r.err == nil {
+		r.err = fmt.Errorf("reference %q does not point to a component", ref)
+	}
+	return c
+}
+
+func (r *resolver) schema(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if target := lookup(r, s.Ref, "schemas", r.doc.Components.Schemas); target != nil {
+			return r.schema(target)
+		}
+		return s
+	}
+	if r.schemas[s] {
+		return s
+	}
+	r.schemas[s] = true
+
+	for _, name := range s.Properties.names {
+		s.Properties.schemas[name] = r.schema(s.Properties.schemas[name])
+	}
+	s.Items = r.schema(s.Items)
+	s.AdditionalProperties.Schema = r.schema(s.AdditionalProperties.Schema)
+	return s
+}
+
+func (r *resolver) parameter(p *Parameter) *Parameter {
+	if p != nil && p.Ref != "" {
+		return r.parameter(lookup(r, p.Ref, "parameters", r.doc.Components.Parameters))
+	}
+	if p != nil {
+		p.Schema = r.schema(p.Schema)
+	}
+	return p
+}
+
+func (r *resolver) header(h *Header) *Header {
+	if h != nil && h.Ref != "" {
+		return r.header(lookup(r, h.Ref, "headers", r.doc.Components.Headers))
+	}
+	if h != nil {
+		h.Schema = r.schema(h.Schema)
+	}
+	return h
+}
+
+func (r *resolver) requestBody(b *RequestBody) *RequestBody {
+	if b != nil && b.Ref != "" {
+		return r.requestBody(lookup(r, b.Ref, "requestBodies", r.doc.Components.RequestBodies))
+	}
+	if b != nil {
+		for _, mt := range b.Content {
+			mt.Schema = r.schema(mt.Schema)
+		}
+	}
+	return b
+}
+
+func (r *resolver) response(resp *Response) *Response {
+	if resp != nil && resp.Ref != "" {
+		return r.response(lookup(r, resp.Ref, "responses", r.doc.Components.Responses))
+	}
+	if resp != nil {
+		for name, h := range resp.Headers {
+			resp.Headers[name] = r.header(h)
+		}
+		for _, mt := range resp.Content {
+			mt.Schema = r.schema(mt.Schema)
+		}
+	}
+	return resp
+}
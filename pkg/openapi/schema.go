@@ -0,0 +1,356 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the subset of the OpenAPI 3.0 schema object QuickPizza uses.
+//
+// Unlike JSON Schema, objects do not allow properties other than the listed ones, unless additionalProperties says so:
+// the API rejects unknown fields in requests, and fields missing from the document would be invisible to clients
+// generated from it.
+type Schema struct {
+	Ref                  string               `yaml:"$ref"`
+	Type                 string               `yaml:"type"`
+	Format               string               `yaml:"format"`
+	Nullable             bool                 `yaml:"nullable"`
+	ReadOnly             bool                 `yaml:"readOnly"`
+	Enum                 []any                `yaml:"enum"`
+	Minimum              *float64             `yaml:"minimum"`
+	Maximum              *float64             `yaml:"maximum"`
+	MinLength            *int                 `yaml:"minLength"`
+	MaxLength            *int                 `yaml:"maxLength"`
+	Items                *Schema              `yaml:"items"`
+	Properties           Properties           `yaml:"properties"`
+	Required             []string             `yaml:"required"`
+	AdditionalProperties AdditionalProperties `yaml:"additionalProperties"`
+}
+
+// Properties are the properties of an object schema, in the order they are listed in the document.
+type Properties struct {
+	names   []string
+	schemas map[string]*Schema
+}
+
+func (p *Properties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: properties must be a mapping", node.Line)
+	}
+
+	p.schemas = map[string]*Schema{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		var s Schema
+		if err := node.Content[i+1].Decode(&s); err != nil {
+			return err
+		}
+		p.names = append(p.names, name)
+		p.schemas[name] = &s
+	}
+	return nil
+}
+
+// Get returns the schema of the property with the given name, or nil if there is none.
+func (p Properties) Get(name string) *Schema {
+	return p.schemas[name]
+}
+
+// AdditionalProperties says whether an object schema allows properties other than the listed ones, and which schema
+// their values have if it does.
+type AdditionalProperties struct {
+	Allowed bool
+	// Schema of the values of additional properties. Any value is allowed if it is nil.
+	Schema *Schema
+}
+
+func (a *AdditionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Allowed)
+	}
+
+	a.Allowed = true
+	return node.Decode(&a.Schema)
+}
+
+// Error describes a value that does not match the document.
+type Error struct {
+	// In is where the value is: path, query, header or body.
+	In string
+	// Field is the name of the parameter, or the location of the value in the body, e.g. ingredients[0].name. It is
+	// empty for the whole body.
+	Field  string
+	Detail string
+}
+
+func (e Error) Error() string {
+	return e.Detail
+}
+
+// Errors lists the values that do not match the document.
+type Errors []Error
+
+func (e Errors) Error() string {
+	details := make([]string, len(e))
+	for i, err := range e {
+		details[i] = err.Detail
+	}
+	return strings.Join(details, "; ")
+}
+
+// Validate validates v, a value decoded from JSON with json.Decoder.UseNumber, against the schema. Errors are
+// reported to be in the given location, and their fields are relative to field.
+func (s *Schema) Validate(in, field string, v any) Errors {
+	var errs Errors
+	s.validate(&validation{in: in, errs: &errs}, field, v)
+	return errs
+}
+
+type validation struct {
+	in string
+	// request is true when validating requests, which may leave out read-only properties.
+	request bool
+	errs    *Errors
+}
+
+func (c *validation) fail(field, format string, args ...any) {
+	*c.errs = append(*c.errs, Error{In: c.in, Field: field, Detail: fmt.Sprintf(format, args...)})
+}
+
+// describe names a value in error details.
+func describe(field string) string {
+	if field == "" {
+		return "body"
+	}
+	return field
+}
+
+func (s *Schema) validate(c *validation, field string, v any) {
+	if s == nil {
+		return
+	}
+
+	if v == nil {
+		if !s.Nullable && s.Type != "" {
+			c.fail(field, "%s must not be null", describe(field))
+		}
+		return
+	}
+
+	switch s.Type {
+	case "":
+		// Any value is allowed.
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			c.fail(field, "%s must be an object", describe(field))
+			return
+		}
+		s.validateObject(c, field, obj)
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			c.fail(field, "%s must be an array", describe(field))
+			return
+		}
+		for i, item := range arr {
+			s.Items.validate(c, fmt.Sprintf("%s[%d]", field, i), item)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			c.fail(field, "%s must be a string", describe(field))
+			return
+		}
+		s.validateString(c, field, str)
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			c.fail(field, "%s must be an integer", describe(field))
+			return
+		}
+		if _, err := n.Int64(); err != nil {
+			c.fail(field, "%s must be an integer", describe(field))
+			return
+		}
+		s.validateNumber(c, field, n)
+	case "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			c.fail(field, "%s must be a number", describe(field))
+			return
+		}
+		s.validateNumber(c, field, n)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			c.fail(field, "%s must be a boolean", describe(field))
+			return
+		}
+	default:
+		c.fail(field, "%s has unsupported type %q in the OpenAPI document", describe(field), s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !s.inEnum(v) {
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = fmt.Sprint(e)
+		}
+		c.fail(field, "%s must be one of %s", describe(field), strings.Join(values, ", "))
+	}
+}
+
+func (s *Schema) validateObject(c *validation, field string, obj map[string]any) {
+	child := func(name string) string {
+		if field == "" {
+			return name
+		}
+		return field + "." + name
+	}
+
+	for _, name := range s.Properties.names {
+		prop := s.Properties.schemas[name]
+		value, ok := obj[name]
+		if !ok {
+			if slices.Contains(s.Required, name) && !(c.request && prop.ReadOnly) {
+				c.fail(child(name), "%s is required", child(name))
+			}
+			continue
+		}
+		prop.validate(c, child(name), value)
+	}
+
+	for _, name := range s.Required {
+		if _, listed := s.Properties.schemas[name]; !listed {
+			if _, ok := obj[name]; !ok {
+				c.fail(child(name), "%s is required", child(name))
+			}
+		}
+	}
+
+	var unknown []string
+	for name := range obj {
+		if _, listed := s.Properties.schemas[name]; !listed {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		if !s.AdditionalProperties.Allowed {
+			c.fail(child(name), "unknown field %q", child(name))
+			continue
+		}
+		s.AdditionalProperties.Schema.validate(c, child(name), obj[name])
+	}
+}
+
+func (s *Schema) validateString(c *validation, field, str string) {
+	n := utf8.RuneCountInString(str)
+	if s.MinLength != nil && n < *s.MinLength {
+		c.fail(field, "%s must be at least %d characters long", describe(field), *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		c.fail(field, "%s must be at most %d characters long", describe(field), *s.MaxLength)
+	}
+
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			c.fail(field, "%s must be an RFC 3339 date-time", describe(field))
+		}
+	}
+}
+
+func (s *Schema) validateNumber(c *validation, field string, n json.Number) {
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) {
+		c.fail(field, "%s must be a number", describe(field))
+		return
+	}
+
+	if s.Minimum != nil && f < *s.Minimum {
+		c.fail(field, "%s must be at least %s", describe(field), formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		c.fail(field, "%s must be at most %s", describe(field), formatNumber(*s.Maximum))
+	}
+}
+
+func (s *Schema) inEnum(v any) bool {
+	for _, e := range s.Enum {
+		if n, ok := v.(json.Number); ok {
+			if f, err := n.Float64(); err == nil && fmt.Sprint(f) == fmt.Sprint(toFloat(e)) {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat converts the numbers enum values are decoded to from YAML to float64.
+func toFloat(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return v
+	}
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseParameter converts the value of a parameter to the type of its schema, so that it can be validated like a
+// JSON value. It returns false if the value cannot be converted.
+func (s *Schema) parseParameter(value string) (any, bool) {
+	if s == nil {
+		return value, true
+	}
+
+	switch s.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(value), true
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, false
+		}
+		return json.Number(value), true
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, false
+		}
+		return b, true
+	case "array":
+		var items []any
+		for _, item := range strings.Split(value, ",") {
+			v, ok := s.Items.parseParameter(item)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, v)
+		}
+		return items, true
+	default:
+		return value, true
+	}
+}
@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidateRequest validates the parameters and the body of a request for the operation. pathParams are the values of
+// the parameters in the template of the path, as returned by FindOperation.
+//
+// Bodies are only validated if they are JSON or form documents described by the operation. Bodies that are missing or
+// malformed are left to the handler of the request, so that they are reported like for any other request.
+func (op *Operation) ValidateRequest(r *http.Request, pathParams map[string]string, body []byte) Errors {
+	var errs Errors
+	c := &validation{request: true, errs: &errs}
+
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			if v, ok := pathParams[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = r.Header.Values(p.Name)
+		case "cookie":
+			if cookie, err := r.Cookie(p.Name); err == nil {
+				values = []string{cookie.Value}
+			}
+		}
+
+		c.in = p.In
+		if len(values) == 0 {
+			if p.Required {
+				c.fail(p.Name, "%s %s is required", p.In, p.Name)
+			}
+			continue
+		}
+
+		for _, value := range values {
+			v, ok := p.Schema.parseParameter(value)
+			if !ok {
+				c.fail(p.Name, "%s %s must be %s", p.In, p.Name, typeName(p.Schema))
+				continue
+			}
+			p.Schema.validate(c, p.Name, v)
+		}
+	}
+
+	if op.RequestBody == nil || len(body) == 0 {
+		return errs
+	}
+
+	schema, mediaType := op.RequestBody.schema(r.Header.Get("Content-Type"))
+	if schema == nil {
+		return errs
+	}
+
+	var v any
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return errs
+		}
+		v = schema.formValue(form)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return errs
+		}
+	}
+
+	c.in = "body"
+	schema.validate(c, "", v)
+	return errs
+}
+
+// schema returns the schema of request bodies with the given Content-Type, and the media type it is described with.
+// As handlers decode JSON regardless of the Content-Type, bodies with a media type the request body does not list
+// are validated as JSON if it lists application/json.
+func (b *RequestBody) schema(contentType string) (*Schema, string) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mt, ok := b.Content[mediaType]; ok {
+		return mt.Schema, mediaType
+	}
+	if _, ok := b.Content["*/*"]; ok {
+		return nil, ""
+	}
+	if mt, ok := b.Content["application/json"]; ok {
+		return mt.Schema, "application/json"
+	}
+	return nil, ""
+}
+
+// formValue converts a form to an object, with values converted to the types of the properties of the schema. Values
+// that cannot be converted are kept as strings, so that they fail validation.
+func (s *Schema) formValue(form url.Values) map[string]any {
+	obj := map[string]any{}
+	for name, values := range form {
+		if len(values) == 0 {
+			continue
+		}
+		obj[name] = values[0]
+		if v, ok := s.Properties.Get(name).parseParameter(values[0]); ok {
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+// ValidateResponse validates the body of a response to the operation, with the given status and headers, against
+// the response the operation documents for the status. It returns an error without validating the body if the status
+// is not documented.
+func (op *Operation) ValidateResponse(status int, header http.Header, body []byte) Errors {
+	resp := op.Response(status)
+	if resp == nil {
+		return Errors{{In: "status", Detail: fmt.Sprintf("status %d is not documented", status)}}
+	}
+	return resp.Validate(header, body)
+}
+
+// Validate validates the body of a response, with the given headers, against the description of the response.
+func (resp *Response) Validate(header http.Header, body []byte) Errors {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(resp.Content) == 0 {
+		return Errors{{In: "body", Detail: "the response has a body, but none is documented"}}
+	}
+
+	contentType := header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	mt, ok := resp.Content[mediaType]
+	if !ok {
+		if _, ok := resp.Content["*/*"]; ok {
+			return nil
+		}
+		var documented []string
+		for name := range resp.Content {
+			documented = append(documented, name)
+		}
+		sort.Strings(documented)
+		return Errors{{
+			In:     "header",
+			Field:  "Content-Type",
+			Detail: fmt.Sprintf("Content-Type %q is not documented, expected one of %s", contentType, strings.Join(documented, ", ")),
+		}}
+	}
+
+	if mt.Schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return Errors{{In: "body", Detail: fmt.Sprintf("body is not valid JSON: %v", err)}}
+	}
+	return mt.Schema.Validate("body", "", v)
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// typeName describes the values of a schema in error details.
+func typeName(s *Schema) string {
+	switch s.Type {
+	case "integer":
+		return "an integer"
+	case "array", "object":
+		return "an " + s.Type
+	default:
+		return "a " + s.Type
+	}
+}
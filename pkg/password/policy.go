@@ -0,0 +1,91 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxLength is the longest password bcrypt can hash.
+const maxLength = 72
+
+// Policy describes the passwords users are allowed to choose.
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// DefaultPolicy returns the policy used unless configured otherwise: at least 8 characters, of any kind.
+func DefaultPolicy() Policy {
+	return Policy{MinLength: 8}
+}
+
+// ParseRequirements sets the character classes required by the policy from a comma-separated list of "upper",
+// "lower", "digit" and "symbol".
+func (p *Policy) ParseRequirements(v string) error {
+	for _, req := range strings.Split(v, ",") {
+		switch strings.TrimSpace(req) {
+		case "":
+		case "upper":
+			p.RequireUpper = true
+		case "lower":
+			p.RequireLower = true
+		case "digit":
+			p.RequireDigit = true
+		case "symbol":
+			p.RequireSymbol = true
+		default:
+			return fmt.Errorf("unknown password requirement %q", req)
+		}
+	}
+	return nil
+}
+
+// Check returns an error describing why password is not allowed for the user with the given name, if it is not.
+func (p Policy) Check(username, password string) error {
+	switch {
+	case len(password) < p.MinLength:
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	case len(password) > maxLength:
+		return fmt.Errorf("password must be at most %d bytes long", maxLength)
+	case username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)):
+		return errors.New("password must not contain the username")
+	case p.RequireUpper && !strings.ContainsFunc(password, unicode.IsUpper):
+		return errors.New("password must contain an uppercase letter")
+	case p.RequireLower && !strings.ContainsFunc(password, unicode.IsLower):
+		return errors.New("password must contain a lowercase letter")
+	case p.RequireDigit && !strings.ContainsFunc(password, unicode.IsDigit):
+		return errors.New("password must contain a digit")
+	case p.RequireSymbol && !strings.ContainsFunc(password, isSymbol):
+		return errors.New("password must contain a symbol")
+	default:
+		return nil
+	}
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+// String describes the policy, e.g. for logging.
+func (p Policy) String() string {
+	var reqs []string
+	for _, req := range []struct {
+		name     string
+		required bool
+	}{
+		{"upper", p.RequireUpper},
+		{"lower", p.RequireLower},
+		{"digit", p.RequireDigit},
+		{"symbol", p.RequireSymbol},
+	} {
+		if req.required {
+			reqs = append(reqs, req.name)
+		}
+	}
+	return fmt.Sprintf("min=%d require=%s", p.MinLength, strings.Join(reqs, ","))
+}
@@ -0,0 +1,164 @@
+// Package ratelimit implements an in-memory token bucket rate limiter.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is the sustained number of requests allowed per period, with up to Burst requests allowed at once.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// ParseRate parses a rate in the form "<limit>/<period>", optionally followed by ":<burst>", e.g. "10/s", "100/m" or
+// "5/s:20". Periods can be "s", "m", "h" or any value accepted by time.ParseDuration, such as "10s". If no burst is
+// given, it defaults to the limit.
+func ParseRate(v string) (Rate, error) {
+	spec, burstStr, hasBurst := strings.Cut(strings.TrimSpace(v), ":")
+	limitStr, periodStr, found := strings.Cut(spec, "/")
+	if !found {
+		return Rate{}, fmt.Errorf("invalid rate %q: expected <limit>/<period>", v)
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate %q: limit must be a positive integer", v)
+	}
+
+	var period time.Duration
+	switch periodStr {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		period, err = time.ParseDuration(periodStr)
+		if err != nil || period <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q: invalid period %q", v, periodStr)
+		}
+	}
+
+	burst := limit
+	if hasBurst {
+		burst, err = strconv.Atoi(burstStr)
+		if err != nil || burst <= 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q: burst must be a positive integer", v)
+		}
+	}
+
+	return Rate{Limit: limit, Period: period, Burst: burst}, nil
+}
+
+// String returns the rate in the format accepted by ParseRate.
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s:%d", r.Limit, r.Period, r.Burst)
+}
+
+// interval returns the time it takes for the bucket to regain one token.
+func (r Rate) interval() time.Duration {
+	return r.Period / time.Duration(r.Limit)
+}
+
+// Result describes the outcome of a call to Limiter.Allow.
+type Result struct {
+	// Allowed is true if the request can proceed.
+	Allowed bool
+	// Limit is the maximum number of requests that can be made at once, i.e. the bucket capacity.
+	Limit int
+	// Remaining is the number of requests that can still be made right away.
+	Remaining int
+	// Reset is the time left until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time left until the next request is allowed. It is zero if Allowed is true.
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps one token bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	rate Rate
+	now  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// New returns a Limiter that allows requests at the given rate for each key.
+func New(rate Rate) *Limiter {
+	if rate.Burst <= 0 {
+		rate.Burst = rate.Limit
+	}
+
+	return &Limiter{
+		rate:      rate,
+		now:       time.Now,
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// Rate returns the rate enforced by the limiter.
+func (l *Limiter) Rate() Rate {
+	return l.rate
+}
+
+// Allow takes one token from the bucket for key, if there is any left.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.rate.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	interval := l.rate.interval()
+	b.tokens = math.Min(float64(l.rate.Burst), b.tokens+float64(now.Sub(b.last))/float64(interval))
+	b.last = now
+
+	res := Result{Limit: l.rate.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((float64(l.rate.Burst) - b.tokens) * float64(interval))
+	return res
+}
+
+// sweep forgets about buckets that have been idle for long enough to be full again, as they are equivalent to new
+// ones. It runs at most once per fill period, so that memory usage is bounded by the number of recently active keys.
+func (l *Limiter) sweep(now time.Time) {
+	fill := l.rate.interval() * time.Duration(l.rate.Burst)
+	if now.Sub(l.lastSweep) < fill {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= fill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value   string
+		want    Rate
+		wantErr bool
+	}{
+		{value: "10/s", want: Rate{Limit: 10, Period: time.Second, Burst: 10}},
+		{value: "100/m", want: Rate{Limit: 100, Period: time.Minute, Burst: 100}},
+		{value: "1000/h", want: Rate{Limit: 1000, Period: time.Hour, Burst: 1000}},
+		{value: "5/s:20", want: Rate{Limit: 5, Period: time.Second, Burst: 20}},
+		{value: "3/10s", want: Rate{Limit: 3, Period: 10 * time.Second, Burst: 3}},
+		{value: " 2/500ms ", want: Rate{Limit: 2, Period: 500 * time.Millisecond, Burst: 2}},
+		{value: "", wantErr: true},
+		{value: "10", wantErr: true},
+		{value: "0/s", wantErr: true},
+		{value: "-1/s", wantErr: true},
+		{value: "ten/s", wantErr: true},
+		{value: "10/d", wantErr: true},
+		{value: "10/-1s", wantErr: true},
+		{value: "10/s:0", wantErr: true},
+		{value: "10/s:many", wantErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseRate(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRateStringRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	rate := Rate{Limit: 5, Period: 10 * time.Second, Burst: 20}
+	got, err := ParseRate(rate.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != rate {
+		t.Fatalf("expected %+v, got %+v", rate, got)
+	}
+}
+
+// step is a call to Limiter.Allow, after advancing the clock by elapsed.
+type step struct {
+	elapsed    time.Duration
+	key        string
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+func TestLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		rate  Rate
+		steps []step
+	}{
+		{
+			name: "burst then reject",
+			rate: Rate{Limit: 2, Period: time.Second},
+			steps: []step{
+				{allowed: true, remaining: 1},
+				{allowed: true, remaining: 0},
+				{allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill over time",
+			rate: Rate{Limit: 2, Period: time.Second},
+			steps: []step{
+				{allowed: true, remaining: 1},
+				{allowed: true, remaining: 0},
+				{elapsed: 200 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 300 * time.Millisecond},
+				{elapsed: 300 * time.Millisecond, allowed: true, remaining: 0},
+				{elapsed: 10 * time.Second, allowed: true, remaining: 1},
+			},
+		},
+		{
+			name: "burst larger than limit",
+			rate: Rate{Limit: 1, Period: time.Second, Burst: 3},
+			steps: []step{
+				{allowed: true, remaining: 2},
+				{allowed: true, remaining: 1},
+				{allowed: true, remaining: 0},
+				{allowed: false, remaining: 0, retryAfter: time.Second},
+			},
+		},
+		{
+			name: "keys have their own buckets",
+			rate: Rate{Limit: 1, Period: time.Minute},
+			steps: []step{
+				{key: "a", allowed: true, remaining: 0},
+				{key: "a", allowed: false, remaining: 0, retryAfter: time.Minute},
+				{key: "b", allowed: true, remaining: 0},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Unix(0, 0)
+			l := New(tc.rate)
+			l.now = func() time.Time { return now }
+			l.lastSweep = now
+
+			for i, s := range tc.steps {
+				now = now.Add(s.elapsed)
+				res := l.Allow(s.key)
+				if res.Allowed != s.allowed || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter {
+					t.Fatalf("step %d: expected allowed=%t remaining=%d retryAfter=%s, got %+v",
+						i, s.allowed, s.remaining, s.retryAfter, res)
+				}
+				if res.Limit != l.Rate().Burst {
+					t.Fatalf("step %d: expected limit %d, got %d", i, l.Rate().Burst, res.Limit)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(0, 0)
+	l := New(Rate{Limit: 1, Period: time.Second})
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+
+	l.Allow("a")
+	now = now.Add(2 * time.Second)
+	l.Allow("b")
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Fatal("expected the idle bucket to be swept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Fatal("expected the active bucket to be kept")
+	}
+}
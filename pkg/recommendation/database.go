@@ -0,0 +1,47 @@
+package recommendation
+
+import (
+	"context"
+
+	"github.com/grafana/quickpizza/pkg/database"
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// NewDatabaseCatalog returns a Catalog backed by the database of the Catalog service, for the APIs that run its business
+// logic themselves, like gRPC and GraphQL.
+func NewDatabaseCatalog(db *database.Catalog) Catalog {
+	return databaseCatalog{db}
+}
+
+type databaseCatalog struct {
+	db *database.Catalog
+}
+
+func (c databaseCatalog) Ingredients(ctx context.Context, ingredientType string) ([]model.Ingredient, error) {
+	return c.db.GetIngredients(ctx, ingredientType)
+}
+
+func (c databaseCatalog) Tools(ctx context.Context) ([]string, error) {
+	return c.db.GetTools(ctx)
+}
+
+func (c databaseCatalog) Doughs(ctx context.Context) ([]model.Dough, error) {
+	return c.db.GetDoughs(ctx)
+}
+
+// NewDatabaseCopy returns a Copy backed by the database of the Copy service, see NewDatabaseCatalog.
+func NewDatabaseCopy(db *database.Copy) Copy {
+	return databaseCopy{db}
+}
+
+type databaseCopy struct {
+	db *database.Copy
+}
+
+func (c databaseCopy) Adjectives(ctx context.Context) ([]string, error) {
+	return c.db.GetAdjectives(ctx)
+}
+
+func (c databaseCopy) Names(ctx context.Context) ([]string, error) {
+	return c.db.GetClassicalNames(ctx)
+}
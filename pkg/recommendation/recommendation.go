@@ -0,0 +1,198 @@
+// Package recommendation generates the pizzas QuickPizza recommends. It is shared by the HTTP and gRPC APIs, so that
+// both recommend pizzas the same way.
+package recommendation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/quickpizza/pkg/model"
+)
+
+// ErrNoMatch is returned when the restrictions exclude all the ingredients of a kind, or all the tools.
+var ErrNoMatch = errors.New("no pizza matches the restrictions")
+
+// Restrictions are sent by the client to further specify how the target pizza should look like
+type Restrictions struct {
+	MaxCaloriesPerSlice int      `json:"maxCaloriesPerSlice"`
+	MustBeVegetarian    bool     `json:"mustBeVegetarian"`
+	ExcludedIngredients []string `json:"excludedIngredients"`
+	ExcludedTools       []string `json:"excludedTools"`
+	MaxNumberOfToppings int      `json:"maxNumberOfToppings"`
+	MinNumberOfToppings int      `json:"minNumberOfToppings"`
+	CustomName          string   `json:"customName"`
+}
+
+func (r Restrictions) WithDefaults() Restrictions {
+	if r.MaxCaloriesPerSlice == 0 {
+		r.MaxCaloriesPerSlice = 1000
+	}
+	if r.MaxNumberOfToppings == 0 {
+		r.MaxNumberOfToppings = 5
+	}
+	if r.MinNumberOfToppings == 0 {
+		r.MinNumberOfToppings = 3
+	}
+
+	return r
+}
+
+// Catalog provides the ingredients, tools and doughs pizzas are made of.
+type Catalog interface {
+	Ingredients(ctx context.Context, ingredientType string) ([]model.Ingredient, error)
+	Tools(ctx context.Context) ([]string, error)
+	Doughs(ctx context.Context) ([]model.Dough, error)
+}
+
+// Copy provides the words the names of pizzas are made of.
+type Copy interface {
+	Adjectives(ctx context.Context) ([]string, error)
+	Names(ctx context.Context) ([]string, error)
+}
+
+// Generate generates a pizza following the restrictions, with ingredients and tools from catalog, and a name made of
+// words from the copy unless the restrictions give one. The pizza is not recorded, so it has no ID.
+func Generate(ctx context.Context, catalog Catalog, cp Copy, restrictions Restrictions) (model.Pizza, error) {
+	restrictions = restrictions.WithDefaults()
+
+	if len(restrictions.CustomName) > model.MaxPizzaNameLength {
+		restrictions.CustomName = restrictions.CustomName[:model.MaxPizzaNameLength]
+	}
+
+	allowed := func(ingredient model.Ingredient) bool {
+		return !slices.Contains(restrictions.ExcludedIngredients, ingredient.Name) && (!restrictions.MustBeVegetarian || ingredient.Vegetarian)
+	}
+
+	// Retrieve list of ingredients from Catalog.
+	validIngredients := map[string][]model.Ingredient{}
+	for _, ingredientType := range []string{"olive_oil", "tomato", "mozzarella", "topping"} {
+		ingredients, err := catalog.Ingredients(ctx, ingredientType)
+		if err != nil {
+			return model.Pizza{}, fmt.Errorf("requesting ingredients: %w", err)
+		}
+
+		for _, ingredient := range ingredients {
+			if allowed(ingredient) {
+				validIngredients[ingredientType] = append(validIngredients[ingredientType], ingredient)
+			}
+		}
+		if len(validIngredients[ingredientType]) == 0 {
+			return model.Pizza{}, fmt.Errorf("%w: all %s ingredients are excluded", ErrNoMatch, ingredientType)
+		}
+	}
+
+	tools, err := catalog.Tools(ctx)
+	if err != nil {
+		return model.Pizza{}, fmt.Errorf("requesting tools: %w", err)
+	}
+
+	var validTools []string
+	for _, tool := range tools {
+		if !slices.Contains(restrictions.ExcludedTools, tool) {
+			validTools = append(validTools, tool)
+		}
+	}
+	if len(validTools) == 0 {
+		return model.Pizza{}, fmt.Errorf("%w: all tools are excluded", ErrNoMatch)
+	}
+
+	doughs, err := catalog.Doughs(ctx)
+	if err != nil {
+		return model.Pizza{}, fmt.Errorf("requesting doughs: %w", err)
+	}
+
+	// Retrieve adjectives and names from Copy.
+	adjectives, err := cp.Adjectives(ctx)
+	if err != nil {
+		return model.Pizza{}, fmt.Errorf("requesting adjectives: %w", err)
+	}
+
+	names, err := cp.Names(ctx)
+	if err != nil {
+		return model.Pizza{}, fmt.Errorf("requesting names: %w", err)
+	}
+
+	if len(doughs) == 0 || len(adjectives) == 0 || len(names) == 0 {
+		return model.Pizza{}, errors.New("the catalog or the copy is empty")
+	}
+
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer("")
+
+	pizzaCtx, pizzaSpan := tracer.Start(ctx, "pizza-generation")
+	defer pizzaSpan.End()
+
+	pick := func(ingredientType string) model.Ingredient {
+		ingredients := validIngredients[ingredientType]
+		return ingredients[rand.Intn(len(ingredients))]
+	}
+
+	var p model.Pizza
+	for range 10 {
+		randomName := restrictions.CustomName
+
+		if randomName == "" {
+			_, nameSpan := tracer.Start(pizzaCtx, "name-generation")
+
+			for {
+				randomName = fmt.Sprintf("%s %s", adjectives[rand.Intn(len(adjectives))], names[rand.Intn(len(names))])
+				if strings.HasPrefix(randomName, "A") || strings.HasPrefix(randomName, "E") || strings.HasPrefix(randomName, "I") || strings.HasPrefix(randomName, "O") || strings.HasPrefix(randomName, "U") {
+					randomName = fmt.Sprintf("An %s", randomName)
+				} else {
+					if rand.Intn(100) < 50 {
+						randomName = fmt.Sprintf("The %s", randomName)
+					} else {
+						randomName = fmt.Sprintf("A %s", randomName)
+					}
+				}
+
+				// Measure how funny the name is. It fails if the name is too funny or too unfunny
+				if rand.Intn(100) < 50 {
+					time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond)
+					break
+				}
+			}
+			nameSpan.End()
+		}
+
+		p = model.Pizza{
+			Name:        randomName,
+			Dough:       doughs[rand.Intn(len(doughs))],
+			Ingredients: []model.Ingredient{pick("olive_oil"), pick("tomato"), pick("mozzarella")},
+			Tool:        validTools[rand.Intn(len(validTools))],
+		}
+
+		// Compute how many extra toppings we are allowed to add. If any, randomize that number.
+		extraToppings := restrictions.MaxNumberOfToppings - restrictions.MinNumberOfToppings
+		if extraToppings > 0 {
+			extraToppings = rand.Intn(extraToppings + 1)
+		}
+
+		for range extraToppings + restrictions.MinNumberOfToppings {
+			p.Ingredients = append(p.Ingredients, pick("topping"))
+		}
+
+		uniqueIngredients := make(map[string]model.Ingredient)
+		for _, ingredient := range p.Ingredients {
+			uniqueIngredients[ingredient.Name] = ingredient
+		}
+		p.Ingredients = make([]model.Ingredient, 0)
+		for _, ingredient := range uniqueIngredients {
+			p.Ingredients = append(p.Ingredients, ingredient)
+		}
+
+		if p.CalculateCalories() > restrictions.MaxCaloriesPerSlice {
+			continue
+		}
+
+		break
+	}
+
+	return p, nil
+}
@@ -1,10 +1,8 @@
 package util
 
 import (
+	crand "crypto/rand"
 	"math/rand"
-	"os"
-	"strconv"
-	"time"
 )
 
 const (
@@ -13,38 +11,27 @@ const (
 
 var characters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
+// GenerateAlphaNumToken returns a random alphanumeric string of the given length, suitable to be used as a secret.
 func GenerateAlphaNumToken(length int) string {
-	data := make([]rune, length)
-	for i := range data {
-		// NOTE: This should use a cryptographically-safe random
-		// number generator instead.
-		data[i] = characters[rand.Intn(len(characters))]
+	// Bytes at or above maxByte are discarded, so that every character is equally likely.
+	maxByte := byte(256 - 256%len(characters))
+
+	data := make([]rune, 0, length)
+	buf := make([]byte, length)
+	for len(data) < length {
+		// crypto/rand.Read never returns an error.
+		_, _ = crand.Read(buf)
+		for _, b := range buf {
+			if b >= maxByte || len(data) == length {
+				continue
+			}
+			data = append(data, characters[int(b)%len(characters)])
+		}
 	}
 	return string(data)
 }
 
-// DelayIfEnvSet applies a delay in milliseconds if the specified environment variable is set.
-// The environment variable should contain an integer value representing milliseconds.
-func DelayIfEnvSet(envVarName string) {
-	if delayStr, ok := os.LookupEnv(envVarName); ok {
-		delayMs, _ := strconv.Atoi(delayStr)
-		time.Sleep(time.Duration(delayMs) * time.Millisecond)
-	}
-}
-
-// FailRandomlyIfEnvSet checks if this request should fail based on a random percentage.
-// The environment variable should contain an integer value between 0 and 100 representing the failure rate percentage.
-// Invalid values are treated as 0 (no failures).
-func FailRandomlyIfEnvSet(envVarName string) bool {
-	rateStr, ok := os.LookupEnv(envVarName)
-	if !ok {
-		return false
-	}
-
-	rate, err := strconv.Atoi(rateStr)
-	if err != nil || rate < 0 || rate > 100 {
-		return false
-	}
-
-	return rand.Intn(100) < rate
+// FailRandomly returns true for rate percent of calls, e.g. to make requests fail randomly.
+func FailRandomly(rate int) bool {
+	return rate > 0 && rand.Intn(100) < rate
 }
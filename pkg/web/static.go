@@ -9,3 +9,9 @@ var Static embed.FS
 
 //go:embed test.k6.io
 var TestK6IO embed.FS
+
+//go:embed oauth2
+var OAuth2 embed.FS
+
+//go:embed explorer
+var Explorer embed.FS